@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"github.com/nitram509/ntgrrc/netgear"
+	"io"
+	"os"
+	"strings"
+)
+
+const passwordEnvVar = "NTGRRC_PASSWORD"
+
+// newClient creates a client for the given host or switch name from the config file, which re-uses the stored session (token).
+// With --re-login, the client logs in again, when the session has expired, and stores the new token.
+func newClient(args *GlobalOptions, address string) (*netgear.Client, error) {
+	options := []netgear.ClientOption{netgear.WithVerboseOutput(verboseOutput(args))}
+
+	host := resolveAddress(args, address)
+	args.usedHosts = append(args.usedHosts, host)
+	model, token, err := readTokenAndModel2GlobalOptions(args, host)
+	if err == nil {
+		err = checkExpectedModel(args, host, model)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, netgear.WithSession(model, token))
+	} else if !args.ReLogin {
+		return nil, err
+	}
+
+	if args.ReLogin {
+		options = append(options, netgear.WithReLogin(
+			func() (string, error) {
+				return readPassword(args, host)
+			},
+			func(client *netgear.Client) error {
+				err := checkExpectedModel(args, host, client.Model())
+				if err != nil {
+					return err
+				}
+				args.model = client.Model()
+				args.token = client.Token()
+				return storeToken(args, host, client.Token())
+			}))
+	}
+	return netgear.NewClient(host, options...), nil
+}
+
+// touchUsedTokens records the successful use of the sessions of all hosts, a client was created for
+func touchUsedTokens(args *GlobalOptions) {
+	for _, host := range args.usedHosts {
+		err := touchToken(args, host)
+		if err != nil && args.Verbose {
+			fmt.Println("Unable to update the login token's last use: " + err.Error())
+		}
+	}
+}
+
+// readPassword reads the admin console's password from the switch's credential source in the config file,
+// from --password-file or from the NTGRRC_PASSWORD environment variable
+func readPassword(args *GlobalOptions, host string) (string, error) {
+	password, err := readSwitchPassword(args, host)
+	if err != nil || password != "" {
+		return password, err
+	}
+	if args.PasswordFile != "" {
+		return readPasswordFile(args.PasswordFile)
+	}
+	if password := os.Getenv(passwordEnvVar); password != "" {
+		return password, nil
+	}
+	return "", newCommandError(categoryInvalidArgument, "no password for re-login given; use --password-file or the %s environment variable", passwordEnvVar)
+}
+
+// readSwitchPassword reads the password from the switch's credential source in the config file, if any
+func readSwitchPassword(args *GlobalOptions, host string) (string, error) {
+	target := args.config.lookupSwitch(host)
+	switch {
+	case target.config == nil:
+		return "", nil
+	case target.config.PasswordFile != "":
+		return readPasswordFile(expandHome(target.config.PasswordFile))
+	case target.config.PasswordEnv != "":
+		password := os.Getenv(target.config.PasswordEnv)
+		if password == "" {
+			return "", newCommandError(categoryInvalidArgument, "the environment variable %s, configured for switch '%s', is empty", target.config.PasswordEnv, target.Name)
+		}
+		return password, nil
+	}
+	return "", nil
+}
+
+func readPasswordFile(fileName string) (string, error) {
+	bytes, err := os.ReadFile(fileName)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(bytes), "\r\n"), nil
+}
+
+func verboseOutput(args *GlobalOptions) io.Writer {
+	if args.Verbose {
+		return os.Stdout
+	}
+	return io.Discard
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/ntgrrc/netgear"
+	"github.com/nitram509/ntgrrc/netgear/emulator"
+)
+
+// loggedInEmulator starts an emulated switch and logs in to it, like the login command does.
+// It returns the options with the stored session and the switch's address.
+func loggedInEmulator(t *testing.T, model netgear.NetgearModel) (*GlobalOptions, string) {
+	_, args, host := loggedInEmulatorSwitch(t, model)
+	return args, host
+}
+
+// loggedInEmulatorSwitch is loggedInEmulator, which returns the emulated switch too, to change its state
+func loggedInEmulatorSwitch(t *testing.T, model netgear.NetgearModel) (*emulator.Switch, *GlobalOptions, string) {
+	args := &GlobalOptions{TokenDir: t.TempDir(), Quiet: true}
+	sw, host := loginEmulator(t, args, model)
+	return sw, args, host
+}
+
+// loginEmulator starts an emulated switch and logs in to it with the given options, e.g. to store the sessions
+// of several switches in the same token directory
+func loginEmulator(t *testing.T, args *GlobalOptions, model netgear.NetgearModel) (*emulator.Switch, string) {
+	sw, err := emulator.New(model, "secret")
+	then.AssertThat(t, err, is.Nil())
+	server := httptest.NewServer(sw)
+	t.Cleanup(server.Close)
+	host := strings.TrimPrefix(server.URL, "http://")
+	login := LoginCommand{SwitchSelector: SwitchSelector{Address: []string{host}}, Password: "secret"}
+	then.AssertThat(t, login.Run(args), is.Nil())
+	return sw, host
+}
+
+func Test_re_login_stores_the_new_token(t *testing.T) {
+	// setup
+	server, err := emulator.NewServer(netgear.GS308EPP, "secret")
+	then.AssertThat(t, err, is.Nil())
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	err = os.WriteFile(passwordFile, []byte("secret\n"), 0600)
+	then.AssertThat(t, err, is.Nil())
+	args := GlobalOptions{
+		TokenDir:     t.TempDir(),
+		ReLogin:      true,
+		PasswordFile: passwordFile,
+		model:        netgear.GS30xEPx,
+	}
+	// given
+	err = storeToken(&args, host, "expired")
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	client, err := newClient(&args, host)
+	then.AssertThat(t, err, is.Nil())
+	_, err = client.PoeStatus()
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	args.model = ""
+	args.token = ""
+	_, token, err := readTokenAndModel2GlobalOptions(&args, host)
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, token, is.EqualTo(client.Token()))
+	then.AssertThat(t, token, is.Not(is.EqualTo("expired")))
+}
+
+func Test_re_login_reads_password_from_environment(t *testing.T) {
+	t.Setenv(passwordEnvVar, "secret")
+
+	password, err := readPassword(&GlobalOptions{}, "")
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, password, is.EqualTo("secret"))
+}
+
+func Test_re_login_without_password_fails(t *testing.T) {
+	t.Setenv(passwordEnvVar, "")
+
+	_, err := readPassword(&GlobalOptions{}, "")
+
+	then.AssertThat(t, err, is.Not(is.Nil()))
+}
+
+func Test_logout_ends_the_session_and_deletes_the_token(t *testing.T) {
+	// given
+	args, host := loggedInEmulator(t, netgear.GS308EPP)
+	model, token, err := readTokenAndModel2GlobalOptions(args, host)
+	then.AssertThat(t, err, is.Nil())
+	staleClient := netgear.NewClient(host, netgear.WithSession(model, token))
+
+	// when
+	logout := LogoutCommand{Address: host}
+	err = logout.Run(args)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	_, err = os.Stat(tokenFilename(args.TokenDir, host))
+	then.AssertThat(t, os.IsNotExist(err), is.True())
+	_, err = staleClient.PoeStatus()
+	then.AssertThat(t, errors.Is(err, netgear.ErrLoginRequired), is.True())
+}
+
+func Test_logout_from_gs316_deletes_the_token_only(t *testing.T) {
+	args, host := loggedInEmulator(t, netgear.GS316EP)
+
+	logout := LogoutCommand{Address: host}
+	err := logout.Run(args)
+
+	then.AssertThat(t, err, is.Nil())
+	_, err = os.Stat(tokenFilename(args.TokenDir, host))
+	then.AssertThat(t, os.IsNotExist(err), is.True())
+}
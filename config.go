@@ -0,0 +1,309 @@
+package main
+
+import (
+	"errors"
+	"github.com/alecthomas/kong"
+	"github.com/nitram509/ntgrrc/netgear"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const configEnvVar = "NTGRRC_CONFIG"
+
+// Config is the content of the config file, which defines the switches by name and groups of them
+type Config struct {
+	// Defaults for the global flags, e.g. 'token-dir: /var/lib/ntgrrc'
+	Defaults map[string]string       `yaml:"defaults"`
+	Switches map[string]SwitchConfig `yaml:"switches"`
+	Groups   map[string][]string     `yaml:"groups"`
+	// Schedules switch the PoE power of ports by the time, see "schedule run"
+	Schedules []PoeSchedule `yaml:"schedules"`
+	// Watchdogs power cycle the ports of hung devices, see "poe watchdog"
+	Watchdogs []PoeWatchdog `yaml:"watchdogs"`
+	// Notifications are sent on PoE faults and recoveries, see "poe monitor"
+	Notifications []NotificationSink `yaml:"notifications"`
+}
+
+// SwitchConfig describes a single switch
+type SwitchConfig struct {
+	Address string `yaml:"address"`
+	// Model is optional; when given, a login to a switch of another model fails
+	Model netgear.NetgearModel `yaml:"model"`
+	// PasswordFile or PasswordEnv are the credential source for the login and --re-login
+	PasswordFile string       `yaml:"password-file"`
+	PasswordEnv  string       `yaml:"password-env"`
+	OutputFormat OutputFormat `yaml:"output-format"`
+	// PoeBudget is the total PoE power budget in watts, when it's not known for the model, e.g. for GS30x switches
+	PoeBudget float64 `yaml:"poe-budget"`
+}
+
+// switchTarget is a switch to run a command for, with its config, if it's defined in the config file
+type switchTarget struct {
+	Name    string
+	Address string
+	config  *SwitchConfig
+}
+
+// SwitchSelector selects one or more switches, by address, by name or by group from the config file
+type SwitchSelector struct {
+	Address []string `help:"the Netgear switch's IP address or host name to connect to, or its name from the config file; use multiple times for several switches" short:"a" xor:"target" required:""`
+	Group   string   `help:"run for all switches of a group from the config file" short:"g" xor:"target" required:""`
+	All     bool     `help:"run for all switches from the config file" xor:"target" required:""`
+}
+
+// defaultConfigFileName is ~/.config/ntgrrc/config.yaml, or the equivalent on Windows and MacOS
+func defaultConfigFileName() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(configDir, "ntgrrc", "config.yaml")
+}
+
+// configFileName finds the config file before kong parses the command line, because the config file
+// provides defaults for the flags: --config, the NTGRRC_CONFIG environment variable or the default location
+func configFileName(osArgs []string) (fileName string, explicit bool) {
+	for i, arg := range osArgs {
+		if arg == "--" {
+			break
+		}
+		if value, ok := strings.CutPrefix(arg, "--config="); ok {
+			return expandHome(value), true
+		}
+		if arg == "--config" && i+1 < len(osArgs) {
+			return expandHome(osArgs[i+1]), true
+		}
+	}
+	if fileName := os.Getenv(configEnvVar); fileName != "" {
+		return expandHome(fileName), true
+	}
+	return defaultConfigFileName(), false
+}
+
+// outputFormatArg finds the output format before kong parses the command line, thus an error of loading
+// the config file is reported in the format given with -f or --output-format; the default is markdown
+func outputFormatArg(osArgs []string) OutputFormat {
+	format := MarkdownFormat
+	for i, arg := range osArgs {
+		if arg == "--" {
+			break
+		}
+		if value, ok := strings.CutPrefix(arg, "--output-format="); ok {
+			format = OutputFormat(value)
+		} else if (arg == "-f" || arg == "--output-format") && i+1 < len(osArgs) {
+			format = OutputFormat(osArgs[i+1])
+		}
+	}
+	return format
+}
+
+// loadConfig reads the config file; a missing file is only an error, when it was given explicitly
+func loadConfig(fileName string, explicit bool) (*Config, error) {
+	config := &Config{}
+	if fileName == "" {
+		return config, nil
+	}
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(data, config)
+	if err != nil {
+		return nil, newCommandError(categoryInvalidArgument, "invalid config file '%s': %w", fileName, err)
+	}
+	return config, config.validate()
+}
+
+func (config *Config) validate() error {
+	for name, sw := range config.Switches {
+		if sw.Address == "" {
+			return newCommandError(categoryInvalidArgument, "config file: switch '%s' has no address", name)
+		}
+		if sw.Model != "" && !netgear.IsSupportedModel(string(sw.Model)) {
+			return newCommandError(categoryInvalidArgument, "config file: switch '%s' has an unknown model '%s'", name, sw.Model)
+		}
+		if sw.OutputFormat != "" && !slices.Contains([]OutputFormat{MarkdownFormat, JsonFormat, CsvFormat, TsvFormat, InfluxFormat}, sw.OutputFormat) {
+			return newCommandError(categoryInvalidArgument, "config file: switch '%s' has an unknown output format '%s'", name, sw.OutputFormat)
+		}
+	}
+	for group, names := range config.Groups {
+		for _, name := range names {
+			if _, ok := config.Switches[name]; !ok {
+				return newCommandError(categoryInvalidArgument, "config file: group '%s' contains the unknown switch '%s'", group, name)
+			}
+		}
+	}
+	for i, schedule := range config.Schedules {
+		if schedule.Name == "" {
+			return newCommandError(categoryInvalidArgument, "config file: schedule #%d has no name", i+1)
+		}
+		if _, ok := config.Groups[schedule.Group]; schedule.Group != "" && !ok {
+			return newCommandError(categoryInvalidArgument, "config file: schedule '%s' has the unknown group '%s'", schedule.Name, schedule.Group)
+		}
+		err := schedule.validate()
+		if err != nil {
+			return newCommandError(categoryInvalidArgument, "config file: schedule '%s' %w", schedule.Name, err)
+		}
+	}
+	for i, watchdog := range config.Watchdogs {
+		if watchdog.Name == "" {
+			return newCommandError(categoryInvalidArgument, "config file: watchdog #%d has no name", i+1)
+		}
+		err := watchdog.validate()
+		if err != nil {
+			return newCommandError(categoryInvalidArgument, "config file: watchdog '%s' %w", watchdog.Name, err)
+		}
+	}
+	for i, sink := range config.Notifications {
+		if sink.Name == "" {
+			return newCommandError(categoryInvalidArgument, "config file: notification #%d has no name", i+1)
+		}
+		err := sink.validate()
+		if err != nil {
+			return newCommandError(categoryInvalidArgument, "config file: notification '%s' %w", sink.Name, err)
+		}
+	}
+	return nil
+}
+
+// resolver provides the defaults from the config file for the global flags;
+// flags given on the command line take precedence
+func (config *Config) resolver() kong.Resolver {
+	return kong.ResolverFunc(func(context *kong.Context, parent *kong.Path, flag *kong.Flag) (any, error) {
+		if parent.App == nil {
+			return nil, nil
+		}
+		value, ok := config.Defaults[flag.Name]
+		if !ok {
+			return nil, nil
+		}
+		return value, nil
+	})
+}
+
+// lookupSwitch finds a switch by name, or by address, in the config file.
+// Unknown switches are targeted by the given address.
+func (config *Config) lookupSwitch(address string) switchTarget {
+	if config != nil {
+		if sw, ok := config.Switches[address]; ok {
+			return switchTarget{Name: address, Address: sw.Address, config: &sw}
+		}
+		for _, name := range config.switchNames() {
+			sw := config.Switches[name]
+			if sw.Address == address {
+				return switchTarget{Name: name, Address: sw.Address, config: &sw}
+			}
+		}
+	}
+	return switchTarget{Name: address, Address: address}
+}
+
+// switchNames lists all switches, alphabetically sorted
+func (config *Config) switchNames() []string {
+	var names []string
+	for name := range config.Switches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// configFile is the content of the config file; it's empty without a config file, e.g. in tests
+func (args *GlobalOptions) configFile() *Config {
+	if args.config == nil {
+		return &Config{}
+	}
+	return args.config
+}
+
+// targets resolves the selected switches
+func (selector *SwitchSelector) targets(args *GlobalOptions) ([]switchTarget, error) {
+	config := args.configFile()
+	var names []string
+	switch {
+	case selector.All:
+		names = config.switchNames()
+		if len(names) == 0 {
+			return nil, newCommandError(categoryInvalidArgument, "there are no switches in the config file")
+		}
+	case selector.Group != "":
+		group, ok := config.Groups[selector.Group]
+		if !ok {
+			return nil, newCommandError(categoryInvalidArgument, "unknown group '%s'; there's no such group in the config file", selector.Group)
+		}
+		names = group
+	default:
+		names = selector.Address
+	}
+	var targets []switchTarget
+	for _, name := range names {
+		targets = append(targets, config.lookupSwitch(name))
+	}
+	return targets, nil
+}
+
+// isMultiple is true, when more than one switch, or a group of switches, is selected
+func (selector *SwitchSelector) isMultiple() bool {
+	return selector.All || selector.Group != "" || len(selector.Address) > 1
+}
+
+// resolveAddress returns the switch's address for a name from the config file, or the given address as is
+func resolveAddress(args *GlobalOptions, address string) string {
+	return args.config.lookupSwitch(address).Address
+}
+
+// outputFormat is the format given by the -f flag or, if not given on the command line, the switch's format
+func outputFormat(args *GlobalOptions, address string) OutputFormat {
+	if args.outputFormatGiven {
+		return args.OutputFormat
+	}
+	target := args.config.lookupSwitch(address)
+	if target.config != nil && target.config.OutputFormat != "" {
+		return target.config.OutputFormat
+	}
+	return args.OutputFormat
+}
+
+// checkExpectedModel compares the switch's model with the model from the config file, if any.
+// The GS305EP(P) and GS308EP(P) models can't be told apart at login, thus the model family GS30xEPx matches all of them.
+func checkExpectedModel(args *GlobalOptions, address string, model netgear.NetgearModel) error {
+	target := args.config.lookupSwitch(address)
+	if target.config == nil || target.config.Model == "" || target.config.Model == model {
+		return nil
+	}
+	if model == netgear.GS30xEPx {
+		expectedDriver, _ := netgear.DriverFor(target.config.Model)
+		driver, _ := netgear.DriverFor(model)
+		if expectedDriver == driver {
+			return nil
+		}
+	}
+	return newCommandError(categoryInvalidArgument, "switch '%s' is a %s, but the config file expects a %s", target.Name, model, target.config.Model)
+}
+
+// isFlagGiven checks, if the flag was given on the command line, in contrast to defaults or the config file
+func isFlagGiven(ctx *kong.Context, name string) bool {
+	for _, path := range ctx.Path {
+		if path.Flag != nil && path.Flag.Name == name && !path.Resolved {
+			return true
+		}
+	}
+	return false
+}
+
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/kong"
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/ntgrrc/netgear"
+)
+
+const testConfig = `
+defaults:
+  token-dir: /tmp/ntgrrc-test
+  output-format: json
+switches:
+  office-1:
+    address: 192.168.0.2
+    model: GS308EPP
+    password-env: NTGRRC_TEST_OFFICE_PASSWORD
+  office-2:
+    address: 192.168.0.3
+    output-format: md
+    poe-budget: 60
+  lab-1:
+    address: 10.0.0.2
+groups:
+  office: [office-1, office-2]
+`
+
+func loadTestConfig(t *testing.T, content string) (*Config, error) {
+	fileName := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(fileName, []byte(content), 0600)
+	then.AssertThat(t, err, is.Nil())
+	return loadConfig(fileName, true)
+}
+
+func Test_config_file_is_found_before_parsing_the_command_line(t *testing.T) {
+	t.Setenv(configEnvVar, "/etc/ntgrrc.yaml")
+
+	fileName, explicit := configFileName([]string{"--config", "/tmp/ntgrrc.yaml", "poe", "status"})
+	then.AssertThat(t, fileName, is.EqualTo("/tmp/ntgrrc.yaml"))
+	then.AssertThat(t, explicit, is.True())
+
+	fileName, explicit = configFileName([]string{"poe", "status", "--config=/tmp/other.yaml"})
+	then.AssertThat(t, fileName, is.EqualTo("/tmp/other.yaml"))
+	then.AssertThat(t, explicit, is.True())
+
+	fileName, explicit = configFileName([]string{"poe", "status"})
+	then.AssertThat(t, fileName, is.EqualTo("/etc/ntgrrc.yaml"))
+	then.AssertThat(t, explicit, is.True())
+}
+
+func Test_output_format_is_found_before_parsing_the_command_line(t *testing.T) {
+	then.AssertThat(t, outputFormatArg([]string{"-f", "json", "poe", "status"}), is.EqualTo(JsonFormat))
+	then.AssertThat(t, outputFormatArg([]string{"poe", "status", "--output-format", "csv"}), is.EqualTo(CsvFormat))
+	then.AssertThat(t, outputFormatArg([]string{"poe", "status", "--output-format=json"}), is.EqualTo(JsonFormat))
+	then.AssertThat(t, outputFormatArg([]string{"poe", "status"}), is.EqualTo(MarkdownFormat))
+	then.AssertThat(t, outputFormatArg([]string{"poe", "status", "--", "-f", "json"}), is.EqualTo(MarkdownFormat))
+}
+
+func Test_missing_default_config_file_is_no_error(t *testing.T) {
+	config, err := loadConfig(filepath.Join(t.TempDir(), "config.yaml"), false)
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, config.Switches == nil, is.True())
+}
+
+func Test_config_file_with_unknown_switch_in_group_is_rejected(t *testing.T) {
+	_, err := loadTestConfig(t, "switches:\n  a:\n    address: 10.0.0.1\ngroups:\n  g: [a, b]\n")
+
+	then.AssertThat(t, err, is.Not(is.Nil()))
+}
+
+func Test_switch_is_found_by_name_or_address(t *testing.T) {
+	config, err := loadTestConfig(t, testConfig)
+	then.AssertThat(t, err, is.Nil())
+
+	byName := config.lookupSwitch("office-1")
+	byAddress := config.lookupSwitch("192.168.0.2")
+	unknown := config.lookupSwitch("10.1.1.1")
+
+	then.AssertThat(t, byName.Address, is.EqualTo("192.168.0.2"))
+	then.AssertThat(t, byAddress.Name, is.EqualTo("office-1"))
+	then.AssertThat(t, unknown.Address, is.EqualTo("10.1.1.1"))
+	then.AssertThat(t, unknown.config == nil, is.True())
+}
+
+func Test_selector_targets_groups_and_all_switches(t *testing.T) {
+	config, err := loadTestConfig(t, testConfig)
+	then.AssertThat(t, err, is.Nil())
+	args := GlobalOptions{config: config}
+
+	group, err := (&SwitchSelector{Group: "office"}).targets(&args)
+	then.AssertThat(t, err, is.Nil())
+	all, err := (&SwitchSelector{All: true}).targets(&args)
+	then.AssertThat(t, err, is.Nil())
+	_, err = (&SwitchSelector{Group: "unknown"}).targets(&args)
+
+	then.AssertThat(t, group, has.Length[switchTarget](2))
+	then.AssertThat(t, group[1].Address, is.EqualTo("192.168.0.3"))
+	then.AssertThat(t, all, has.Length[switchTarget](3))
+	then.AssertThat(t, all[0].Name, is.EqualTo("lab-1"))
+	then.AssertThat(t, err, is.Not(is.Nil()))
+}
+
+func Test_config_file_provides_defaults_for_global_flags(t *testing.T) {
+	config, err := loadTestConfig(t, testConfig)
+	then.AssertThat(t, err, is.Nil())
+	var options struct {
+		TokenDir     string         `default:""`
+		OutputFormat OutputFormat   `enum:"md,json" default:"md" short:"f"`
+		Version      VersionCommand `cmd:""`
+	}
+	parser, err := kong.New(&options, kong.Resolvers(config.resolver()))
+	then.AssertThat(t, err, is.Nil())
+
+	ctx, err := parser.Parse([]string{"--token-dir", "/tmp/given", "version"})
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, options.TokenDir, is.EqualTo("/tmp/given"))
+	then.AssertThat(t, options.OutputFormat, is.EqualTo(JsonFormat))
+	then.AssertThat(t, isFlagGiven(ctx, "token-dir"), is.True())
+	then.AssertThat(t, isFlagGiven(ctx, "output-format"), is.False())
+}
+
+func Test_switch_output_format_is_used_unless_given_on_the_command_line(t *testing.T) {
+	config, err := loadTestConfig(t, testConfig)
+	then.AssertThat(t, err, is.Nil())
+	args := GlobalOptions{config: config, OutputFormat: JsonFormat}
+
+	then.AssertThat(t, outputFormat(&args, "office-2"), is.EqualTo(MarkdownFormat))
+	then.AssertThat(t, outputFormat(&args, "office-1"), is.EqualTo(JsonFormat))
+	args.outputFormatGiven = true
+	then.AssertThat(t, outputFormat(&args, "office-2"), is.EqualTo(JsonFormat))
+}
+
+func Test_expected_model_from_config_file(t *testing.T) {
+	config, err := loadTestConfig(t, testConfig)
+	then.AssertThat(t, err, is.Nil())
+	args := GlobalOptions{config: config}
+
+	then.AssertThat(t, checkExpectedModel(&args, "office-1", netgear.GS308EPP), is.Nil())
+	then.AssertThat(t, checkExpectedModel(&args, "office-1", netgear.GS30xEPx), is.Nil())
+	then.AssertThat(t, checkExpectedModel(&args, "office-1", netgear.GS316EP), is.Not(is.Nil()))
+	then.AssertThat(t, checkExpectedModel(&args, "lab-1", netgear.GS316EP), is.Nil())
+}
+
+func Test_password_is_read_from_the_switch_credential_source(t *testing.T) {
+	config, err := loadTestConfig(t, testConfig)
+	then.AssertThat(t, err, is.Nil())
+	t.Setenv("NTGRRC_TEST_OFFICE_PASSWORD", "office-secret")
+	t.Setenv(passwordEnvVar, "secret")
+	args := GlobalOptions{config: config}
+
+	officePassword, err := readPassword(&args, "192.168.0.2")
+	then.AssertThat(t, err, is.Nil())
+	labPassword, err := readPassword(&args, "10.0.0.2")
+	then.AssertThat(t, err, is.Nil())
+
+	then.AssertThat(t, officePassword, is.EqualTo("office-secret"))
+	then.AssertThat(t, labPassword, is.EqualTo("secret"))
+}
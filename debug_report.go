@@ -2,24 +2,27 @@ package main
 
 import (
 	"fmt"
+	"github.com/nitram509/ntgrrc/netgear"
 )
 
 type DebugReportCommand struct {
-	Address string `required:"" help:"the Netgear switch's IP address or host name to connect to" short:"a"`
+	Address string `required:"" help:"the Netgear switch's IP address or host name to connect to, or its name from the config file" short:"a"`
 }
 
 func (drc *DebugReportCommand) Run(args *GlobalOptions) error {
 	args.Verbose = true
-	model, _, err := readTokenAndModel2GlobalOptions(args, drc.Address)
+	client, err := newClient(args, drc.Address)
 	if err != nil {
 		fmt.Println("Warning, prior error: " + err.Error())
-		printDebugNotLoggedIn(args, drc.Address, err)
+		host := resolveAddress(args, drc.Address)
+		client = netgear.NewClient(host, netgear.WithVerboseOutput(verboseOutput(args)))
+		printDebugNotLoggedIn(client, host, err)
 	}
-	printDebugLoggedIn(args, model, drc.Address)
+	printDebugLoggedIn(client)
 	return nil
 }
 
-func printDebugNotLoggedIn(args *GlobalOptions, host string, err error) {
+func printDebugNotLoggedIn(client *netgear.Client, host string, err error) {
 	fmt.Println("---[DEBUG: not logged in]---")
 	fmt.Println(fmt.Sprintf("Not logged in error: %s", err))
 	fmt.Println("Please try to login and run `debug-report` command again, in order to detect the model and get even more debug information")
@@ -30,7 +33,7 @@ func printDebugNotLoggedIn(args *GlobalOptions, host string, err error) {
 		fmt.Sprintf("http://%s/redirect.html", host),
 	}
 	for _, reqUrl := range reqUrls {
-		body, err := doUnauthenticatedHttpRequestAndReadResponse(args, "GET", reqUrl, "")
+		body, err := client.RequestPageUnauthenticated(reqUrl)
 		fmt.Println(fmt.Sprintf("---[RESPONSE: %s]---", reqUrl))
 		if err != nil {
 			fmt.Println("ERROR: " + err.Error())
@@ -42,37 +45,16 @@ func printDebugNotLoggedIn(args *GlobalOptions, host string, err error) {
 	fmt.Println("---[/DEBUG]---")
 }
 
-func printDebugLoggedIn(args *GlobalOptions, model NetgearModel, host string) {
-	var reqUrls []string
-	if !isModel30x(model) {
-		reqUrls = append(reqUrls,
-			// GS316xx
-			fmt.Sprintf("http://%s/iss/specific/poe.html", host),
-			fmt.Sprintf("http://%s/iss/specific/poePortConf.html", host),
-			fmt.Sprintf("http://%s/iss/specific/poePortStatus.html", host),
-			fmt.Sprintf("http://%s/iss/specific/poePortStatus.html?GetData=TRUE", host),
-			fmt.Sprintf("http://%s/iss/specific/getPortRate.html", host),
-			fmt.Sprintf("http://%s/iss/specific/dashboard.html", host),
-			fmt.Sprintf("http://%s/iss/specific/homepage.html", host),
-		)
-	}
-	if !isModel316(model) {
-		reqUrls = append(reqUrls,
-			// GS30xxx
-			fmt.Sprintf("http://%s/getPoePortStatus.cgi", host),
-			fmt.Sprintf("http://%s/PoEPortConfig.cgi", host),
-			fmt.Sprintf("http://%s/port_status.cgi", host),
-			fmt.Sprintf("http://%s/dashboard.cgi", host),
-		)
-	}
+func printDebugLoggedIn(client *netgear.Client) {
+	reqUrls := client.DebugPageUrls()
 	if len(reqUrls) > 0 {
-		fmt.Println(fmt.Sprintf("---[DEBUG: model '%s']---", model))
+		fmt.Println(fmt.Sprintf("---[DEBUG: model '%s']---", client.Model()))
 		for _, reqUrl := range reqUrls {
-			body, err := doHttpRequestAndReadResponse(args, "GET", host, reqUrl, "")
+			body, err := client.RequestPage(reqUrl)
 			fmt.Println(fmt.Sprintf("---[RESPONSE: %s]---", reqUrl))
 			if err != nil {
 				fmt.Println("ERROR: " + err.Error())
-			} else if checkIsLoginRequired(body) {
+			} else if netgear.IsLoginRequired(body) {
 				fmt.Println("WARN: it seems the session token expired, please re-login")
 			} else {
 				fmt.Println(body)
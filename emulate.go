@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"github.com/nitram509/ntgrrc/netgear"
+	"github.com/nitram509/ntgrrc/netgear/emulator"
+	"net"
+	"net/http"
+	"time"
+)
+
+type EmulateCommand struct {
+	Model        string        `required:"" help:"the switch model to emulate [GS305EP, GS305EPP, GS308EP, GS308EPP, GS316EP, GS316EPP]" enum:"GS305EP,GS305EPP,GS308EP,GS308EPP,GS316EP,GS316EPP" short:"m"`
+	Listen       string        `help:"the address (host:port) to listen on" default:"127.0.0.1:8080" short:"l"`
+	Password     string        `help:"the admin console's password, the emulated switch accepts" default:"password" short:"p"`
+	PowerUpDelay time.Duration `help:"the time, the powered device needs to draw power again, after its port was power cycled or enabled, e.g. '5s'" placeholder:"DURATION"`
+}
+
+func (emulate *EmulateCommand) Run(args *GlobalOptions) error {
+	sw, err := emulator.New(netgear.NetgearModel(emulate.Model), emulate.Password)
+	if err != nil {
+		return err
+	}
+	sw.SetPowerUpDelay(emulate.PowerUpDelay)
+	listener, err := net.Listen("tcp", emulate.Listen)
+	if err != nil {
+		return err
+	}
+	if !args.Quiet {
+		fmt.Printf("Emulating a Netgear %s at %s, login with: ntgrrc login --address %s --password '%s'\n",
+			sw.Model(), listener.Addr(), listener.Addr(), emulate.Password)
+	}
+	return http.Serve(listener, sw)
+}
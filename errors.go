@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nitram509/ntgrrc/netgear"
+	"net"
+	"os"
+)
+
+// errorCategory is the kind of error, with a stable exit code for scripts and the code of the JSON error object
+type errorCategory struct {
+	code     string
+	exitCode int
+}
+
+var (
+	categoryGeneral         = errorCategory{code: "error", exitCode: 1}
+	categoryInvalidArgument = errorCategory{code: "invalid_argument", exitCode: 2}
+	categoryNotLoggedIn     = errorCategory{code: "not_logged_in", exitCode: 3}
+	categoryLoginFailed     = errorCategory{code: "login_failed", exitCode: 4}
+	categoryUnreachable     = errorCategory{code: "unreachable", exitCode: 5}
+	categoryChangeRejected  = errorCategory{code: "change_rejected", exitCode: 6}
+	categoryNotSupported    = errorCategory{code: "not_supported", exitCode: 7}
+	categorySwitchesFailed  = errorCategory{code: "switches_failed", exitCode: 8}
+	categoryPowerUpTimeout  = errorCategory{code: "power_up_timeout", exitCode: 9}
+)
+
+// kongUsageExitCode is the exit code of kong, when parsing the command line fails
+const kongUsageExitCode = 80
+
+// commandError is an error of a category and/or about a host; errors without a category are categorized
+// by the wrapped error, e.g. netgear.ErrLoginRequired
+type commandError struct {
+	category errorCategory
+	host     string
+	err      error
+}
+
+func (e *commandError) Error() string {
+	return e.err.Error()
+}
+
+func (e *commandError) Unwrap() error {
+	return e.err
+}
+
+func newCommandError(category errorCategory, format string, a ...any) error {
+	return &commandError{category: category, err: fmt.Errorf(format, a...)}
+}
+
+// withHost adds the switch's host to the error, for the JSON error object
+func withHost(host string, err error) error {
+	if err == nil || errorHost(err) != "" {
+		return err
+	}
+	return &commandError{host: host, err: err}
+}
+
+// findCommandError walks the tree of wrapped errors, e.g. joined by kong, for the first matching commandError
+func findCommandError(err error, matches func(commandErr *commandError) bool) *commandError {
+	if commandErr, ok := err.(*commandError); ok && matches(commandErr) {
+		return commandErr
+	}
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		return findCommandError(e.Unwrap(), matches)
+	case interface{ Unwrap() []error }:
+		for _, wrapped := range e.Unwrap() {
+			if commandErr := findCommandError(wrapped, matches); commandErr != nil {
+				return commandErr
+			}
+		}
+	}
+	return nil
+}
+
+func errorCategoryOf(err error) errorCategory {
+	if commandErr := findCommandError(err, func(e *commandError) bool { return e.category.code != "" }); commandErr != nil {
+		return commandErr.category
+	}
+	var loginFailed *netgear.LoginFailedError
+	var changeRejected *netgear.ChangeRejectedError
+	var portOutOfRange *netgear.PortOutOfRangeError
+	var invalidSetting *netgear.InvalidSettingError
+	var powerUpTimeout *netgear.PowerUpTimeoutError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, netgear.ErrNoSession) || errors.Is(err, netgear.ErrLoginRequired):
+		return categoryNotLoggedIn
+	case errors.As(err, &loginFailed):
+		return categoryLoginFailed
+	case errors.As(err, &changeRejected):
+		return categoryChangeRejected
+	case errors.Is(err, netgear.ErrNoPassword) || errors.As(err, &portOutOfRange) || errors.As(err, &invalidSetting):
+		return categoryInvalidArgument
+	case errors.Is(err, netgear.ErrNotSupported):
+		return categoryNotSupported
+	case errors.As(err, &powerUpTimeout):
+		return categoryPowerUpTimeout
+	case errors.As(err, &netErr):
+		return categoryUnreachable
+	}
+	return categoryGeneral
+}
+
+func errorHost(err error) string {
+	if commandErr := findCommandError(err, func(e *commandError) bool { return e.host != "" }); commandErr != nil {
+		return commandErr.host
+	}
+	return ""
+}
+
+// jsonError is the error object of the JSON output
+type jsonError struct {
+	SchemaVersion int `json:"schema_version"`
+	Error         struct {
+		Code     string `json:"code"`
+		ExitCode int    `json:"exit_code"`
+		Message  string `json:"message"`
+		Host     string `json:"host,omitempty"`
+	} `json:"error"`
+}
+
+// reportError prints the error on stderr, as JSON error object in JSON output format, and returns the exit code
+func reportError(format OutputFormat, err error) int {
+	category := errorCategoryOf(err)
+	if format == JsonFormat {
+		doc := jsonError{SchemaVersion: jsonSchemaVersion}
+		doc.Error.Code = category.code
+		doc.Error.ExitCode = category.exitCode
+		doc.Error.Message = err.Error()
+		doc.Error.Host = errorHost(err)
+		bytes, marshalErr := json.Marshal(doc)
+		if marshalErr == nil {
+			fmt.Fprintln(os.Stderr, string(bytes))
+			return category.exitCode
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+	return category.exitCode
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/ntgrrc/netgear"
+)
+
+func Test_error_categories_of_library_errors(t *testing.T) {
+	then.AssertThat(t, errorCategoryOf(netgear.ErrLoginRequired), is.EqualTo(categoryNotLoggedIn))
+	then.AssertThat(t, errorCategoryOf(fmt.Errorf("office-1: %w", netgear.ErrNoSession)), is.EqualTo(categoryNotLoggedIn))
+	then.AssertThat(t, errorCategoryOf(&netgear.LoginFailedError{Reason: "wrong password"}), is.EqualTo(categoryLoginFailed))
+	then.AssertThat(t, errorCategoryOf(&netgear.ChangeRejectedError{Response: "FAILED"}), is.EqualTo(categoryChangeRejected))
+	then.AssertThat(t, errorCategoryOf(&netgear.PortOutOfRangeError{Port: 9, MaxPort: 8}), is.EqualTo(categoryInvalidArgument))
+	then.AssertThat(t, errorCategoryOf(&netgear.NotSupportedError{Model: netgear.GS316EP}), is.EqualTo(categoryNotSupported))
+	then.AssertThat(t, errorCategoryOf(&netgear.PowerUpTimeoutError{Ports: []int{3}}), is.EqualTo(categoryPowerUpTimeout))
+	then.AssertThat(t, errorCategoryOf(errors.New("something else")), is.EqualTo(categoryGeneral))
+}
+
+func Test_error_category_of_unreachable_switch(t *testing.T) {
+	_, err := http.Get("http://localhost:1/")
+
+	then.AssertThat(t, errorCategoryOf(withHost("localhost:1", err)), is.EqualTo(categoryUnreachable))
+}
+
+func Test_with_host_keeps_the_category_and_the_first_host(t *testing.T) {
+	err := withHost("192.168.0.2", newCommandError(categoryInvalidArgument, "unknown column '%s'", "watts"))
+	err = withHost("192.168.0.3", fmt.Errorf("wrapped: %w", err))
+
+	then.AssertThat(t, errorCategoryOf(err), is.EqualTo(categoryInvalidArgument))
+	then.AssertThat(t, errorHost(err), is.EqualTo("192.168.0.2"))
+	then.AssertThat(t, err.Error(), is.EqualTo("wrapped: unknown column 'watts'"))
+}
+
+func Test_error_category_and_host_of_joined_errors(t *testing.T) {
+	err := errors.Join(withHost("192.168.0.2", newCommandError(categorySwitchesFailed, "%d of %d switches failed", 1, 2)))
+
+	then.AssertThat(t, errorCategoryOf(err), is.EqualTo(categorySwitchesFailed))
+	then.AssertThat(t, errorHost(err), is.EqualTo("192.168.0.2"))
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"github.com/nitram509/ntgrrc/netgear"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type ExporterCommand struct {
+	Listen   string   `help:"the address (host:port) to listen on" default:":9720" short:"l"`
+	Address  []string `help:"switches to export on /metrics, by IP address, host name or name from the config file; use multiple times for several switches" short:"a" xor:"target"`
+	Group    string   `help:"export all switches of a group from the config file on /metrics" short:"g" xor:"target"`
+	All      bool     `help:"export all switches from the config file on /metrics" xor:"target"`
+	Parallel int      `help:"maximum number of switches to query at the same time" default:"4"`
+	// AllowUnknownTargets is opt-in, because the exporter logs in to the probed switch with the password
+	AllowUnknownTargets bool `help:"allow /probe to query switches, which are neither in the config file nor selected for /metrics; the exporter logs in to them with the password from --password-file or NTGRRC_PASSWORD" name:"allow-unknown-targets"`
+}
+
+// Run serves the metrics of the selected switches on /metrics and of a known switch on /probe?target=...,
+// like the Prometheus blackbox_exporter does.
+func (exporter *ExporterCommand) Run(args *GlobalOptions) error {
+	daemonOptions(args)
+	var targets []switchTarget
+	if len(exporter.Address) > 0 || exporter.Group != "" || exporter.All {
+		selector := SwitchSelector{Address: exporter.Address, Group: exporter.Group, All: exporter.All}
+		var err error
+		targets, err = selector.targets(args)
+		if err != nil {
+			return err
+		}
+	}
+	listener, err := net.Listen("tcp", exporter.Listen)
+	if err != nil {
+		return err
+	}
+	if !args.Quiet {
+		fmt.Printf("Serving metrics at http://%s/metrics and http://%s/probe?target=...\n", listener.Addr(), listener.Addr())
+	}
+	return http.Serve(listener, newExporterHandler(args, targets, exporter.Parallel, exporter.AllowUnknownTargets))
+}
+
+type exporterHandler struct {
+	args     *GlobalOptions
+	targets  []switchTarget
+	parallel int
+	// allowUnknownTargets allows /probe to query any address, not only the switches from the config file and the targets
+	allowUnknownTargets bool
+	hostLocks           hostLocks
+}
+
+func newExporterHandler(args *GlobalOptions, targets []switchTarget, parallel int, allowUnknownTargets bool) http.Handler {
+	handler := &exporterHandler{args: args, targets: targets, parallel: max(parallel, 1), allowUnknownTargets: allowUnknownTargets}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", handler.serveMetrics)
+	mux.HandleFunc("/probe", handler.serveProbe)
+	return mux
+}
+
+func (h *exporterHandler) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	h.reply(w, h.scrapeAll(h.targets))
+}
+
+func (h *exporterHandler) serveProbe(w http.ResponseWriter, r *http.Request) {
+	target := r.URL.Query().Get("target")
+	if target == "" {
+		http.Error(w, "the 'target' parameter is missing", http.StatusBadRequest)
+		return
+	}
+	probed, ok := h.probeTarget(target)
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown target '%s', probe a switch from the config file or from --address, --group or --all, or use --allow-unknown-targets", target), http.StatusBadRequest)
+		return
+	}
+	h.reply(w, h.scrapeAll([]switchTarget{probed}))
+}
+
+// probeTarget finds the switch by name or address in the config file or the targets of /metrics; other addresses
+// are only allowed with --allow-unknown-targets, otherwise anyone, who reaches the exporter, could make it log in
+// with the password to a host of their choice
+func (h *exporterHandler) probeTarget(name string) (switchTarget, bool) {
+	target := h.args.config.lookupSwitch(name)
+	if target.config != nil || h.allowUnknownTargets {
+		return target, true
+	}
+	for _, known := range h.targets {
+		if known.Name == name || known.Address == name {
+			return known, true
+		}
+	}
+	return switchTarget{}, false
+}
+
+func (h *exporterHandler) reply(w http.ResponseWriter, m *metrics) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = m.write(w)
+}
+
+// scrapeAll queries the switches concurrently and returns the metrics in the order of the switches
+func (h *exporterHandler) scrapeAll(targets []switchTarget) *metrics {
+	results := make([]*metrics, len(targets))
+	slots := make(chan struct{}, h.parallel)
+	wg := sync.WaitGroup{}
+	for i, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			results[i] = h.scrape(target)
+		}()
+	}
+	wg.Wait()
+	all := newMetrics()
+	for _, result := range results {
+		all.merge(result)
+	}
+	return all
+}
+
+func (h *exporterHandler) scrape(target switchTarget) *metrics {
+	defer h.hostLocks.lock(target.Address)()
+
+	start := time.Now()
+	m := newMetrics()
+	err := scrapeSwitch(h.args, target, m)
+	up := 1.0
+	if err != nil {
+		up = 0
+		if !h.args.Quiet {
+			fmt.Fprintf(os.Stderr, "Error: %s: %s\n", target.Name, err.Error())
+		}
+	}
+	result := newMetrics()
+	result.gauge("ntgrrc_up", "1, if the switch could be queried, otherwise 0", up, "switch", target.Name)
+	result.gauge("ntgrrc_scrape_duration_seconds", "duration of querying the switch", time.Since(start).Seconds(), "switch", target.Name)
+	if err == nil {
+		result.merge(m)
+	}
+	return result
+}
+
+func scrapeSwitch(args *GlobalOptions, target switchTarget, m *metrics) error {
+	switchArgs := switchOptions(args)
+	client, err := newClient(switchArgs, target.Address)
+	if err != nil {
+		return err
+	}
+	statuses, err := client.PoeStatus()
+	if err != nil {
+		return err
+	}
+	settings, err := client.PortSettings()
+	if err != nil {
+		return err
+	}
+	addPoeStatusMetrics(m, target.Name, statuses)
+	addPortSettingMetrics(m, target.Name, settings)
+	return touchToken(switchArgs, target.Address)
+}
+
+func addPoeStatusMetrics(m *metrics, switchName string, statuses []netgear.PoePortStatus) {
+	for _, status := range statuses {
+		labels := []string{"switch", switchName, "port", strconv.Itoa(int(status.PortIndex)), "port_name", status.PortName}
+		m.gauge("ntgrrc_poe_port_voltage_volts", "PoE output voltage of the port", float64(status.VoltageInVolt), labels...)
+		m.gauge("ntgrrc_poe_port_current_amperes", "PoE output current of the port", float64(status.CurrentInMilliAmps)/1000, labels...)
+		m.gauge("ntgrrc_poe_port_power_watts", "PoE output power of the port", exactFloat64(status.PowerInWatt), labels...)
+		m.gauge("ntgrrc_poe_port_temperature_celsius", "temperature of the port's PoE circuit", float64(status.TemperatureInCelsius), labels...)
+		m.gauge("ntgrrc_poe_port_info", "PoE status, power class and error status of the port", 1,
+			append(labels, "status", status.PoePortStatus, "class", status.PoePowerClass, "error", status.ErrorStatus)...)
+	}
+}
+
+func addPortSettingMetrics(m *metrics, switchName string, settings []netgear.PortSetting) {
+	for _, setting := range settings {
+		labels := []string{"switch", switchName, "port", strconv.Itoa(int(setting.Index)), "port_name", setting.Name}
+		up := 0.0
+		if isPortUp(setting.PortStatus) {
+			up = 1
+		}
+		m.gauge("ntgrrc_port_up", "1, if the port has a link, otherwise 0", up, labels...)
+		m.gauge("ntgrrc_port_link_speed_bits_per_second", "link speed of the port, 0 without link", linkSpeedInBitsPerSecond(setting.LinkSpeed), labels...)
+		m.gauge("ntgrrc_port_info", "link status and link speed of the port, as shown by the switch", 1,
+			append(labels, "status", setting.PortStatus, "link_speed", setting.LinkSpeed)...)
+	}
+}
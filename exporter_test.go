@@ -0,0 +1,120 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/ntgrrc/netgear"
+	"github.com/nitram509/ntgrrc/netgear/emulator"
+)
+
+func scrapeExporter(t *testing.T, handler http.Handler, url string) (int, string) {
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	resp, err := http.Get(server.URL + url)
+	then.AssertThat(t, err, is.Nil())
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	then.AssertThat(t, err, is.Nil())
+	return resp.StatusCode, string(body)
+}
+
+func Test_exporter_probe_re_logins_and_exposes_port_metrics(t *testing.T) {
+	// setup
+	sw, err := emulator.NewServer(netgear.GS308EPP, "secret")
+	then.AssertThat(t, err, is.Nil())
+	defer sw.Close()
+	host := strings.TrimPrefix(sw.URL, "http://")
+	t.Setenv(passwordEnvVar, "secret")
+	args := GlobalOptions{TokenDir: t.TempDir(), ReLogin: true, Quiet: true, model: netgear.GS308EPP}
+	// given
+	err = storeToken(&args, host, "expired")
+	then.AssertThat(t, err, is.Nil())
+	args.model = ""
+
+	// when
+	status, body := scrapeExporter(t, newExporterHandler(&args, nil, 1, true), "/probe?target="+host)
+
+	// then
+	labels := `switch="` + host + `",port="1",port_name=""`
+	then.AssertThat(t, status, is.EqualTo(http.StatusOK))
+	then.AssertThat(t, body, has.Prefix("# HELP ntgrrc_up "))
+	then.AssertThat(t, body, is.StringContaining(`ntgrrc_up{switch="`+host+`"} 1`+"\n"))
+	then.AssertThat(t, body, is.StringContaining("ntgrrc_poe_port_voltage_volts{"+labels+"} 53\n"))
+	then.AssertThat(t, body, is.StringContaining("ntgrrc_poe_port_power_watts{"+labels+"} 5.8\n"))
+	then.AssertThat(t, body, is.StringContaining("ntgrrc_poe_port_info{"+labels+`,status="Delivering Power",class="4",error="No Error"} 1`+"\n"))
+	then.AssertThat(t, body, is.StringContaining("ntgrrc_port_up{"+labels+"} 1\n"))
+	then.AssertThat(t, body, is.StringContaining("ntgrrc_port_link_speed_bits_per_second{"+labels+"} 1e+09\n"))
+	then.AssertThat(t, strings.Count(body, "# TYPE ntgrrc_poe_port_power_watts gauge"), is.EqualTo(1))
+}
+
+func Test_exporter_metrics_reports_unreachable_switches_as_down(t *testing.T) {
+	args := GlobalOptions{TokenDir: t.TempDir(), Quiet: true}
+	targets := []switchTarget{{Name: "office-1", Address: "localhost:1"}}
+
+	status, body := scrapeExporter(t, newExporterHandler(&args, targets, 1, false), "/metrics")
+
+	then.AssertThat(t, status, is.EqualTo(http.StatusOK))
+	then.AssertThat(t, body, is.StringContaining(`ntgrrc_up{switch="office-1"} 0`+"\n"))
+	then.AssertThat(t, strings.Contains(body, "ntgrrc_poe_port"), is.False())
+}
+
+func Test_exporter_probe_requires_target(t *testing.T) {
+	args := GlobalOptions{TokenDir: t.TempDir(), Quiet: true}
+
+	status, _ := scrapeExporter(t, newExporterHandler(&args, nil, 1, false), "/probe")
+
+	then.AssertThat(t, status, is.EqualTo(http.StatusBadRequest))
+}
+
+func Test_exporter_probe_rejects_unknown_targets(t *testing.T) {
+	contacted := false
+	unknown := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { contacted = true }))
+	defer unknown.Close()
+	host := strings.TrimPrefix(unknown.URL, "http://")
+	t.Setenv(passwordEnvVar, "secret")
+	args := GlobalOptions{TokenDir: t.TempDir(), ReLogin: true, Quiet: true}
+
+	status, body := scrapeExporter(t, newExporterHandler(&args, nil, 1, false), "/probe?target="+host)
+
+	then.AssertThat(t, status, is.EqualTo(http.StatusBadRequest))
+	then.AssertThat(t, body, is.EqualTo("unknown target '"+host+"', probe a switch from the config file or from --address, --group or --all, or use --allow-unknown-targets\n"))
+	then.AssertThat(t, contacted, is.False())
+}
+
+func Test_exporter_probes_switches_from_the_config_file_and_the_targets(t *testing.T) {
+	config, err := loadTestConfig(t, testConfig)
+	then.AssertThat(t, err, is.Nil())
+	args := GlobalOptions{TokenDir: t.TempDir(), Quiet: true, config: config}
+	targets := []switchTarget{{Name: "localhost:1", Address: "localhost:1"}}
+	handler := newExporterHandler(&args, targets, 1, false)
+
+	for _, target := range []string{"office-1", "192.168.0.3", "localhost:1"} {
+		status, _ := scrapeExporter(t, handler, "/probe?target="+target)
+
+		then.AssertThat(t, status, is.EqualTo(http.StatusOK).Reason(target))
+	}
+}
+
+func Test_link_speed_in_bits_per_second(t *testing.T) {
+	then.AssertThat(t, linkSpeedInBitsPerSecond("1000M full"), is.EqualTo(1e9))
+	then.AssertThat(t, linkSpeedInBitsPerSecond("100M Half"), is.EqualTo(1e8))
+	then.AssertThat(t, linkSpeedInBitsPerSecond("No Speed"), is.EqualTo(0.0))
+}
+
+func Test_metrics_label_values_are_escaped(t *testing.T) {
+	m := newMetrics()
+	m.gauge("test_metric", "a test", 1, "name", "a \"quoted\" \\ name\n")
+	sb := strings.Builder{}
+
+	err := m.write(&sb)
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, sb.String(), is.EqualTo("# HELP test_metric a test\n# TYPE test_metric gauge\ntest_metric{name=\"a \\\"quoted\\\" \\\\ name\\n\"} 1\n"))
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"github.com/nitram509/ntgrrc/netgear"
+	"os"
+	"sync"
+)
+
+// switchResult is the outcome of a command for a single switch, a table or an error
+type switchResult struct {
+	target switchTarget
+	model  netgear.NetgearModel
+	table  table
+	err    error
+}
+
+// switchQuery reads a table from a single switch; the switch's name is part of the rows in JSON
+type switchQuery func(client *netgear.Client, switchName string) (table, error)
+
+// queryAll runs the query for all selected switches concurrently, with at most 'parallel' switches at a time,
+// and prints a single table, with a 'Switch' column, when more than one switch is selected.
+// The table options filter, sort and select the columns of the table.
+// The switches, which failed, are reported after the table; then, the command fails, too.
+func (selector *SwitchSelector) queryAll(args *GlobalOptions, parallel int, options *TableOptions, item string, query switchQuery) error {
+	targets, err := selector.targets(args)
+	if err != nil {
+		return err
+	}
+	results, t, err := queryTable(args, targets, selector.isMultiple(), parallel, options, item, nil, query)
+	if err != nil {
+		return err
+	}
+	err = printSwitchTable(tableOutput(args, tableAddress(targets, selector.isMultiple())), results, t)
+	if err != nil {
+		return err
+	}
+	return failedSwitches(results)
+}
+
+// queryTable queries the switches and combines their tables; for a single switch, its error is returned,
+// for several switches, the errors are part of the results
+func queryTable(args *GlobalOptions, targets []switchTarget, multiple bool, parallel int, options *TableOptions, item string, clients *clientCache, query switchQuery) ([]switchResult, table, error) {
+	if !multiple {
+		result := querySwitch(args, targets[0], clients, query)
+		if result.err != nil {
+			return nil, table{}, result.err
+		}
+		t, err := options.apply(result.table)
+		return []switchResult{result}, t, err
+	}
+
+	results := fanOut(args, targets, parallel, clients, query)
+	combined := table{item: item}
+	for _, result := range results {
+		if result.err == nil {
+			combined.columns = result.table.columns
+			combined.records = append(combined.records, result.table.records...)
+		}
+	}
+	if len(combined.columns) == 0 {
+		return results, combined, nil
+	}
+	combined, err := options.apply(combined.showColumn("switch"))
+	return results, combined, err
+}
+
+// tableAddress is the address for the output format of a table, which may be configured per switch
+func tableAddress(targets []switchTarget, multiple bool) string {
+	if multiple {
+		return ""
+	}
+	return targets[0].Address
+}
+
+func failedSwitches(results []switchResult) error {
+	failures := 0
+	for _, result := range results {
+		if result.err != nil {
+			failures++
+		}
+	}
+	if failures > 0 {
+		return newCommandError(categorySwitchesFailed, "%d of %d switches failed", failures, len(results))
+	}
+	return nil
+}
+
+// printSwitchTable prints the table and reports the switches, which failed, on stderr; JSON also lists all switches
+// and the failures
+func printSwitchTable(output outputOptions, results []switchResult, t table) error {
+	var switches []switchInfo
+	var failures []switchResult
+	for _, result := range results {
+		switches = append(switches, switchInfo{Name: result.target.Name, Address: result.target.Address, Model: result.model})
+		if result.err != nil {
+			failures = append(failures, result)
+		}
+	}
+	if output.format == JsonFormat {
+		doc := jsonDocument{switches: switches, table: t}
+		if len(results) > 1 {
+			doc.errors = []switchError{}
+			for _, failure := range failures {
+				doc.errors = append(doc.errors, switchError{Switch: failure.target.Name, Error: failure.err.Error()})
+			}
+		}
+		return printJsonDocument(doc)
+	}
+	var err error
+	if output.format == InfluxFormat {
+		err = printInfluxTable(t, switches)
+	} else if len(t.columns) > 0 {
+		err = printTable(output, t)
+	}
+	if err != nil {
+		return err
+	}
+	printFailures(results)
+	return nil
+}
+
+func printFailures(results []switchResult) {
+	for _, result := range results {
+		if result.err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %s\n", result.target.Name, result.err.Error())
+		}
+	}
+}
+
+// printClientTable prints the table of a command, which changed a single switch
+func printClientTable(args *GlobalOptions, client *netgear.Client, address string, newTable func(switchName string) table) error {
+	target := args.config.lookupSwitch(address)
+	return printSwitchTable(tableOutput(args, address), []switchResult{{target: target, model: client.Model()}}, newTable(target.Name))
+}
+
+// fanOut runs the query for all switches concurrently, with at most 'parallel' switches at a time.
+// The results are in the same order as the switches.
+func fanOut(args *GlobalOptions, targets []switchTarget, parallel int, clients *clientCache, query switchQuery) []switchResult {
+	if parallel < 1 {
+		parallel = 1
+	}
+	results := make([]switchResult, len(targets))
+	slots := make(chan struct{}, parallel)
+	wg := sync.WaitGroup{}
+	for i, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			results[i] = querySwitch(args, target, clients, query)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// switchOptions copies the global options for querying a single switch concurrently to others,
+// because the options hold the session of a single switch
+func switchOptions(args *GlobalOptions) *GlobalOptions {
+	switchArgs := *args
+	switchArgs.model = ""
+	switchArgs.token = ""
+	switchArgs.usedHosts = nil
+	return &switchArgs
+}
+
+// querySwitch runs the query with a new client, or the cached one; the stored token's last use is updated,
+// when the client is created
+func querySwitch(args *GlobalOptions, target switchTarget, clients *clientCache, query switchQuery) switchResult {
+	result := switchResult{target: target}
+	client, switchArgs, created, err := clients.client(args, target)
+	if err == nil {
+		result.table, err = query(client, target.Name)
+		result.model = client.Model()
+	}
+	if err != nil {
+		result.err = withHost(target.Address, err)
+		return result
+	}
+	if created {
+		err = touchToken(switchArgs, target.Address)
+		if err != nil && args.Verbose {
+			fmt.Println("Unable to update the login token's last use: " + err.Error())
+		}
+	}
+	return result
+}
+
+// clientCache keeps the clients of the switches, e.g. for polling them repeatedly without reading the tokens again;
+// a nil cache creates a new client for each query
+type clientCache struct {
+	mutex   sync.Mutex
+	clients map[string]*netgear.Client
+}
+
+func newClientCache() *clientCache {
+	return &clientCache{clients: map[string]*netgear.Client{}}
+}
+
+// client returns the switch's client and its options, and whether it was created
+func (cache *clientCache) client(args *GlobalOptions, target switchTarget) (*netgear.Client, *GlobalOptions, bool, error) {
+	switchArgs := switchOptions(args)
+	if cache == nil {
+		client, err := newClient(switchArgs, target.Address)
+		return client, switchArgs, true, err
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if client, ok := cache.clients[target.Address]; ok {
+		return client, switchArgs, false, nil
+	}
+	client, err := newClient(switchArgs, target.Address)
+	if err == nil {
+		cache.clients[target.Address] = client
+	}
+	return client, switchArgs, true, err
+}
+
+// hostLocks serializes the queries per switch of concurrent long-running commands, because a re-login replaces the stored session
+type hostLocks struct {
+	locks sync.Map
+}
+
+// lock locks the switch and returns the function to unlock it
+func (h *hostLocks) lock(address string) func() {
+	lock, _ := h.locks.LoadOrStore(address, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	return lock.(*sync.Mutex).Unlock
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/ntgrrc/netgear"
+)
+
+func Test_fan_out_reports_failures_per_switch(t *testing.T) {
+	// setup
+	args := GlobalOptions{TokenDir: t.TempDir(), Quiet: true}
+	var targets []switchTarget
+	for _, model := range []netgear.NetgearModel{netgear.GS308EPP, netgear.GS316EP} {
+		_, host := loginEmulator(t, &args, model)
+		targets = append(targets, switchTarget{Name: string(model), Address: host})
+	}
+	targets = append(targets, switchTarget{Name: "not-logged-in", Address: "localhost:1"})
+
+	// when
+	results := fanOut(&args, targets, 2, nil, func(client *netgear.Client, switchName string) (table, error) {
+		statuses, err := client.PoeStatus()
+		if err != nil {
+			return table{}, err
+		}
+		return poePortStatusTable(switchName, statuses), nil
+	})
+
+	// then
+	then.AssertThat(t, results, has.Length[switchResult](3))
+	then.AssertThat(t, results[0].err, is.Nil())
+	then.AssertThat(t, results[0].table.content(), has.Length[[]string](8))
+	then.AssertThat(t, results[1].err, is.Nil())
+	then.AssertThat(t, results[0].model, is.EqualTo(netgear.GS30xEPx))
+	then.AssertThat(t, results[1].table.content(), has.Length[[]string](15))
+	then.AssertThat(t, results[1].table.records[0].texts[0], is.EqualTo(string(netgear.GS316EP)))
+	then.AssertThat(t, errors.Is(results[2].err, netgear.ErrNoSession), is.True())
+}
+
+func Test_fan_out_queries_at_most_parallel_switches_at_a_time(t *testing.T) {
+	// setup
+	args := GlobalOptions{TokenDir: t.TempDir(), model: netgear.GS308EPP}
+	var targets []switchTarget
+	for _, host := range []string{"a", "b", "c", "d", "e", "f"} {
+		err := storeToken(&args, host, "token")
+		then.AssertThat(t, err, is.Nil())
+		targets = append(targets, switchTarget{Name: host, Address: host})
+	}
+	var running, maxRunning atomic.Int32
+
+	// when
+	results := fanOut(&args, targets, 2, nil, func(client *netgear.Client, switchName string) (table, error) {
+		now := running.Add(1)
+		defer running.Add(-1)
+		for {
+			current := maxRunning.Load()
+			if now <= current || maxRunning.CompareAndSwap(current, now) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return table{records: []record{{texts: []string{client.Address()}}}}, nil
+	})
+
+	// then
+	then.AssertThat(t, maxRunning.Load(), is.EqualTo(int32(2)))
+	for i, result := range results {
+		then.AssertThat(t, result.table.records[0].texts[0], is.EqualTo(targets[i].Address))
+	}
+}
+
+func Test_json_output_lists_failed_switches_as_errors(t *testing.T) {
+	doc := jsonDocument{
+		switches: []switchInfo{{Name: "office-1", Address: "192.168.0.2", Model: netgear.GS308EPP}, {Name: "lab-1", Address: "192.168.0.3"}},
+		table:    poePortSettingsTable("office-1", []netgear.PoePortSetting{{PortIndex: 1, PortName: `a "quoted" name`, PortPwr: true, PwrLimit: "30.0", LongerDetect: "Enable"}}),
+		errors:   []switchError{{Switch: "lab-1", Error: `no "session"`}},
+	}
+
+	json, err := doc.MarshalJSON()
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, string(json), is.EqualTo(`{"schema_version":1,`+
+		`"switches":[{"name":"office-1","address":"192.168.0.2","model":"GS308EPP"},{"name":"lab-1","address":"192.168.0.3"}],`+
+		`"poe_settings":[{"switch":"office-1","port_id":1,"port_name":"a \"quoted\" name","port_power":true,"mode":"","priority":"","limit_type":"","limit_w":30,"max_limit_w":null,"detection_type":"","longer_detection_time":true}],`+
+		`"errors":[{"switch":"lab-1","error":"no \"session\""}]}`))
+}
+
+func Test_json_output_without_rows_is_an_empty_list(t *testing.T) {
+	json, err := jsonDocument{table: table{item: "sessions"}}.MarshalJSON()
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, string(json), is.EqualTo(`{"schema_version":1,"sessions":[]}`))
+}
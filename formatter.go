@@ -1,8 +1,52 @@
 package main
 
+import "text/template"
+
 type OutputFormat string
 
 const (
 	MarkdownFormat OutputFormat = "md"
 	JsonFormat     OutputFormat = "json"
+	CsvFormat      OutputFormat = "csv"
+	TsvFormat      OutputFormat = "tsv"
+	// InfluxFormat is the InfluxDB line protocol, e.g. for Telegraf's exec input
+	InfluxFormat OutputFormat = "influx"
+	// TemplateFormat executes the Go template given with --template or --template-file
+	TemplateFormat OutputFormat = "template"
 )
+
+// outputOptions controls how a command prints its tables
+type outputOptions struct {
+	format OutputFormat
+	// noHeader leaves out the header row in CSV and TSV
+	noHeader bool
+	template *template.Template
+}
+
+// tableOutput is the output of a command for the switch's address; the address is empty for commands,
+// which aren't about a single switch
+func tableOutput(args *GlobalOptions, address string) outputOptions {
+	return outputOptions{format: outputFormat(args, address), noHeader: args.NoHeader, template: args.template}
+}
+
+// printTable prints the table in the output format; an error of writing the output or of executing the template
+// fails the command
+func printTable(output outputOptions, t table) error {
+	switch output.format {
+	case MarkdownFormat:
+		printMarkdownTable(t.header(), t.content())
+	case JsonFormat:
+		return printJsonDataTable(t)
+	case CsvFormat:
+		return printCsvTable(t.header(), t.content(), ',', output.noHeader)
+	case TsvFormat:
+		return printCsvTable(t.header(), t.content(), '\t', output.noHeader)
+	case InfluxFormat:
+		return printInfluxTable(t, nil)
+	case TemplateFormat:
+		return printTemplate(output.template, t.results())
+	default:
+		return newCommandError(categoryInvalidArgument, "unknown output format '%s', use md, json, csv, tsv, influx or template", output.format)
+	}
+	return nil
+}
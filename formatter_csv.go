@@ -0,0 +1,29 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+)
+
+func printCsvTable(header []string, content [][]string, comma rune, noHeader bool) error {
+	return writeCsvTable(os.Stdout, header, content, comma, noHeader)
+}
+
+// writeCsvTable writes the table as CSV (RFC 4180); with a tab as comma, values containing tabs, quotes
+// or line breaks are quoted the same way
+func writeCsvTable(w io.Writer, header []string, content [][]string, comma rune, noHeader bool) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+	if !noHeader {
+		err := writer.Write(header)
+		if err != nil {
+			return err
+		}
+	}
+	err := writer.WriteAll(content)
+	if err != nil {
+		return err
+	}
+	return writer.Error()
+}
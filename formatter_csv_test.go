@@ -0,0 +1,27 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+func Test_csv_quotes_values_with_separators_and_quotes(t *testing.T) {
+	sb := strings.Builder{}
+
+	err := writeCsvTable(&sb, []string{"Port ID", "Port Name"}, [][]string{{"1", `link to "sw1", upstairs`}, {"2", ""}}, ',', false)
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, sb.String(), is.EqualTo("Port ID,Port Name\n1,\"link to \"\"sw1\"\", upstairs\"\n2,\n"))
+}
+
+func Test_tsv_without_header_quotes_values_with_tabs(t *testing.T) {
+	sb := strings.Builder{}
+
+	err := writeCsvTable(&sb, []string{"Port ID", "Port Name"}, [][]string{{"1", "a\tb"}, {"2", "100M full"}}, '\t', true)
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, sb.String(), is.EqualTo("1\t\"a\tb\"\n2\t100M full\n"))
+}
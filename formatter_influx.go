@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// influxRole is the role of a column in the InfluxDB line protocol
+type influxRole int
+
+const (
+	influxNone influxRole = iota
+	influxTag
+	influxField
+)
+
+func printInfluxTable(t table, switches []switchInfo) error {
+	return writeInfluxTable(os.Stdout, t, switches)
+}
+
+// writeInfluxTable writes a line per row, with the measurement "ntgrrc_<item>", e.g. "ntgrrc_poe_status",
+// the tag and field columns, and the time of the query in nanoseconds.
+// The switch's address and model are added as 'host' and 'model' tags.
+func writeInfluxTable(w io.Writer, t table, switches []switchInfo) error {
+	hasFields := false
+	for _, c := range t.columns {
+		hasFields = hasFields || c.influx == influxField
+	}
+	if !hasFields && len(t.records) > 0 {
+		return newCommandError(categoryInvalidArgument, "the columns of %s contain no fields for the influx output format", t.item)
+	}
+	measurement := escapeInflux("ntgrrc_"+t.item, ", ")
+	for _, r := range t.records {
+		line := strings.Builder{}
+		line.WriteString(measurement)
+		var fields []string
+		for i, c := range t.columns {
+			switch c.influx {
+			case influxTag:
+				writeInfluxTag(&line, c.name, r.texts[i])
+				if c.name == "switch" {
+					for _, info := range switches {
+						if info.Name == r.texts[i] {
+							writeInfluxTag(&line, "host", info.Address)
+							writeInfluxTag(&line, "model", string(info.Model))
+						}
+					}
+				}
+			case influxField:
+				if value, ok := influxFieldValue(r.values[i]); ok {
+					fields = append(fields, escapeInflux(c.name, ",= ")+"="+value)
+				}
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		_, err := fmt.Fprintf(w, "%s %s %d\n", line.String(), strings.Join(fields, ","), r.time.UnixNano())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeInfluxTag leaves out empty tags, which the line protocol doesn't allow
+func writeInfluxTag(line *strings.Builder, key string, value string) {
+	if value == "" {
+		return
+	}
+	line.WriteString(",")
+	line.WriteString(escapeInflux(key, ",= "))
+	line.WriteString("=")
+	line.WriteString(escapeInflux(value, ",= "))
+}
+
+// influxFieldValue formats integers with the 'i' suffix, floats, booleans and quoted strings; unknown values are left out
+func influxFieldValue(value any) (string, bool) {
+	switch v := value.(type) {
+	case int8, int32, int64, int:
+		return fmt.Sprintf("%di", v), true
+	case float32:
+		return strconv.FormatFloat(exactFloat64(v), 'f', -1, 64), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case *float64:
+		if v == nil {
+			return "", false
+		}
+		return strconv.FormatFloat(*v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case string:
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`, true
+	}
+	return "", false
+}
+
+// escapeInflux escapes the special characters with a backslash
+func escapeInflux(text string, special string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune(special, r) {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/ntgrrc/netgear"
+)
+
+func Test_influx_line_protocol_of_poe_status(t *testing.T) {
+	statuses := poePortStatusTable("office-1", testPoePortStatuses)
+	for i := range statuses.records {
+		statuses.records[i].time = time.Unix(1700000000, 42)
+	}
+	switches := []switchInfo{{Name: "office-1", Address: "192.168.0.2", Model: netgear.GS308EPP}}
+	sb := strings.Builder{}
+
+	err := writeInfluxTable(&sb, statuses, switches)
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, sb.String(), is.EqualTo(
+		"ntgrrc_poe_status,switch=office-1,host=192.168.0.2,model=GS308EPP,port_id=1,port_name=Camera voltage_v=53i,current_ma=82i,power_w=4.4,temperature_c=30i 1700000000000000042\n"+
+			"ntgrrc_poe_status,switch=office-1,host=192.168.0.2,model=GS308EPP,port_id=2 voltage_v=0i,current_ma=0i,power_w=0,temperature_c=30i 1700000000000000042\n"))
+}
+
+func Test_influx_escapes_tags_and_string_fields(t *testing.T) {
+	settings := portSettingsTable("", []netgear.PortSetting{{Index: 1, Name: "link to, sw=1", PortStatus: "UP", LinkSpeed: "1000M full"}})
+	settings.records[0].time = time.Unix(0, 1)
+	sb := strings.Builder{}
+
+	err := writeInfluxTable(&sb, settings, nil)
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, sb.String(), is.EqualTo("ntgrrc_port_settings,port_id=1,port_name=link\\ to\\,\\ sw\\=1 link_up=true,link_speed_mbps=1000i 1\n"))
+	value, _ := influxFieldValue(`say "hi" \ bye`)
+	then.AssertThat(t, value, is.EqualTo(`"say \"hi\" \\ bye"`))
+}
+
+func Test_influx_requires_field_columns(t *testing.T) {
+	options := TableOptions{Columns: []string{"port_name"}}
+	statuses, err := options.apply(poePortStatusTable("", testPoePortStatuses))
+	then.AssertThat(t, err, is.Nil())
+
+	err = writeInfluxTable(&strings.Builder{}, statuses, nil)
+
+	then.AssertThat(t, err.Error(), is.EqualTo("the columns of poe_status contain no fields for the influx output format"))
+	then.AssertThat(t, errorCategoryOf(err), is.EqualTo(categoryInvalidArgument))
+}
+
+func Test_influx_write_error_is_returned(t *testing.T) {
+	err := writeInfluxTable(failingWriter{}, poePortStatusTable("", testPoePortStatuses), nil)
+
+	then.AssertThat(t, err, is.EqualTo(errWriteFailed))
+}
+
+var errWriteFailed = errors.New("write failed")
+
+// failingWriter fails like stdout, which is a closed pipe
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errWriteFailed
+}
@@ -1,26 +1,94 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
-	"strings"
+	"github.com/nitram509/ntgrrc/netgear"
+	"io"
+	"os"
 )
 
-func printJsonDataTable(item string, header []string, content [][]string) {
-	json := strings.Builder{}
-	json.WriteString(fmt.Sprintf("{\"%s\":[", item))
-	for i, row := range content {
-		if i > 0 {
-			json.WriteString(",")
+// jsonSchemaVersion is increased on incompatible changes of the JSON output
+const jsonSchemaVersion = 1
+
+// switchInfo is the metadata of a switch in the JSON output
+type switchInfo struct {
+	Name    string               `json:"name"`
+	Address string               `json:"address"`
+	Model   netgear.NetgearModel `json:"model,omitempty"`
+}
+
+// switchError reports a switch, which failed, in the JSON output
+type switchError struct {
+	Switch string `json:"switch"`
+	Error  string `json:"error"`
+}
+
+// jsonDocument is the JSON output of a command: the schema version, the switches, the rows of the table
+// under the table's item name, e.g. "poe_status", and the switches, which failed, if any
+type jsonDocument struct {
+	switches []switchInfo
+	table    table
+	errors   []switchError
+}
+
+func printJsonDocument(doc jsonDocument) error {
+	return writeJsonDocument(os.Stdout, doc)
+}
+
+func writeJsonDocument(w io.Writer, doc jsonDocument) error {
+	bytes, err := doc.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(bytes))
+	return err
+}
+
+func printJsonDataTable(t table) error {
+	return printJsonDocument(jsonDocument{table: t})
+}
+
+// MarshalJSON keeps the order of the properties, because the name of the rows depends on the command
+func (doc jsonDocument) MarshalJSON() ([]byte, error) {
+	rows := doc.table.rows()
+	if rows == nil {
+		rows = []jsonObject{}
+	}
+	object := jsonObject{names: []string{"schema_version"}, values: []any{jsonSchemaVersion}}
+	if doc.switches != nil {
+		object.names = append(object.names, "switches")
+		object.values = append(object.values, doc.switches)
+	}
+	object.names = append(object.names, doc.table.item)
+	object.values = append(object.values, rows)
+	if doc.errors != nil {
+		object.names = append(object.names, "errors")
+		object.values = append(object.values, doc.errors)
+	}
+	return object.MarshalJSON()
+}
+
+// jsonObject is a JSON object with the properties in the order of the names
+type jsonObject struct {
+	names  []string
+	values []any
+}
+
+func (object jsonObject) MarshalJSON() ([]byte, error) {
+	result := []byte("{")
+	for i, name := range object.names {
+		value, err := json.Marshal(object.values[i])
+		if err != nil {
+			return nil, err
 		}
-		json.WriteString("{")
-		for i, value := range row {
-			if i > 0 {
-				json.WriteString(",")
-			}
-			json.WriteString(fmt.Sprintf("\"%s\":\"%s\"", header[i], value))
+		if i > 0 {
+			result = append(result, ',')
 		}
-		json.WriteString("}")
+		key, _ := json.Marshal(name)
+		result = append(result, key...)
+		result = append(result, ':')
+		result = append(result, value...)
 	}
-	json.WriteString("]}")
-	fmt.Println(json.String())
+	return append(result, '}'), nil
 }
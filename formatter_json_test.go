@@ -0,0 +1,14 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+func Test_json_write_error_is_returned(t *testing.T) {
+	err := writeJsonDocument(failingWriter{}, jsonDocument{table: poePortStatusTable("", testPoePortStatuses)})
+
+	then.AssertThat(t, err, is.EqualTo(errWriteFailed))
+}
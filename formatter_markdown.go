@@ -6,6 +6,12 @@ import (
 )
 
 func printMarkdownTable(header []string, content [][]string) {
+	fmt.Print(markdownTable(header, content, nil))
+}
+
+// markdownTable renders the table; the decorate function may change the cells of the content,
+// e.g. to highlight them with colors, which don't count for the width of the columns
+func markdownTable(header []string, content [][]string, decorate func(row int, column int, cell string) string) string {
 	var lengths = make([]int, len(header))
 	for i, h := range header {
 		lengths[i] = len([]rune(h))
@@ -17,6 +23,7 @@ func printMarkdownTable(header []string, content [][]string) {
 		}
 	}
 
+	result := strings.Builder{}
 	line := strings.Builder{}
 
 	line.WriteString("|")
@@ -25,7 +32,7 @@ func printMarkdownTable(header []string, content [][]string) {
 		line.WriteString(suffixToLength(h, lengths[i]))
 		line.WriteString(" |")
 	}
-	fmt.Println(line.String())
+	result.WriteString(line.String() + "\n")
 	line.Reset()
 
 	line.WriteString("|")
@@ -33,17 +40,22 @@ func printMarkdownTable(header []string, content [][]string) {
 		line.WriteString(strings.Repeat("-", l+2)) // a single space for one suffix and one prefix
 		line.WriteString("|")
 	}
-	fmt.Println(line.String())
+	result.WriteString(line.String() + "\n")
 	line.Reset()
 
-	for _, row := range content {
+	for r, row := range content {
 		for i, value := range row {
 			line.WriteString("| ")
-			line.WriteString(suffixToLength(value, lengths[i]+1))
+			padding := suffixToLength(value, lengths[i]+1)[len(value):]
+			if decorate != nil {
+				value = decorate(r, i, value)
+			}
+			line.WriteString(value + padding)
 		}
 		line.WriteString("|")
-		fmt.Println(line.String())
+		result.WriteString(line.String() + "\n")
 		line.Reset()
 	}
 
+	return result.String()
 }
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"text/template"
+)
+
+// parseOutputTemplate parses the template from the --template flag or the --template-file;
+// the template output format requires one of them
+func parseOutputTemplate(format OutputFormat, text string, fileName string) (*template.Template, error) {
+	if fileName != "" {
+		bytes, err := os.ReadFile(fileName)
+		if err != nil {
+			return nil, newCommandError(categoryInvalidArgument, "unable to read the template file: %w", err)
+		}
+		text = string(bytes)
+	}
+	if text == "" {
+		if format == TemplateFormat {
+			return nil, newCommandError(categoryInvalidArgument, "the template output format requires --template or --template-file")
+		}
+		return nil, nil
+	}
+	tmpl, err := template.New("output").Parse(text)
+	if err != nil {
+		return nil, newCommandError(categoryInvalidArgument, "invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// printTemplate executes the template with the list of typed results, e.g. netgear.PoePortStatus with the switch's name;
+// the template fails, e.g., for a field, which the results don't have
+func printTemplate(tmpl *template.Template, values []any) error {
+	if values == nil {
+		values = []any{}
+	}
+	err := tmpl.Execute(os.Stdout, values)
+	if err != nil {
+		return newCommandError(categoryInvalidArgument, "unable to execute the template: %w", err)
+	}
+	return nil
+}
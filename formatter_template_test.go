@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+func Test_template_is_executed_with_the_typed_results(t *testing.T) {
+	tmpl, err := parseOutputTemplate(TemplateFormat, `{{range .}}{{.Switch}} {{.PortIndex}} {{.PowerInWatt}}{{"\n"}}{{end}}`, "")
+	then.AssertThat(t, err, is.Nil())
+	sb := strings.Builder{}
+
+	err = tmpl.Execute(&sb, poePortStatusTable("office-1", testPoePortStatuses).results())
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, sb.String(), is.EqualTo("office-1 1 4.4\noffice-1 2 0\n"))
+}
+
+func Test_template_is_read_from_file(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "report.tmpl")
+	err := os.WriteFile(fileName, []byte(`{{len .}} ports`), 0600)
+	then.AssertThat(t, err, is.Nil())
+	tmpl, err := parseOutputTemplate(TemplateFormat, "", fileName)
+	then.AssertThat(t, err, is.Nil())
+	sb := strings.Builder{}
+
+	err = tmpl.Execute(&sb, poePortSettingsTable("", testPoePortSettings).results())
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, sb.String(), is.EqualTo("2 ports"))
+}
+
+func Test_template_format_requires_a_template(t *testing.T) {
+	_, err := parseOutputTemplate(TemplateFormat, "", "")
+
+	then.AssertThat(t, err.Error(), is.StringContaining("--template"))
+}
+
+func Test_invalid_template_is_an_error(t *testing.T) {
+	_, err := parseOutputTemplate(MarkdownFormat, "{{range .}", "")
+
+	then.AssertThat(t, err.Error(), has.Prefix("invalid template"))
+}
+
+func Test_failing_template_fails_the_command(t *testing.T) {
+	tmpl, err := parseOutputTemplate(TemplateFormat, `{{range .}}{{.NoSuchField}}{{end}}`, "")
+	then.AssertThat(t, err, is.Nil())
+
+	err = printTable(outputOptions{format: TemplateFormat, template: tmpl}, poePortStatusTable("", testPoePortStatuses))
+
+	then.AssertThat(t, err.Error(), has.Prefix("unable to execute the template"))
+	then.AssertThat(t, errorCategoryOf(err), is.EqualTo(categoryInvalidArgument))
+}
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+func Test_unknown_output_format_is_an_error(t *testing.T) {
+	err := printTable(outputOptions{format: "xml"}, poePortStatusTable("", testPoePortStatuses))
+
+	then.AssertThat(t, errorCategoryOf(err), is.EqualTo(categoryInvalidArgument))
+	then.AssertThat(t, err.Error(), is.EqualTo("unknown output format 'xml', use md, json, csv, tsv, influx or template"))
+}
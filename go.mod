@@ -1,4 +1,4 @@
-module ntgrrc
+module github.com/nitram509/ntgrrc
 
 go 1.25.0
 
@@ -7,6 +7,7 @@ require (
 	github.com/alecthomas/kong v1.16.0
 	github.com/corbym/gocrest v1.2.1
 	golang.org/x/term v0.45.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
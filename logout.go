@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/nitram509/ntgrrc/netgear"
+	"os"
+)
+
+type LogoutCommand struct {
+	Address string `required:"" help:"the Netgear switch's IP address or host name to connect to, or its name from the config file" short:"a"`
+}
+
+// Run ends the session on the switch and deletes the stored token in any case,
+// so that a switch, which is not reachable or can't end the session, e.g. the GS316, doesn't prevent cleaning up
+func (logout *LogoutCommand) Run(args *GlobalOptions) error {
+	host := resolveAddress(args, logout.Address)
+	logoutErr := logoutFromSwitch(args, host)
+	err := deleteToken(args, host)
+	if err != nil {
+		return err
+	}
+	return logoutErr
+}
+
+func logoutFromSwitch(args *GlobalOptions, host string) error {
+	client, err := newClient(args, host)
+	if err == nil {
+		err = client.Logout()
+	}
+	if errors.Is(err, netgear.ErrNoSession) {
+		return nil
+	}
+	if errors.Is(err, netgear.ErrNotSupported) {
+		if !args.Quiet {
+			fmt.Fprintf(os.Stderr, "%s can't end the session, it expires on the switch; deleting the stored token only\n", host)
+		}
+		return nil
+	}
+	return err
+}
@@ -1,32 +1,54 @@
 package main
 
 import (
-	"fmt"
 	"github.com/alecthomas/kong"
+	"github.com/nitram509/ntgrrc/netgear"
 	"os"
+	"text/template"
 )
 
 type GlobalOptions struct {
 	Verbose      bool
 	Quiet        bool
 	OutputFormat OutputFormat
+	NoHeader     bool
 	TokenDir     string
-	model        NetgearModel
+	TokenKeyFile string
+	ReLogin      bool
+	PasswordFile string
+	model        netgear.NetgearModel
 	token        string
+	usedHosts    []string
+	config       *Config
+	template     *template.Template
+	// outputFormatGiven is true, when -f was given on the command line, and thus overrides the switch's format
+	outputFormatGiven bool
 }
 
 var cli struct {
 	HelpAll      HelpAllFlag  `help:"advanced/full help"`
+	Config       string       `help:"config file with switches and groups (default: ~/.config/ntgrrc/config.yaml); alternatively, set the NTGRRC_CONFIG environment variable" default:"" type:"path"`
 	Verbose      bool         `help:"verbose log messages" short:"v"`
 	Quiet        bool         `help:"no log messages" short:"q"`
-	OutputFormat OutputFormat `help:"what output format to use [md, json]" enum:"md,json" default:"md" short:"f"`
+	OutputFormat OutputFormat `help:"what output format to use [md, json, csv, tsv, influx, template]" enum:"md,json,csv,tsv,influx,template" default:"md" short:"f"`
+	NoHeader     bool         `help:"leave out the header row in csv and tsv output" name:"no-header"`
+	Template     string       `help:"Go template for the template output format, executed with the list of results, e.g. '{{range .}}{{.PortIndex}} {{.PowerInWatt}}{{end}}'" xor:"template"`
+	TemplateFile string       `help:"file with the Go template for the template output format" type:"path" xor:"template"`
 	TokenDir     string       `help:"directory to store login tokens" default:"" short:"d"`
+	TokenKeyFile string       `help:"encrypt stored login tokens with the key or passphrase from this file; alternatively, set the NTGRRC_TOKEN_PASSPHRASE environment variable" default:"" type:"path"`
+	ReLogin      bool         `help:"log in again, when the session (token) has expired; the password is read from --password-file or the NTGRRC_PASSWORD environment variable" name:"re-login"`
+	PasswordFile string       `help:"file to read the admin console's password from, used with --re-login" default:"" type:"path"`
 
 	Version   VersionCommand     `cmd:"" name:"version" help:"show version"`
 	Login     LoginCommand       `cmd:"" name:"login" help:"create a session for further commands (requires admin console password)"`
+	Logout    LogoutCommand      `cmd:"" name:"logout" help:"end the session on the switch and delete the stored token"`
+	Session   SessionCommand     `cmd:"" name:"session" help:"list, show or prune stored sessions (login tokens)"`
 	Poe       PoeCommand         `cmd:"" name:"poe" help:"show POE status or change the configuration"`
 	Port      PortCommand        `cmd:"" name:"port" help:"show port status or change the configuration for a port"`
 	ShowDebug DebugReportCommand `cmd:"" name:"debug-report" help:"show information from the switch communication, useful for supporting development and bug fixes"`
+	Emulate   EmulateCommand     `cmd:"" name:"emulate" help:"run an emulated switch, useful for development and testing without hardware"`
+	Exporter  ExporterCommand    `cmd:"" name:"exporter" help:"serve PoE and port metrics for Prometheus"`
+	Schedule  ScheduleCommand    `cmd:"" name:"schedule" help:"switch the PoE power of ports by the time, according to schedules from the config file"`
 }
 
 func main() {
@@ -35,22 +57,52 @@ func main() {
 		os.Args = append(os.Args, "--help")
 	}
 
+	// the config file provides defaults for the flags, thus it must be loaded before parsing them
+	config, err := loadConfig(configFileName(os.Args[1:]))
+	if err != nil {
+		os.Exit(reportError(outputFormatArg(os.Args[1:]), err))
+	}
+
 	options := kong.Parse(&cli,
+		kong.Resolvers(config.resolver()),
 		kong.UsageOnError(),
+		kong.Exit(func(code int) {
+			if code == kongUsageExitCode {
+				code = categoryInvalidArgument.exitCode
+			}
+			os.Exit(code)
+		}),
 		kong.ConfigureHelp(kong.HelpOptions{
 			Compact:             true,
 			NoExpandSubcommands: true,
 		}),
 	)
 
-	err := options.Run(&GlobalOptions{
+	outputTemplate, err := parseOutputTemplate(cli.OutputFormat, cli.Template, cli.TemplateFile)
+	if err != nil {
+		os.Exit(reportError(cli.OutputFormat, err))
+	}
+
+	args := &GlobalOptions{
 		Verbose:      cli.Verbose,
 		Quiet:        cli.Quiet,
 		OutputFormat: cli.OutputFormat,
+		NoHeader:     cli.NoHeader,
 		TokenDir:     cli.TokenDir,
-	})
+		TokenKeyFile: cli.TokenKeyFile,
+		ReLogin:      cli.ReLogin,
+		PasswordFile: cli.PasswordFile,
+		config:       config,
+		template:     outputTemplate,
+
+		outputFormatGiven: isFlagGiven(options, "output-format"),
+	}
+	err = options.Run(args)
 	if err != nil {
-		fmt.Printf("Error: %s\n", err.Error())
-		os.Exit(1)
+		if len(args.usedHosts) == 1 {
+			err = withHost(args.usedHosts[0], err)
+		}
+		os.Exit(reportError(args.OutputFormat, err))
 	}
+	touchUsedTokens(args)
 }
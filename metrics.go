@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// metrics collects samples in the Prometheus text exposition format, grouped by metric family
+type metrics struct {
+	families []*metricFamily
+	byName   map[string]*metricFamily
+}
+
+type metricFamily struct {
+	name    string
+	help    string
+	samples []string
+}
+
+func newMetrics() *metrics {
+	return &metrics{byName: map[string]*metricFamily{}}
+}
+
+// gauge adds a sample; labels are given as name and value pairs
+func (m *metrics) gauge(name string, help string, value float64, labels ...string) {
+	family, ok := m.byName[name]
+	if !ok {
+		family = &metricFamily{name: name, help: help}
+		m.families = append(m.families, family)
+		m.byName[name] = family
+	}
+	sample := strings.Builder{}
+	sample.WriteString(name)
+	if len(labels) > 0 {
+		sample.WriteString("{")
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				sample.WriteString(",")
+			}
+			sample.WriteString(fmt.Sprintf("%s=\"%s\"", labels[i], escapeLabelValue(labels[i+1])))
+		}
+		sample.WriteString("}")
+	}
+	sample.WriteString(" ")
+	sample.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	family.samples = append(family.samples, sample.String())
+}
+
+// merge adds all samples of the other metrics
+func (m *metrics) merge(other *metrics) {
+	for _, otherFamily := range other.families {
+		family, ok := m.byName[otherFamily.name]
+		if !ok {
+			family = &metricFamily{name: otherFamily.name, help: otherFamily.help}
+			m.families = append(m.families, family)
+			m.byName[family.name] = family
+		}
+		family.samples = append(family.samples, otherFamily.samples...)
+	}
+}
+
+func (m *metrics) write(w io.Writer) error {
+	for _, family := range m.families {
+		_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", family.name, family.help, family.name)
+		if err != nil {
+			return err
+		}
+		for _, sample := range family.samples {
+			_, err = fmt.Fprintln(w, sample)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func escapeLabelValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return strings.ReplaceAll(value, "\n", `\n`)
+}
@@ -0,0 +1,166 @@
+// Package netgear is a client library to manage Netgear managed plus switches (GS305EP(P), GS308EP(P), GS316EP(P)).
+//
+// Since Netgear does not offer a REST API, the client uses web scraping techniques
+// to read status and settings from the switch's admin web interface and to change the configuration.
+// The client never prints to stdout, verbose log messages are written to an optional io.Writer.
+package netgear
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Client talks to a single Netgear switch.
+// Once logged in, the client keeps the detected model and the session token,
+// which are required for all further requests.
+type Client struct {
+	address    string
+	model      NetgearModel
+	token      string
+	httpClient *http.Client
+	logger     io.Writer
+	password   func() (string, error)
+	onLogin    func(client *Client) error
+}
+
+// ClientOption configures optional properties of a Client
+type ClientOption func(client *Client)
+
+// WithSession re-uses an existing session, e.g. a token stored from a former login
+func WithSession(model NetgearModel, token string) ClientOption {
+	return func(client *Client) {
+		client.model = model
+		client.token = token
+	}
+}
+
+// WithHttpClient replaces the default http.Client, e.g. for setting timeouts
+func WithHttpClient(httpClient *http.Client) ClientOption {
+	return func(client *Client) {
+		client.httpClient = httpClient
+	}
+}
+
+// WithVerboseOutput enables verbose log messages, which are written to the given writer
+func WithVerboseOutput(writer io.Writer) ClientOption {
+	return func(client *Client) {
+		client.logger = writer
+	}
+}
+
+// WithReLogin enables a transparent re-login, when the switch asks for a login, e.g. because the session has expired,
+// or when there's no session yet. The password is only requested, when needed.
+// After a successful login, the optional onLogin function is called, e.g. to store the new session token,
+// and the failed operation is retried once.
+func WithReLogin(password func() (string, error), onLogin func(client *Client) error) ClientOption {
+	return func(client *Client) {
+		client.password = password
+		client.onLogin = onLogin
+	}
+}
+
+// NewClient creates a client for the switch with the given IP address or host name
+func NewClient(address string, options ...ClientOption) *Client {
+	client := &Client{
+		address:    address,
+		httpClient: &http.Client{},
+		logger:     io.Discard,
+	}
+	for _, option := range options {
+		option(client)
+	}
+	return client
+}
+
+// Address is the switch's IP address or host name
+func (c *Client) Address() string {
+	return c.address
+}
+
+// Model is the switch's model, known after login or when using an existing session
+func (c *Client) Model() NetgearModel {
+	return c.model
+}
+
+// Token is the session token, known after login or when using an existing session
+func (c *Client) Token() string {
+	return c.token
+}
+
+// DebugPageUrls lists all pages of the switch, which are useful for supporting development and bug fixes
+func (c *Client) DebugPageUrls() []string {
+	driver, err := DriverFor(c.model)
+	if err != nil {
+		return nil
+	}
+	return driver.DebugPageUrls(c.address)
+}
+
+func (c *Client) hasSession() bool {
+	return len(c.model) > 0 && len(c.token) > 0
+}
+
+func (c *Client) logf(format string, a ...any) {
+	_, _ = fmt.Fprintf(c.logger, format+"\n", a...)
+}
+
+func (c *Client) driver() (Driver, error) {
+	if !c.hasSession() {
+		return nil, ErrNoSession
+	}
+	return DriverFor(c.model)
+}
+
+// retryOnLoginRequired runs the operation and, when re-login is enabled and a login is required,
+// logs in again and retries the operation once
+func (c *Client) retryOnLoginRequired(operation func() error) error {
+	err := operation()
+	if c.password == nil || !(errors.Is(err, ErrLoginRequired) || errors.Is(err, ErrNoSession)) {
+		return err
+	}
+	c.logf("login required, logging in again")
+	password, err := c.password()
+	if err != nil {
+		return err
+	}
+	err = c.Login(password)
+	if err != nil {
+		return err
+	}
+	if c.onLogin != nil {
+		err = c.onLogin(c)
+		if err != nil {
+			return err
+		}
+	}
+	return operation()
+}
+
+// withReLogin is the variant of retryOnLoginRequired for operations with a result
+func withReLogin[T any](c *Client, operation func() (T, error)) (T, error) {
+	var result T
+	err := c.retryOnLoginRequired(func() error {
+		var err error
+		result, err = operation()
+		return err
+	})
+	return result, err
+}
+
+// requestContent fetches a page, which requires a valid session.
+// An empty page URL means, the driver does not support this operation.
+func (c *Client) requestContent(operation string, pageUrl string) (string, error) {
+	if pageUrl == "" {
+		return "", &NotSupportedError{Model: c.model, Operation: operation}
+	}
+	page, err := c.requestPage(pageUrl)
+	if err != nil {
+		return "", err
+	}
+	if IsLoginRequired(page) {
+		return "", ErrLoginRequired
+	}
+	return page, nil
+}
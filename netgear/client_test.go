@@ -0,0 +1,66 @@
+package netgear
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+func TestClientPoeStatusUsesSession(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("SID")
+		if err != nil || cookie.Value != "abc123" || r.URL.Path != "/getPoePortStatus.cgi" {
+			_, _ = w.Write([]byte(loadTestFile("GS308EPP", "login.cgi.html")))
+			return
+		}
+		_, _ = w.Write([]byte(loadTestFile("GS308EPP", "getPoePortStatus.cgi.html")))
+	}))
+	defer server.Close()
+	client := NewClient(strings.TrimPrefix(server.URL, "http://"), WithSession(GS308EPP, "abc123"))
+
+	statuses, err := client.PoeStatus()
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, statuses, has.Length[PoePortStatus](8))
+}
+
+func TestClientReportsExpiredSession(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(loadTestFile("GS308EPP", "login.cgi.html")))
+	}))
+	defer server.Close()
+	client := NewClient(strings.TrimPrefix(server.URL, "http://"), WithSession(GS308EPP, "expired"))
+
+	_, err := client.PoeStatus()
+
+	then.AssertThat(t, errors.Is(err, ErrLoginRequired), is.True())
+}
+
+func TestClientReportsUnknownModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html><head><title>Some Router</title></head></html>"))
+	}))
+	defer server.Close()
+	client := NewClient(strings.TrimPrefix(server.URL, "http://"))
+
+	_, err := client.DetectModel()
+
+	var unsupportedModel *UnsupportedModelError
+	then.AssertThat(t, errors.As(err, &unsupportedModel), is.True())
+	then.AssertThat(t, errors.Is(err, ErrNotSupported), is.True())
+	then.AssertThat(t, err.Error(), is.StringContaining("can't detect the Netgear model"))
+}
+
+func TestClientWithoutSession(t *testing.T) {
+	client := NewClient("192.168.0.239")
+
+	_, err := client.PortSettings()
+
+	then.AssertThat(t, errors.Is(err, ErrNoSession), is.True())
+}
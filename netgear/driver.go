@@ -0,0 +1,125 @@
+package netgear
+
+import (
+	"io"
+	"net/http"
+	"sort"
+)
+
+// Driver implements everything, which is specific for a family of switch models:
+// how to log in and inject the session into requests, which pages to request,
+// how to parse them and how to build the payloads to change settings.
+//
+// To support a new family of models, implement this interface and add it with RegisterDriver.
+// Operations, which are not (yet) supported, shall return a NotSupportedError;
+// embedding UnsupportedOperations gives you that for all operations, not implemented by the driver.
+type Driver interface {
+	// LoginPageUrl is the page, which contains the random seed value required to encrypt the password
+	LoginPageUrl(host string) string
+	// LoginRequest returns the URL and the form data to post the encrypted password to
+	LoginRequest(host string, encryptedPwd string) (url string, formData string)
+	// FindSessionToken extracts the session token from the login response
+	FindSessionToken(resp *http.Response, body string) (string, error)
+	// InjectSession adds the session token to a request
+	InjectSession(req *http.Request, token string)
+	// Logout ends the session on the switch
+	Logout(c *Client) error
+	// SessionProbeUrl is a small page, which requires a valid session, used to check if a session still works
+	SessionProbeUrl(host string) string
+
+	PoeStatusPageUrl(host string) string
+	ParsePoeStatus(reader io.Reader) ([]PoePortStatus, error)
+	PoeSettingsPageUrl(host string) string
+	ParsePoeSettings(reader io.Reader) ([]PoePortSetting, error)
+	PortSettingsPageUrl(host string) string
+	ParsePortSettings(reader io.Reader) ([]PortSetting, error)
+
+	SetPoe(c *Client, ports []int, update PoePortSettingsUpdate) error
+	CyclePoe(c *Client, ports []int) error
+	SetPort(c *Client, ports []int, update PortSettingsUpdate) error
+
+	// DebugPageUrls lists all pages, which are useful for supporting development and bug fixes
+	DebugPageUrls(host string) []string
+}
+
+var drivers = map[NetgearModel]Driver{}
+
+// RegisterDriver makes a driver available for the given models
+func RegisterDriver(driver Driver, models ...NetgearModel) {
+	for _, model := range models {
+		drivers[model] = driver
+	}
+}
+
+// DriverFor looks up the driver for the given model
+func DriverFor(model NetgearModel) (Driver, error) {
+	driver, ok := drivers[model]
+	if !ok {
+		return nil, &UnsupportedModelError{Model: model}
+	}
+	return driver, nil
+}
+
+// SupportedModels lists all models, a driver is registered for, alphabetically sorted
+func SupportedModels() []NetgearModel {
+	var models []NetgearModel
+	for model := range drivers {
+		models = append(models, model)
+	}
+	sort.Slice(models, func(i, j int) bool {
+		return models[i] < models[j]
+	})
+	return models
+}
+
+// UnsupportedOperations can be embedded in a driver, so that all operations, not implemented by the driver,
+// return a NotSupportedError. An empty page URL marks reading this page as not supported.
+type UnsupportedOperations struct{}
+
+func (UnsupportedOperations) Logout(c *Client) error {
+	return &NotSupportedError{Model: c.model, Operation: "Logout"}
+}
+
+func (UnsupportedOperations) SessionProbeUrl(host string) string {
+	return ""
+}
+
+func (UnsupportedOperations) PoeStatusPageUrl(host string) string {
+	return ""
+}
+
+func (UnsupportedOperations) ParsePoeStatus(reader io.Reader) ([]PoePortStatus, error) {
+	return nil, &NotSupportedError{Operation: "PoeStatus"}
+}
+
+func (UnsupportedOperations) PoeSettingsPageUrl(host string) string {
+	return ""
+}
+
+func (UnsupportedOperations) ParsePoeSettings(reader io.Reader) ([]PoePortSetting, error) {
+	return nil, &NotSupportedError{Operation: "PoeSettings"}
+}
+
+func (UnsupportedOperations) PortSettingsPageUrl(host string) string {
+	return ""
+}
+
+func (UnsupportedOperations) ParsePortSettings(reader io.Reader) ([]PortSetting, error) {
+	return nil, &NotSupportedError{Operation: "PortSettings"}
+}
+
+func (UnsupportedOperations) SetPoe(c *Client, ports []int, update PoePortSettingsUpdate) error {
+	return &NotSupportedError{Model: c.model, Operation: "SetPoe"}
+}
+
+func (UnsupportedOperations) CyclePoe(c *Client, ports []int) error {
+	return &NotSupportedError{Model: c.model, Operation: "CyclePoe"}
+}
+
+func (UnsupportedOperations) SetPort(c *Client, ports []int, update PortSettingsUpdate) error {
+	return &NotSupportedError{Model: c.model, Operation: "SetPort"}
+}
+
+func (UnsupportedOperations) DebugPageUrls(host string) []string {
+	return nil
+}
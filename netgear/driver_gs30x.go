@@ -0,0 +1,88 @@
+package netgear
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// gs30xDriver supports the GS305EP(P) and GS308EP(P) models
+type gs30xDriver struct{}
+
+func init() {
+	RegisterDriver(&gs30xDriver{}, GS30xEPx, GS305EP, GS305EPP, GS308EP, GS308EPP)
+}
+
+func (d *gs30xDriver) LoginPageUrl(host string) string {
+	return fmt.Sprintf("http://%s/login.cgi", host)
+}
+
+func (d *gs30xDriver) LoginRequest(host string, encryptedPwd string) (string, string) {
+	return fmt.Sprintf("http://%s/login.cgi", host), "password=" + encryptedPwd
+}
+
+func (d *gs30xDriver) FindSessionToken(resp *http.Response, body string) (string, error) {
+	token := getSessionToken(resp)
+	if token == FailedAttempt && resp.StatusCode == http.StatusOK {
+		return "", &LoginFailedError{StatusCode: resp.StatusCode, Reason: "login request returned 200 OK, but response did not contain a session token ('SID' cookie). " +
+			"this is known behaviour from the switch. please, wait some minutes and tray again later"}
+	}
+	if token == FailedAttempt {
+		return "", &LoginFailedError{StatusCode: resp.StatusCode, Reason: "login request failed with HTTP status " + resp.Status}
+	}
+	return token, nil
+}
+
+func (d *gs30xDriver) InjectSession(req *http.Request, token string) {
+	req.Header.Set("Cookie", "SID="+token)
+}
+
+func (d *gs30xDriver) Logout(c *Client) error {
+	_, err := c.PostPage(fmt.Sprintf("http://%s/logout.cgi", c.Address()), "")
+	return err
+}
+
+func (d *gs30xDriver) SessionProbeUrl(host string) string {
+	return fmt.Sprintf("http://%s/getPoePortStatus.cgi", host)
+}
+
+func (d *gs30xDriver) PoeStatusPageUrl(host string) string {
+	return fmt.Sprintf("http://%s/getPoePortStatus.cgi", host)
+}
+
+func (d *gs30xDriver) ParsePoeStatus(reader io.Reader) ([]PoePortStatus, error) {
+	return findPortStatusInGs30xEPxHtml(reader)
+}
+
+func (d *gs30xDriver) PoeSettingsPageUrl(host string) string {
+	return fmt.Sprintf("http://%s/PoEPortConfig.cgi", host)
+}
+
+func (d *gs30xDriver) ParsePoeSettings(reader io.Reader) ([]PoePortSetting, error) {
+	settings, err := findPortPortConfInHtmlGs30x(reader)
+	if err != nil {
+		return nil, err
+	}
+	return toReadablePoePortSettingsGs30x(settings), nil
+}
+
+func (d *gs30xDriver) PortSettingsPageUrl(host string) string {
+	return fmt.Sprintf("http://%s/dashboard.cgi", host)
+}
+
+func (d *gs30xDriver) ParsePortSettings(reader io.Reader) ([]PortSetting, error) {
+	settings, err := findPortSettingsInGs30xEPxHtml(reader)
+	if err != nil {
+		return nil, err
+	}
+	return toReadablePortSettingsGs30x(settings), nil
+}
+
+func (d *gs30xDriver) DebugPageUrls(host string) []string {
+	return []string{
+		fmt.Sprintf("http://%s/getPoePortStatus.cgi", host),
+		fmt.Sprintf("http://%s/PoEPortConfig.cgi", host),
+		fmt.Sprintf("http://%s/port_status.cgi", host),
+		fmt.Sprintf("http://%s/dashboard.cgi", host),
+	}
+}
@@ -0,0 +1,90 @@
+package netgear
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// gs316Driver supports the GS316EP(P) models
+type gs316Driver struct{}
+
+func init() {
+	RegisterDriver(&gs316Driver{}, GS316EP, GS316EPP)
+}
+
+func (d *gs316Driver) LoginPageUrl(host string) string {
+	return fmt.Sprintf("http://%s/wmi/login", host)
+}
+
+func (d *gs316Driver) LoginRequest(host string, encryptedPwd string) (string, string) {
+	return fmt.Sprintf("http://%s/redirect.html", host), "LoginPassword=" + encryptedPwd
+}
+
+func (d *gs316Driver) FindSessionToken(resp *http.Response, body string) (string, error) {
+	token := findGambitTokenInResponseHtml(strings.NewReader(body))
+	if token == FailedAttempt && resp.StatusCode == http.StatusOK {
+		return "", &LoginFailedError{StatusCode: resp.StatusCode, Reason: "login request returned 200 OK, but response did not contain a token ('Gambit' value in input field) "}
+	}
+	if token == FailedAttempt {
+		return "", &LoginFailedError{StatusCode: resp.StatusCode, Reason: "login request failed with HTTP status " + resp.Status}
+	}
+	return token, nil
+}
+
+// InjectSession adds the token as Gambit query parameter and as cookie
+func (d *gs316Driver) InjectSession(req *http.Request, token string) {
+	query := req.URL.RawQuery
+	if query == "" {
+		req.URL.RawQuery = "Gambit=" + token
+	} else {
+		req.URL.RawQuery = "Gambit=" + token + "&" + query
+	}
+	req.Header.Set("Cookie", "gambitCookie="+token)
+}
+
+// Logout is not supported, because the request of the GS316's logout button is unknown; there's no capture of it
+func (d *gs316Driver) Logout(c *Client) error {
+	return &NotSupportedError{Model: c.model, Operation: "Logout"}
+}
+
+func (d *gs316Driver) SessionProbeUrl(host string) string {
+	return fmt.Sprintf("http://%s/iss/specific/poePortStatus.html?GetData=TRUE", host)
+}
+
+func (d *gs316Driver) PoeStatusPageUrl(host string) string {
+	return fmt.Sprintf("http://%s/iss/specific/poePortStatus.html?GetData=TRUE", host)
+}
+
+func (d *gs316Driver) ParsePoeStatus(reader io.Reader) ([]PoePortStatus, error) {
+	return findPortStatusInGs316EPxHtml(reader)
+}
+
+func (d *gs316Driver) PoeSettingsPageUrl(host string) string {
+	return fmt.Sprintf("http://%s/iss/specific/poePortConf.html", host)
+}
+
+func (d *gs316Driver) ParsePoeSettings(reader io.Reader) ([]PoePortSetting, error) {
+	return findPortPortConfInHtmlGs316(reader)
+}
+
+func (d *gs316Driver) PortSettingsPageUrl(host string) string {
+	return fmt.Sprintf("http://%s/iss/specific/dashboard.html", host)
+}
+
+func (d *gs316Driver) ParsePortSettings(reader io.Reader) ([]PortSetting, error) {
+	return findPortSettingsInGs316EPxHtml(reader)
+}
+
+func (d *gs316Driver) DebugPageUrls(host string) []string {
+	return []string{
+		fmt.Sprintf("http://%s/iss/specific/poe.html", host),
+		fmt.Sprintf("http://%s/iss/specific/poePortConf.html", host),
+		fmt.Sprintf("http://%s/iss/specific/poePortStatus.html", host),
+		fmt.Sprintf("http://%s/iss/specific/poePortStatus.html?GetData=TRUE", host),
+		fmt.Sprintf("http://%s/iss/specific/getPortRate.html", host),
+		fmt.Sprintf("http://%s/iss/specific/dashboard.html", host),
+		fmt.Sprintf("http://%s/iss/specific/homepage.html", host),
+	}
+}
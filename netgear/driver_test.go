@@ -0,0 +1,66 @@
+package netgear
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+type testDriver struct {
+	UnsupportedOperations
+}
+
+func (d *testDriver) LoginPageUrl(host string) string { return "" }
+func (d *testDriver) LoginRequest(host string, encryptedPwd string) (string, string) {
+	return "", ""
+}
+func (d *testDriver) FindSessionToken(resp *http.Response, body string) (string, error) {
+	return "", nil
+}
+func (d *testDriver) InjectSession(req *http.Request, token string) {}
+
+func TestDriverForUnknownModel(t *testing.T) {
+	_, err := DriverFor("XYZ")
+
+	var unsupportedModel *UnsupportedModelError
+	then.AssertThat(t, errors.As(err, &unsupportedModel), is.True())
+	then.AssertThat(t, errors.Is(err, ErrNotSupported), is.True())
+}
+
+func TestDriverRegistry(t *testing.T) {
+	for _, model := range []NetgearModel{GS30xEPx, GS305EP, GS305EPP, GS308EP, GS308EPP, GS316EP, GS316EPP} {
+		_, err := DriverFor(model)
+		then.AssertThat(t, err, is.Nil().Reason("driver for "+string(model)))
+	}
+}
+
+func TestUnsupportedOperationsReturnNotSupportedError(t *testing.T) {
+	const testModel NetgearModel = "GS999TEST"
+	RegisterDriver(&testDriver{}, testModel)
+	defer delete(drivers, testModel)
+	client := NewClient("192.168.0.239", WithSession(testModel, "abc123"))
+
+	_, err := client.PoeStatus()
+	var notSupported *NotSupportedError
+	then.AssertThat(t, errors.As(err, &notSupported), is.True())
+	then.AssertThat(t, notSupported.Operation, is.EqualTo("PoeStatus"))
+
+	_, err = client.SetPoe([]int{1}, PoePortSettingsUpdate{PortPwr: "enable"})
+	then.AssertThat(t, errors.Is(err, ErrNotSupported), is.True())
+
+	_, err = client.CyclePoe([]int{1})
+	then.AssertThat(t, errors.Is(err, ErrNotSupported), is.True())
+}
+
+func TestGs316InjectsGambitIntoQueryAndCookie(t *testing.T) {
+	driver, _ := DriverFor(GS316EP)
+	req, _ := http.NewRequest(http.MethodGet, "http://192.168.0.239/iss/specific/poePortStatus.html?GetData=TRUE", nil)
+
+	driver.InjectSession(req, "abc123")
+
+	then.AssertThat(t, req.URL.String(), is.EqualTo("http://192.168.0.239/iss/specific/poePortStatus.html?Gambit=abc123&GetData=TRUE"))
+	then.AssertThat(t, req.Header.Get("Cookie"), is.EqualTo("gambitCookie=abc123"))
+}
@@ -0,0 +1,424 @@
+// Package emulator provides a stateful emulation of Netgear switches.
+// It serves the same pages as the switch's firmware does, handles the login with seed values and session tokens,
+// and changes its state, when settings are posted. This allows end-to-end tests and development without hardware.
+package emulator
+
+import (
+	"crypto/md5"
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nitram509/ntgrrc/netgear"
+)
+
+// the templates are the pages in test-data, with the values of the ports replaced by the emulator's state
+//
+//go:embed templates/*.html
+var templateFiles embed.FS
+
+var templates = template.Must(template.ParseFS(templateFiles, "templates/*.html"))
+
+// Switch emulates a single Netgear switch and implements http.Handler
+type Switch struct {
+	model    netgear.NetgearModel
+	password string
+
+	mu       sync.Mutex
+	seed     string
+	hash     string
+	sessions map[string]bool
+	poePorts []poePort
+	ports    []port
+	// powerUpDelay is the time, a powered device needs to draw power again, after its port was power cycled or enabled
+	powerUpDelay time.Duration
+}
+
+// poePort holds the PoE state of a port; the settings use the GS30x firmware's numeric codes
+type poePort struct {
+	Index        int
+	PortPwr      bool
+	PwrMode      string
+	PortPrio     string
+	LimitType    string
+	PwrLimit     string
+	DetecType    string
+	LongerDetect string
+	// a powered device is connected, when the class is not empty
+	DeviceClass    string
+	DevicePowerMil int // power consumption of the device in milli watt
+	// the device draws power again after this time, e.g. after a power cycle
+	poweredAfter time.Time
+	// fault is the error status of the port, e.g. 'Overload', which stops the power delivery
+	fault string
+}
+
+// port holds the state of a port; the settings use the GS30x firmware's numeric codes
+type port struct {
+	Index            int
+	Name             string
+	Speed            string
+	IngressRateLimit string
+	EgressRateLimit  string
+	FlowControl      string
+	Connected        bool
+}
+
+type modelSpec struct {
+	noPorts    int
+	noPoePorts int
+}
+
+var modelSpecs = map[netgear.NetgearModel]modelSpec{
+	netgear.GS305EP:  {noPorts: 5, noPoePorts: 4},
+	netgear.GS305EPP: {noPorts: 5, noPoePorts: 4},
+	netgear.GS308EP:  {noPorts: 8, noPoePorts: 8},
+	netgear.GS308EPP: {noPorts: 8, noPoePorts: 8},
+	netgear.GS316EP:  {noPorts: 16, noPoePorts: 15},
+	netgear.GS316EPP: {noPorts: 16, noPoePorts: 15},
+}
+
+// Models lists all models, which can be emulated
+func Models() []netgear.NetgearModel {
+	return []netgear.NetgearModel{netgear.GS305EP, netgear.GS305EPP, netgear.GS308EP, netgear.GS308EPP, netgear.GS316EP, netgear.GS316EPP}
+}
+
+// New creates an emulated switch of the given model, which accepts the given admin console password.
+// The first port has a powered device connected, all other ports are unused.
+func New(model netgear.NetgearModel, password string) (*Switch, error) {
+	spec, ok := modelSpecs[model]
+	if !ok {
+		return nil, &netgear.UnsupportedModelError{Model: model}
+	}
+	s := &Switch{
+		model:    model,
+		password: password,
+		seed:     newSeed(),
+		hash:     randomString("0123456789abcdef", 32),
+		sessions: map[string]bool{},
+	}
+	for i := 1; i <= spec.noPoePorts; i++ {
+		s.poePorts = append(s.poePorts, poePort{
+			Index:        i,
+			PortPwr:      true,
+			PwrMode:      "3",
+			PortPrio:     "0",
+			LimitType:    "2",
+			PwrLimit:     "30.0",
+			DetecType:    "2",
+			LongerDetect: "2",
+		})
+	}
+	for i := 1; i <= spec.noPorts; i++ {
+		s.ports = append(s.ports, port{
+			Index:            i,
+			Speed:            "1",
+			IngressRateLimit: "1",
+			EgressRateLimit:  "1",
+			FlowControl:      "2",
+		})
+	}
+	s.poePorts[0].DeviceClass = "4"
+	s.poePorts[0].DevicePowerMil = 5800
+	s.ports[0].Connected = true
+	return s, nil
+}
+
+// NewServer starts an emulated switch of the given model, see New.
+// The caller should call Close, when finished, to shut it down.
+func NewServer(model netgear.NetgearModel, password string) (*httptest.Server, error) {
+	s, err := New(model, password)
+	if err != nil {
+		return nil, err
+	}
+	return httptest.NewServer(s), nil
+}
+
+// Model returns the emulated model
+func (s *Switch) Model() netgear.NetgearModel {
+	return s.model
+}
+
+// SetPowerUpDelay sets the time, a powered device needs to draw power again, after its port was power cycled
+// or enabled; until then, the port is searching for the device
+func (s *Switch) SetPowerUpDelay(delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.powerUpDelay = delay
+}
+
+// SetPoeFault sets the error status of a PoE port, e.g. 'Overload' or 'Short', which stops the power delivery
+// and reports the port's status as 'Fault'; an empty error status clears the fault
+func (s *Switch) SetPoeFault(port int, errorStatus string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.poePorts[port-1].fault = errorStatus
+}
+
+// ExpireSessions ends all sessions, like the switch does after some time of inactivity
+func (s *Switch) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = map[string]bool{}
+}
+
+func (s *Switch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Method == http.MethodPost && r.Header.Get("Content-Type") == "" {
+		// the switches accept form data, even without a content type
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	if s.isModel316() {
+		s.serveGs316(w, r)
+	} else {
+		s.serveGs30x(w, r)
+	}
+}
+
+func (s *Switch) isModel316() bool {
+	return s.model == netgear.GS316EP || s.model == netgear.GS316EPP
+}
+
+// checkPassword compares the encrypted password from a login request and renews the seed value,
+// so that each seed can only be used once
+func (s *Switch) checkPassword(encryptedPwd string) bool {
+	expected := encryptPassword(s.password, s.seed)
+	s.seed = newSeed()
+	return encryptedPwd == expected
+}
+
+func (s *Switch) newSession(tokenLength int) string {
+	token := randomString("abcdefghijklmnopqrstuvwxyz", tokenLength)
+	s.sessions[token] = true
+	return token
+}
+
+func (s *Switch) render(w http.ResponseWriter, name string, data any) {
+	w.Header().Set("Content-Type", "text/html")
+	err := templates.ExecuteTemplate(w, name, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Switch) reply(w http.ResponseWriter, result string) {
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = io.WriteString(w, result)
+}
+
+// poeStatus derives the PoE status values from the settings and the connected device
+type poeStatus struct {
+	Index       int
+	Name        string
+	Status      string
+	PowerClass  string
+	Voltage     int
+	Current     int
+	Power       string
+	Temperature int
+	Error       string
+}
+
+func (s *Switch) poeStatuses() []poeStatus {
+	var statuses []poeStatus
+	for _, p := range s.poePorts {
+		status := poeStatus{
+			Index:       p.Index,
+			Name:        s.ports[p.Index-1].Name,
+			Status:      "Searching",
+			Power:       "0.0",
+			Temperature: 30,
+			Error:       "No Error",
+		}
+		if !p.PortPwr {
+			status.Status = "Disabled"
+		} else if p.fault != "" {
+			status.Status = "Fault"
+			status.Error = p.fault
+		} else if isPowered(p) {
+			powerMil := p.DevicePowerMil
+			if p.LimitType == "2" {
+				limitMil := int(parseFloat(p.PwrLimit) * 1000)
+				if powerMil > limitMil {
+					powerMil = limitMil
+				}
+			}
+			status.Status = "Delivering Power"
+			status.PowerClass = p.DeviceClass
+			status.Voltage = 53
+			status.Current = powerMil / status.Voltage
+			status.Power = fmt.Sprintf("%.1f", float64(powerMil)/1000)
+			status.Temperature = 33
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// poeSettingView holds the PoE settings of a port, with codes and texts as shown by the switch
+type poeSettingView struct {
+	poePort
+	Name             string
+	PwrModeText      string
+	PortPrioText     string
+	LimitTypeText    string
+	DetecTypeText    string
+	LongerDetectText string
+}
+
+func (s *Switch) poeSettingViews() []poeSettingView {
+	var views []poeSettingView
+	for _, p := range s.poePorts {
+		views = append(views, poeSettingView{
+			poePort:          p,
+			Name:             s.ports[p.Index-1].Name,
+			PwrModeText:      pwrModeTexts[p.PwrMode],
+			PortPrioText:     portPrioTexts[p.PortPrio],
+			LimitTypeText:    limitTypeTexts[p.LimitType],
+			DetecTypeText:    detecTypeTexts[p.DetecType],
+			LongerDetectText: longerDetectTexts[p.LongerDetect],
+		})
+	}
+	return views
+}
+
+// portView holds the settings and status of a port, with codes and texts as shown by the switch
+type portView struct {
+	port
+	SpeedText            string
+	IngressRateLimitText string
+	EgressRateLimitText  string
+	FlowControlText      string
+	LinkSpeed            string
+	Status               string
+}
+
+func (s *Switch) portViews() []portView {
+	var views []portView
+	for _, p := range s.ports {
+		status := "AVAILABLE"
+		if s.isPortUp(p) {
+			status = "UP"
+		}
+		views = append(views, portView{
+			port:                 p,
+			SpeedText:            portSpeedTexts[p.Speed],
+			IngressRateLimitText: rateLimitTexts[p.IngressRateLimit],
+			EgressRateLimitText:  rateLimitTexts[p.EgressRateLimit],
+			FlowControlText:      flowControlTexts[p.FlowControl],
+			LinkSpeed:            s.linkSpeed(p),
+			Status:               status,
+		})
+	}
+	return views
+}
+
+func (s *Switch) isPortUp(p port) bool {
+	if !p.Connected || p.Speed == "2" {
+		return false
+	}
+	if p.Index <= len(s.poePorts) && s.poePorts[p.Index-1].DeviceClass != "" {
+		return isPowered(s.poePorts[p.Index-1])
+	}
+	return true
+}
+
+// isPowered is true, when a device is connected and draws power
+func isPowered(p poePort) bool {
+	return p.PortPwr && p.DeviceClass != "" && p.fault == "" && !time.Now().Before(p.poweredAfter)
+}
+
+// powerCycle starts powering the port's device again, which takes the power-up delay
+func (s *Switch) powerCycle(p *poePort) {
+	p.poweredAfter = time.Now().Add(s.powerUpDelay)
+}
+
+func (s *Switch) linkSpeed(p port) string {
+	if !s.isPortUp(p) {
+		return "No Speed"
+	}
+	switch p.Speed {
+	case "3":
+		return "10M half"
+	case "4":
+		return "10M full"
+	case "5":
+		return "100M half"
+	case "6":
+		return "100M full"
+	}
+	return "1000M full"
+}
+
+func (s *Switch) checkPoePort(portNo string) (*poePort, bool) {
+	portId, err := strconv.Atoi(portNo)
+	if err != nil || portId < 1 || portId > len(s.poePorts) {
+		return nil, false
+	}
+	return &s.poePorts[portId-1], true
+}
+
+func (s *Switch) checkPort(portNo string) (*port, bool) {
+	portId, err := strconv.Atoi(portNo)
+	if err != nil || portId < 1 || portId > len(s.ports) {
+		return nil, false
+	}
+	return &s.ports[portId-1], true
+}
+
+// isValidPwrLimit checks the power limit in Watt, which must be in the range 3.0..30.0
+func isValidPwrLimit(limit string) bool {
+	value, err := strconv.ParseFloat(limit, 64)
+	return err == nil && value >= 3.0 && value <= 30.0
+}
+
+// formatPwrLimit formats the power limit with one decimal place, like the switch does, e.g. '15' as '15.0'
+func formatPwrLimit(limit string) string {
+	value, err := strconv.ParseFloat(limit, 64)
+	if err != nil {
+		return limit
+	}
+	return strconv.FormatFloat(value, 'f', 1, 64)
+}
+
+func parseFloat(text string) float64 {
+	value, _ := strconv.ParseFloat(text, 64)
+	return value
+}
+
+func newSeed() string {
+	return strconv.Itoa(100000000 + rand.IntN(900000000))
+}
+
+func randomString(alphabet string, length int) string {
+	result := strings.Builder{}
+	for i := 0; i < length; i++ {
+		result.WriteByte(alphabet[rand.IntN(len(alphabet))])
+	}
+	return result.String()
+}
+
+// encryptPassword re-implements the password encryption of the switch's login.js
+func encryptPassword(password string, seedValue string) string {
+	merged := strings.Builder{}
+	for i := 0; i < len(password) || i < len(seedValue); i++ {
+		if i < len(password) {
+			merged.WriteByte(password[i])
+		}
+		if i < len(seedValue) {
+			merged.WriteByte(seedValue[i])
+		}
+	}
+	return fmt.Sprintf("%x", md5.Sum([]byte(merged.String())))
+}
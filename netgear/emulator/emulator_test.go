@@ -0,0 +1,378 @@
+package emulator
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/ntgrrc/netgear"
+)
+
+const testPassword = "secret"
+
+func loggedInClient(t *testing.T, model netgear.NetgearModel) *netgear.Client {
+	server, err := NewServer(model, testPassword)
+	then.AssertThat(t, err, is.Nil())
+	t.Cleanup(server.Close)
+
+	client := netgear.NewClient(strings.TrimPrefix(server.URL, "http://"))
+	err = client.Login(testPassword)
+	then.AssertThat(t, err, is.Nil())
+	return client
+}
+
+func loggedInSwitch(t *testing.T, model netgear.NetgearModel) (*Switch, *netgear.Client) {
+	sw, err := New(model, testPassword)
+	then.AssertThat(t, err, is.Nil())
+	server := httptest.NewServer(sw)
+	t.Cleanup(server.Close)
+
+	client := netgear.NewClient(strings.TrimPrefix(server.URL, "http://"))
+	err = client.Login(testPassword)
+	then.AssertThat(t, err, is.Nil())
+	return sw, client
+}
+
+func TestLoginDetectsModel(t *testing.T) {
+	var tests = []struct {
+		model         netgear.NetgearModel
+		detectedModel netgear.NetgearModel
+	}{
+		{model: netgear.GS308EPP, detectedModel: netgear.GS30xEPx},
+		{model: netgear.GS316EP, detectedModel: netgear.GS316EP},
+		{model: netgear.GS316EPP, detectedModel: netgear.GS316EPP},
+	}
+	for _, test := range tests {
+		t.Run(string(test.model), func(t *testing.T) {
+			client := loggedInClient(t, test.model)
+
+			then.AssertThat(t, client.Model(), is.EqualTo(test.detectedModel))
+			then.AssertThat(t, client.Token(), is.Not(is.EmptyString()))
+		})
+	}
+}
+
+func TestLoginWithWrongPasswordFails(t *testing.T) {
+	for _, model := range []netgear.NetgearModel{netgear.GS308EPP, netgear.GS316EP} {
+		t.Run(string(model), func(t *testing.T) {
+			server, err := NewServer(model, testPassword)
+			then.AssertThat(t, err, is.Nil())
+			defer server.Close()
+			client := netgear.NewClient(strings.TrimPrefix(server.URL, "http://"))
+
+			err = client.Login("wrong")
+
+			var loginFailed *netgear.LoginFailedError
+			then.AssertThat(t, errors.As(err, &loginFailed), is.True())
+		})
+	}
+}
+
+func TestUnknownSessionRequiresLogin(t *testing.T) {
+	var tests = []struct {
+		model      netgear.NetgearModel
+		clientType netgear.NetgearModel
+	}{
+		{model: netgear.GS305EP, clientType: netgear.GS30xEPx},
+		{model: netgear.GS316EP, clientType: netgear.GS316EP},
+	}
+	for _, test := range tests {
+		t.Run(string(test.model), func(t *testing.T) {
+			server, err := NewServer(test.model, testPassword)
+			then.AssertThat(t, err, is.Nil())
+			defer server.Close()
+			client := netgear.NewClient(strings.TrimPrefix(server.URL, "http://"), netgear.WithSession(test.clientType, "unknown"))
+
+			_, err = client.PoeStatus()
+
+			then.AssertThat(t, errors.Is(err, netgear.ErrLoginRequired), is.True())
+		})
+	}
+}
+
+func TestPoeStatus(t *testing.T) {
+	var tests = []struct {
+		model      netgear.NetgearModel
+		noPoePorts int
+	}{
+		{model: netgear.GS305EPP, noPoePorts: 4},
+		{model: netgear.GS308EPP, noPoePorts: 8},
+		{model: netgear.GS316EPP, noPoePorts: 15},
+	}
+	for _, test := range tests {
+		t.Run(string(test.model), func(t *testing.T) {
+			client := loggedInClient(t, test.model)
+
+			statuses, err := client.PoeStatus()
+
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, statuses, has.Length[netgear.PoePortStatus](test.noPoePorts))
+			then.AssertThat(t, statuses[0].PortIndex, is.EqualTo(int8(1)))
+			then.AssertThat(t, statuses[0].PoePortStatus, is.EqualTo("Delivering Power"))
+			then.AssertThat(t, statuses[0].PoePowerClass, is.EqualTo("4"))
+			then.AssertThat(t, statuses[0].PowerInWatt, is.EqualTo(float32(5.8)))
+			then.AssertThat(t, statuses[1].PoePortStatus, is.EqualTo("Searching"))
+		})
+	}
+}
+
+func TestSetPoeChangesSettings(t *testing.T) {
+	for _, model := range []netgear.NetgearModel{netgear.GS308EPP, netgear.GS316EP} {
+		t.Run(string(model), func(t *testing.T) {
+			client := loggedInClient(t, model)
+
+			changed, err := client.SetPoe([]int{2, 3}, netgear.PoePortSettingsUpdate{
+				PortPwr:  "disable",
+				PortPrio: "critical",
+				PwrLimit: "15.4",
+			})
+
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, changed, has.Length[netgear.PoePortSetting](2))
+
+			settings, err := client.PoeSettings()
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, settings[0].PortPwr, is.True())
+			then.AssertThat(t, settings[0].PwrLimit, is.EqualTo("30.0"))
+			for _, setting := range settings[1:3] {
+				then.AssertThat(t, setting.PortPwr, is.False())
+				then.AssertThat(t, strings.ToLower(setting.PortPrio), is.EqualTo("critical"))
+				then.AssertThat(t, setting.PwrLimit, is.EqualTo("15.4"))
+			}
+
+			statuses, err := client.PoeStatus()
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, statuses[1].PoePortStatus, is.EqualTo("Disabled"))
+		})
+	}
+}
+
+func TestSetPoeRejectsPortOutOfRange(t *testing.T) {
+	client := loggedInClient(t, netgear.GS305EP)
+
+	_, err := client.SetPoe([]int{5}, netgear.PoePortSettingsUpdate{PortPwr: "disable"})
+
+	var outOfRange *netgear.PortOutOfRangeError
+	then.AssertThat(t, errors.As(err, &outOfRange), is.True())
+	then.AssertThat(t, outOfRange.MaxPort, is.EqualTo(4))
+}
+
+func TestSetPoeRejectsPwrLimitOutOfTheRangeOfThePort(t *testing.T) {
+	for _, pwrLimit := range []string{"15.5", "31"} {
+		t.Run(pwrLimit, func(t *testing.T) {
+			client := loggedInClient(t, netgear.GS316EP)
+
+			_, err := client.SetPoe([]int{1, 2}, netgear.PoePortSettingsUpdate{PwrLimit: pwrLimit})
+
+			var invalidSetting *netgear.InvalidSettingError
+			then.AssertThat(t, errors.As(err, &invalidSetting), is.True())
+			then.AssertThat(t, err.Error(), is.EqualTo("invalid power limit '"+pwrLimit+"' for port 1, allowed are 3 to 30 watts in steps of 0.2 watts"))
+			settings, err := client.PoeSettings()
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, settings[0].PwrLimit, is.EqualTo("30.0"))
+			then.AssertThat(t, settings[0].MaxPwrLimit, is.EqualTo[float32](30))
+		})
+	}
+}
+
+func TestDisablingPoeTakesDownPoweredDevice(t *testing.T) {
+	client := loggedInClient(t, netgear.GS308EP)
+
+	_, err := client.SetPoe([]int{1}, netgear.PoePortSettingsUpdate{PortPwr: "disable"})
+	then.AssertThat(t, err, is.Nil())
+
+	settings, err := client.PortSettings()
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, settings[0].PortStatus, is.EqualTo("AVAILABLE"))
+	then.AssertThat(t, settings[0].LinkSpeed, is.EqualTo("No Speed"))
+}
+
+func TestPoeFaultStopsPowerDelivery(t *testing.T) {
+	for _, model := range []netgear.NetgearModel{netgear.GS308EPP, netgear.GS316EP} {
+		t.Run(string(model), func(t *testing.T) {
+			sw, client := loggedInSwitch(t, model)
+			sw.SetPoeFault(1, "Overload")
+
+			statuses, err := client.PoeStatus()
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, statuses[0].PoePortStatus, is.EqualTo("Fault"))
+			then.AssertThat(t, statuses[0].ErrorStatus, is.EqualTo("Overload"))
+
+			sw.SetPoeFault(1, "")
+
+			statuses, err = client.PoeStatus()
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, statuses[0].PoePortStatus, is.EqualTo("Delivering Power"))
+			then.AssertThat(t, statuses[0].ErrorStatus, is.EqualTo("No Error"))
+		})
+	}
+}
+
+func TestCyclePoe(t *testing.T) {
+	for _, model := range []netgear.NetgearModel{netgear.GS308EPP, netgear.GS316EP} {
+		t.Run(string(model), func(t *testing.T) {
+			client := loggedInClient(t, model)
+
+			statuses, err := client.CyclePoe([]int{1, 3})
+
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, statuses, has.Length[netgear.PoePortStatus](2))
+		})
+	}
+}
+
+func TestCyclePoeWaitsForPower(t *testing.T) {
+	for _, model := range []netgear.NetgearModel{netgear.GS308EPP, netgear.GS316EP} {
+		t.Run(string(model), func(t *testing.T) {
+			sw, client := loggedInSwitch(t, model)
+			sw.SetPowerUpDelay(50 * time.Millisecond)
+
+			statuses, err := client.CyclePoeWithOptions([]int{1, 2}, netgear.PoeCycleOptions{WaitTimeout: 5 * time.Second, PollInterval: 10 * time.Millisecond})
+
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, statuses, has.Length[netgear.PoePortStatus](2))
+			then.AssertThat(t, statuses[0].PoePortStatus, is.EqualTo(netgear.PoeDeliveringPower))
+		})
+	}
+}
+
+func TestCyclePoeWithOffTimeEnablesPortsAgain(t *testing.T) {
+	sw, client := loggedInSwitch(t, netgear.GS316EP)
+	sw.SetPowerUpDelay(10 * time.Millisecond)
+
+	statuses, err := client.CyclePoeWithOptions([]int{1}, netgear.PoeCycleOptions{OffTime: 10 * time.Millisecond, WaitTimeout: 5 * time.Second, PollInterval: 10 * time.Millisecond})
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, statuses[0].PoePortStatus, is.EqualTo(netgear.PoeDeliveringPower))
+	settings, err := client.PoeSettings()
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, settings[0].PortPwr, is.True())
+}
+
+func TestCyclePoeFailsWhenPortDoesNotDeliverPowerAgain(t *testing.T) {
+	sw, client := loggedInSwitch(t, netgear.GS308EPP)
+	sw.SetPowerUpDelay(time.Hour)
+
+	statuses, err := client.CyclePoeWithOptions([]int{1, 2}, netgear.PoeCycleOptions{WaitTimeout: 30 * time.Millisecond, PollInterval: 10 * time.Millisecond})
+
+	var timeout *netgear.PowerUpTimeoutError
+	then.AssertThat(t, errors.As(err, &timeout), is.True())
+	then.AssertThat(t, timeout.Ports, is.EqualTo([]int{1}))
+	then.AssertThat(t, statuses[0].PoePortStatus, is.EqualTo("Searching"))
+}
+
+func TestSetPortChangesSettings(t *testing.T) {
+	for _, model := range []netgear.NetgearModel{netgear.GS308EPP, netgear.GS316EP} {
+		t.Run(string(model), func(t *testing.T) {
+			client := loggedInClient(t, model)
+			name := "camera"
+
+			changed, err := client.SetPort([]int{4}, netgear.PortSettingsUpdate{
+				Name:             &name,
+				Speed:            "100M full",
+				IngressRateLimit: "1 Mbit/s",
+				FlowControl:      "On",
+			})
+
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, changed, has.Length[netgear.PortSetting](1))
+			then.AssertThat(t, changed[0].Name, is.EqualTo("camera"))
+			then.AssertThat(t, strings.ToLower(changed[0].Speed), is.EqualTo("100m full"))
+			then.AssertThat(t, changed[0].IngressRateLimit, is.EqualTo("1 Mbit/s"))
+			then.AssertThat(t, changed[0].EgressRateLimit, is.EqualTo("No Limit"))
+			then.AssertThat(t, strings.ToLower(changed[0].FlowControl), is.EqualTo("on"))
+
+			statuses, err := client.PoeStatus()
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, statuses[3].PortName, is.EqualTo("camera"))
+		})
+	}
+}
+
+func TestNewRejectsUnknownModel(t *testing.T) {
+	_, err := New("GS108", testPassword)
+
+	then.AssertThat(t, errors.Is(err, netgear.ErrNotSupported), is.True())
+}
+
+func TestReLoginAfterSessionExpired(t *testing.T) {
+	for _, model := range []netgear.NetgearModel{netgear.GS308EPP, netgear.GS316EP} {
+		t.Run(string(model), func(t *testing.T) {
+			sw, err := New(model, testPassword)
+			then.AssertThat(t, err, is.Nil())
+			server := httptest.NewServer(sw)
+			defer server.Close()
+			var storedTokens []string
+			client := netgear.NewClient(strings.TrimPrefix(server.URL, "http://"),
+				netgear.WithReLogin(
+					func() (string, error) { return testPassword, nil },
+					func(c *netgear.Client) error {
+						storedTokens = append(storedTokens, c.Token())
+						return nil
+					}))
+
+			_, err = client.PoeStatus()
+			then.AssertThat(t, err, is.Nil())
+			sw.ExpireSessions()
+			_, err = client.SetPoe([]int{2}, netgear.PoePortSettingsUpdate{PortPwr: "disable"})
+			then.AssertThat(t, err, is.Nil())
+
+			then.AssertThat(t, storedTokens, has.Length[string](2))
+			then.AssertThat(t, storedTokens[1], is.EqualTo(client.Token()))
+		})
+	}
+}
+
+func TestExpiredSessionWithoutReLogin(t *testing.T) {
+	sw, err := New(netgear.GS308EPP, testPassword)
+	then.AssertThat(t, err, is.Nil())
+	server := httptest.NewServer(sw)
+	defer server.Close()
+	client := netgear.NewClient(strings.TrimPrefix(server.URL, "http://"))
+	err = client.Login(testPassword)
+	then.AssertThat(t, err, is.Nil())
+
+	sw.ExpireSessions()
+	_, err = client.PortSettings()
+
+	then.AssertThat(t, errors.Is(err, netgear.ErrLoginRequired), is.True())
+}
+
+func TestLogoutEndsSession(t *testing.T) {
+	client := loggedInClient(t, netgear.GS308EPP)
+	staleClient := netgear.NewClient(client.Address(), netgear.WithSession(client.Model(), client.Token()))
+
+	err := client.Logout()
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, client.Token(), is.EqualTo(""))
+	_, err = staleClient.PoeStatus()
+	then.AssertThat(t, errors.Is(err, netgear.ErrLoginRequired), is.True())
+}
+
+func TestLogoutIsNotSupportedByGs316(t *testing.T) {
+	client := loggedInClient(t, netgear.GS316EP)
+
+	err := client.Logout()
+
+	then.AssertThat(t, errors.Is(err, netgear.ErrNotSupported), is.True())
+	_, err = client.PoeStatus()
+	then.AssertThat(t, err, is.Nil())
+}
+
+func TestCheckSession(t *testing.T) {
+	for _, model := range []netgear.NetgearModel{netgear.GS308EPP, netgear.GS316EP} {
+		t.Run(string(model), func(t *testing.T) {
+			client := loggedInClient(t, model)
+			expiredClient := netgear.NewClient(client.Address(), netgear.WithSession(client.Model(), "expired"))
+
+			then.AssertThat(t, client.CheckSession(), is.Nil())
+			then.AssertThat(t, errors.Is(expiredClient.CheckSession(), netgear.ErrLoginRequired), is.True())
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package emulator
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+func (s *Switch) serveGs30x(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/" {
+		s.render(w, "gs30x_root.html", nil)
+		return
+	}
+	if r.URL.Path == "/login.cgi" {
+		s.loginGs30x(w, r)
+		return
+	}
+	if !s.hasSessionGs30x(r) {
+		// the switch redirects to the login page, for all pages which require a session
+		s.render(w, "gs30x_root.html", nil)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/logout.cgi":
+		cookie, _ := r.Cookie("SID")
+		delete(s.sessions, cookie.Value)
+		s.render(w, "gs30x_root.html", nil)
+	case r.URL.Path == "/getPoePortStatus.cgi" && r.Method == http.MethodGet:
+		s.render(w, "gs30x_poe_status.html", s.poeStatuses())
+	case r.URL.Path == "/PoEPortConfig.cgi" && r.Method == http.MethodGet:
+		s.render(w, "gs30x_poe_config.html", map[string]any{"Ports": s.poeSettingViews(), "Hash": s.hash})
+	case r.URL.Path == "/PoEPortConfig.cgi" && r.Method == http.MethodPost:
+		s.reply(w, s.updatePoeConfigGs30x(r))
+	case r.URL.Path == "/dashboard.cgi" && r.Method == http.MethodGet:
+		s.render(w, "gs30x_dashboard.html", map[string]any{"Model": s.model, "Ports": s.portViews(), "Hash": s.hash})
+	case r.URL.Path == "/port_status.cgi" && r.Method == http.MethodPost:
+		s.reply(w, s.updatePortStatusGs30x(r))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Switch) loginGs30x(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost && s.checkPassword(r.PostFormValue("password")) {
+		sid := s.newSession(64)
+		w.Header().Set("Set-Cookie", fmt.Sprintf("SID=%s; SameSite=Lax;path=/;HttpOnly", sid))
+		s.render(w, "gs30x_login_success.html", nil)
+		return
+	}
+	s.render(w, "gs30x_login.html", map[string]any{"Model": s.model, "Seed": s.seed})
+}
+
+func (s *Switch) hasSessionGs30x(r *http.Request) bool {
+	cookie, err := r.Cookie("SID")
+	return err == nil && s.sessions[cookie.Value]
+}
+
+func (s *Switch) updatePoeConfigGs30x(r *http.Request) string {
+	if r.PostFormValue("hash") != s.hash {
+		return "ERROR: invalid hash"
+	}
+
+	switch r.PostFormValue("ACTION") {
+	case "Apply":
+		portId, err := strconv.Atoi(r.PostFormValue("portID"))
+		if err != nil {
+			return "ERROR: invalid portID"
+		}
+		p, ok := s.checkPoePort(strconv.Itoa(portId + 1))
+		if !ok {
+			return "ERROR: invalid portID"
+		}
+		update := *p
+		update.PortPwr = r.PostFormValue("ADMIN_MODE") == "1"
+		update.PortPrio = r.PostFormValue("PORT_PRIO")
+		update.PwrMode = r.PostFormValue("POW_MOD")
+		update.LimitType = r.PostFormValue("POW_LIMT_TYP")
+		update.PwrLimit = formatPwrLimit(r.PostFormValue("POW_LIMT"))
+		update.DetecType = r.PostFormValue("DETEC_TYP")
+		update.LongerDetect = r.PostFormValue("DISCONNECT_TYP")
+		if result := validatePoePort(update); result != "" {
+			return result
+		}
+		if !p.PortPwr && update.PortPwr {
+			s.powerCycle(&update)
+		}
+		*p = update
+	case "Reset":
+		for i := range s.poePorts {
+			if r.PostFormValue(fmt.Sprintf("port%d", i)) == "checked" {
+				s.powerCycle(&s.poePorts[i])
+			}
+		}
+	default:
+		return "ERROR: invalid ACTION"
+	}
+	return "SUCCESS"
+}
+
+func (s *Switch) updatePortStatusGs30x(r *http.Request) string {
+	if r.PostFormValue("hash") != s.hash {
+		return "ERROR: invalid hash"
+	}
+
+	var ports []*port
+	for i := range s.ports {
+		if r.PostFormValue(fmt.Sprintf("port%d", i+1)) == "checked" {
+			ports = append(ports, &s.ports[i])
+		}
+	}
+	if len(ports) == 0 {
+		return "ERROR: no port selected"
+	}
+
+	for _, p := range ports {
+		update := *p
+		update.Name = r.PostFormValue("DESCRIPTION")
+		update.Speed = r.PostFormValue("SPEED")
+		update.IngressRateLimit = r.PostFormValue("IngressRate")
+		update.EgressRateLimit = r.PostFormValue("EgressRate")
+		update.FlowControl = r.PostFormValue("FLOW_CONTROL")
+		if result := validatePort(update); result != "" {
+			return result
+		}
+		*p = update
+	}
+	return "SUCCESS"
+}
+
+// validatePoePort returns an error message, if a setting is invalid
+func validatePoePort(p poePort) string {
+	switch {
+	case !isKnownCode(p.PortPrio, portPrioTexts):
+		return "ERROR: invalid port priority"
+	case !isKnownCode(p.PwrMode, pwrModeTexts):
+		return "ERROR: invalid power mode"
+	case !isKnownCode(p.LimitType, limitTypeTexts):
+		return "ERROR: invalid power limit type"
+	case !isValidPwrLimit(p.PwrLimit):
+		return "ERROR: invalid power limit"
+	case !isKnownCode(p.DetecType, detecTypeTexts):
+		return "ERROR: invalid detection type"
+	case !isKnownCode(p.LongerDetect, longerDetectTexts):
+		return "ERROR: invalid longer detection type"
+	}
+	return ""
+}
+
+// validatePort returns an error message, if a setting is invalid
+func validatePort(p port) string {
+	switch {
+	case len(p.Name) > 16:
+		return "ERROR: invalid port name"
+	case !isKnownCode(p.Speed, portSpeedTexts):
+		return "ERROR: invalid speed"
+	case !isKnownCode(p.IngressRateLimit, rateLimitTexts):
+		return "ERROR: invalid ingress rate limit"
+	case !isKnownCode(p.EgressRateLimit, rateLimitTexts):
+		return "ERROR: invalid egress rate limit"
+	case !isKnownCode(p.FlowControl, flowControlTexts):
+		return "ERROR: invalid flow control"
+	}
+	return ""
+}
@@ -0,0 +1,188 @@
+package emulator
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func (s *Switch) serveGs316(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/":
+		s.render(w, "gs316_root.html", s.model)
+		return
+	case "/wmi/login":
+		s.render(w, "gs316_login.html", map[string]any{"Model": s.model, "Seed": s.seed})
+		return
+	case "/redirect.html":
+		s.loginGs316(w, r)
+		return
+	}
+	if !s.sessions[r.FormValue("Gambit")] {
+		s.render(w, "gs316_login_redirect.html", nil)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/iss/specific/poePortStatus.html" && r.Method == http.MethodGet:
+		s.render(w, "gs316_poe_status.html", s.poeStatuses())
+	case r.URL.Path == "/iss/specific/poePortConf.html" && r.Method == http.MethodGet:
+		s.render(w, "gs316_poe_config.html", map[string]any{"Ports": s.poeSettingViewsGs316(), "Gambit": r.FormValue("Gambit")})
+	case r.URL.Path == "/iss/specific/poePortConf.html" && r.Method == http.MethodPost:
+		s.reply(w, s.updatePoeConfigGs316(r))
+	case r.URL.Path == "/iss/specific/dashboard.html" && r.Method == http.MethodGet:
+		s.render(w, "gs316_dashboard.html", map[string]any{"Model": s.model, "Ports": s.portViewsGs316()})
+	case r.URL.Path == "/iss/specific/dashboard.html" && r.Method == http.MethodPost:
+		s.reply(w, s.updatePortInfoGs316(r))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Switch) loginGs316(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost && s.checkPassword(r.PostFormValue("LoginPassword")) {
+		s.render(w, "gs316_redirect.html", s.newSession(20))
+		return
+	}
+	s.render(w, "gs316_login.html", map[string]any{"Model": s.model, "Seed": s.seed})
+}
+
+func (s *Switch) poeSettingViewsGs316() []poeSettingView {
+	views := s.poeSettingViews()
+	for i := range views {
+		if views[i].DetecType == "2" {
+			// the GS316 shows the detection type without a space
+			views[i].DetecTypeText = "IEEE802"
+		}
+	}
+	return views
+}
+
+func (s *Switch) portViewsGs316() []portView {
+	views := s.portViews()
+	for i := range views {
+		// the GS316 capitalizes the duplex mode, e.g. '100M Full', and names the port status differently
+		views[i].SpeedText = capitalizeDuplex(views[i].SpeedText)
+		views[i].LinkSpeed = capitalizeDuplex(views[i].LinkSpeed)
+		switch {
+		case views[i].Speed == "2":
+			views[i].Status = "DISABLED"
+		case views[i].Status == "UP":
+			views[i].Status = "CONNECTED"
+		}
+	}
+	return views
+}
+
+func capitalizeDuplex(speed string) string {
+	return strings.NewReplacer("half", "Half", "full", "Full").Replace(speed)
+}
+
+func (s *Switch) updatePoeConfigGs316(r *http.Request) string {
+	switch r.PostFormValue("TYPE") {
+	case "submitPoe":
+		p, ok := s.checkPoePort(r.PostFormValue("PORT_NO"))
+		if !ok {
+			return "ERROR: invalid PORT_NO"
+		}
+		update := *p
+		if value := r.PostFormValue("ADMIN_STATE"); value != "NOTSET" {
+			update.PortPwr = value == "1"
+		}
+		if value := r.PostFormValue("PRIORITY"); value != "NOTSET" {
+			update.PortPrio = portPrioCodesGs316[value]
+		}
+		if value := r.PostFormValue("POWER_MODE"); value != "NOTSET" {
+			update.PwrMode = value
+		}
+		if value := r.PostFormValue("POWER_LIMIT_TYPE"); value != "NOTSET" {
+			update.LimitType = value
+		}
+		if value := r.PostFormValue("POWER_LIMIT_VALUE"); value != "NOTSET" {
+			deciWatt, err := strconv.Atoi(value)
+			if err != nil {
+				return "ERROR: invalid power limit"
+			}
+			update.PwrLimit = strconv.FormatFloat(float64(deciWatt)/10, 'f', 1, 64)
+		}
+		if value := r.PostFormValue("DETECTION"); value != "NOTSET" {
+			update.DetecType = value
+		}
+		if value := r.PostFormValue("DISCONNECT_TYPE"); value != "NOTSET" {
+			update.LongerDetect = value
+		}
+		if result := validatePoePort(update); result != "" {
+			return result
+		}
+		if !p.PortPwr && update.PortPwr {
+			s.powerCycle(&update)
+		}
+		*p = update
+	case "resetPoe":
+		reset := r.PostFormValue("PoePort")
+		if len(reset) != len(s.poePorts) {
+			return "ERROR: invalid PoePort"
+		}
+		for i := range s.poePorts {
+			if reset[i] == '1' {
+				s.powerCycle(&s.poePorts[i])
+			}
+		}
+	default:
+		return "ERROR: invalid TYPE"
+	}
+	return "SUCCESS"
+}
+
+func (s *Switch) updatePortInfoGs316(r *http.Request) string {
+	if r.PostFormValue("TYPE") != "portInfo" {
+		return "ERROR: invalid TYPE"
+	}
+	p, ok := s.checkPort(r.PostFormValue("PORT_NO"))
+	if !ok {
+		return "ERROR: invalid PORT_NO"
+	}
+
+	update := *p
+	update.Name = r.PostFormValue("PORT_NAME")
+	if value := r.PostFormValue("INGRESS"); value != "NOTSET" {
+		update.IngressRateLimit = value
+	}
+	if value := r.PostFormValue("EGRESS"); value != "NOTSET" {
+		update.EgressRateLimit = value
+	}
+	if value := r.PostFormValue("FLOW_CONTROL"); value != "NOTSET" {
+		update.FlowControl = flowControlCodesGs316[value]
+	}
+	switch r.PostFormValue("PORT_CTRL_MODE") {
+	case "NOTSET":
+	case "1":
+		update.Speed = "1"
+	case "3":
+		update.Speed = "2"
+	case "2":
+		update.Speed = speedCodeGs316(r.PostFormValue("PORT_CTRL_SPEED"), r.PostFormValue("PORT_CTRL_DUPLEX"))
+	default:
+		update.Speed = ""
+	}
+	if result := validatePort(update); result != "" {
+		return result
+	}
+	*p = update
+	return "SUCCESS"
+}
+
+// speedCodeGs316 maps the GS316's speed (1=10M, 2=100M) and duplex (1=full, 2=half) to the GS30x speed code
+func speedCodeGs316(speed string, duplex string) string {
+	switch speed + "/" + duplex {
+	case "1/2":
+		return "3"
+	case "1/1":
+		return "4"
+	case "2/2":
+		return "5"
+	case "2/1":
+		return "6"
+	}
+	return ""
+}
@@ -0,0 +1,201 @@
+package emulator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/ntgrrc/netgear"
+)
+
+// The templates are built from the pages in test-data. These tests set up the emulator with the values of such a page,
+// render the emulator's page, and assert, that the library parses both pages the same way.
+
+func TestPoeStatusPageParsesLikeTestData(t *testing.T) {
+	var tests = []struct {
+		model    netgear.NetgearModel
+		fileName string
+		template string
+	}{
+		{model: netgear.GS305EP, fileName: "getPoePortStatus.cgi.html", template: "gs30x_poe_status.html"},
+		{model: netgear.GS308EPP, fileName: "getPoePortStatus.cgi.html", template: "gs30x_poe_status.html"},
+		{model: netgear.GS316EP, fileName: "poePortStatus_GetData_true.html", template: "gs316_poe_status.html"},
+	}
+	for _, test := range tests {
+		t.Run(string(test.model), func(t *testing.T) {
+			driver := driverFor(t, test.model)
+			expected, err := driver.ParsePoeStatus(strings.NewReader(testDataPage(t, test.model, test.fileName)))
+			then.AssertThat(t, err, is.Nil())
+
+			// the measured values are views only, thus the statuses are rendered as parsed
+			var statuses []poeStatus
+			for _, status := range expected {
+				statuses = append(statuses, poeStatus{
+					Index:       int(status.PortIndex),
+					Name:        status.PortName,
+					Status:      status.PoePortStatus,
+					PowerClass:  status.PoePowerClass,
+					Voltage:     int(status.VoltageInVolt),
+					Current:     int(status.CurrentInMilliAmps),
+					Power:       fmt.Sprintf("%.1f", status.PowerInWatt),
+					Temperature: int(status.TemperatureInCelsius),
+					Error:       status.ErrorStatus,
+				})
+			}
+			actual, err := driver.ParsePoeStatus(strings.NewReader(renderPage(t, test.template, statuses)))
+
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, len(expected) > 0, is.True())
+			then.AssertThat(t, actual, is.EqualTo(expected))
+		})
+	}
+}
+
+func TestPoeSettingsPageParsesLikeTestData(t *testing.T) {
+	// the GS305EP's page in test-data is from a firmware without the longer detection time
+	var tests = []struct {
+		model    netgear.NetgearModel
+		fileName string
+	}{
+		{model: netgear.GS308EPP, fileName: "PoEPortConfig.cgi.html"},
+		{model: netgear.GS316EP, fileName: "poePortConf.html"},
+	}
+	for _, test := range tests {
+		t.Run(string(test.model), func(t *testing.T) {
+			driver := driverFor(t, test.model)
+			expected, err := driver.ParsePoeSettings(strings.NewReader(testDataPage(t, test.model, test.fileName)))
+			then.AssertThat(t, err, is.Nil())
+
+			s := newSwitch(t, test.model)
+			s.poePorts = nil
+			for _, setting := range expected {
+				s.poePorts = append(s.poePorts, poePort{
+					Index:        int(setting.PortIndex),
+					PortPwr:      setting.PortPwr,
+					PwrMode:      codeOf(t, pwrModeTexts, setting.PwrMode),
+					PortPrio:     codeOf(t, portPrioTexts, setting.PortPrio),
+					LimitType:    codeOf(t, limitTypeTexts, setting.LimitType),
+					PwrLimit:     setting.PwrLimit,
+					DetecType:    codeOf(t, detecTypeTexts, setting.DetecType),
+					LongerDetect: codeOf(t, longerDetectTexts, setting.LongerDetect),
+				})
+				s.ports[setting.PortIndex-1].Name = setting.PortName
+			}
+			var page string
+			if s.isModel316() {
+				page = renderPage(t, "gs316_poe_config.html", map[string]any{"Ports": s.poeSettingViewsGs316(), "Gambit": "gambit"})
+			} else {
+				page = renderPage(t, "gs30x_poe_config.html", map[string]any{"Ports": s.poeSettingViews(), "Hash": s.hash})
+			}
+			actual, err := driver.ParsePoeSettings(strings.NewReader(page))
+
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, len(expected) > 0, is.True())
+			then.AssertThat(t, actual, is.EqualTo(expected))
+		})
+	}
+}
+
+func TestDashboardPageParsesLikeTestData(t *testing.T) {
+	var tests = []struct {
+		model    netgear.NetgearModel
+		fileName string
+	}{
+		{model: netgear.GS308EPP, fileName: "dashboard.cgi.html"},
+		{model: netgear.GS316EP, fileName: "dashboard.html"},
+	}
+	for _, test := range tests {
+		t.Run(string(test.model), func(t *testing.T) {
+			driver := driverFor(t, test.model)
+			expected, err := driver.ParsePortSettings(strings.NewReader(testDataPage(t, test.model, test.fileName)))
+			then.AssertThat(t, err, is.Nil())
+
+			s := newSwitch(t, test.model)
+			// the ports are connected as stated by the page, no matter of a powered device
+			s.poePorts[0].DeviceClass = ""
+			s.ports = nil
+			for _, setting := range expected {
+				s.ports = append(s.ports, port{
+					Index:            int(setting.Index),
+					Name:             setting.Name,
+					Speed:            codeOf(t, portSpeedTexts, setting.Speed),
+					IngressRateLimit: codeOf(t, rateLimitTexts, setting.IngressRateLimit),
+					EgressRateLimit:  codeOf(t, rateLimitTexts, setting.EgressRateLimit),
+					FlowControl:      codeOf(t, flowControlTexts, setting.FlowControl),
+					Connected:        setting.LinkSpeed != "No Speed",
+				})
+			}
+			var page string
+			if s.isModel316() {
+				page = renderPage(t, "gs316_dashboard.html", map[string]any{"Model": s.model, "Ports": s.portViewsGs316()})
+			} else {
+				page = renderPage(t, "gs30x_dashboard.html", map[string]any{"Model": s.model, "Ports": s.portViews(), "Hash": s.hash})
+			}
+			actual, err := driver.ParsePortSettings(strings.NewReader(page))
+
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, len(expected) > 0, is.True())
+			then.AssertThat(t, actual, is.EqualTo(expected))
+		})
+	}
+}
+
+func TestRootPageRequiresLoginLikeTestData(t *testing.T) {
+	var tests = []struct {
+		model    netgear.NetgearModel
+		template string
+		data     any
+	}{
+		{model: netgear.GS308EPP, template: "gs30x_root.html"},
+		{model: netgear.GS316EP, template: "gs316_root.html", data: netgear.GS316EP},
+	}
+	for _, test := range tests {
+		t.Run(string(test.model), func(t *testing.T) {
+			then.AssertThat(t, netgear.IsLoginRequired(testDataPage(t, test.model, "_root.html")), is.True())
+			then.AssertThat(t, netgear.IsLoginRequired(renderPage(t, test.template, test.data)), is.True())
+		})
+	}
+}
+
+func driverFor(t *testing.T, model netgear.NetgearModel) netgear.Driver {
+	driver, err := netgear.DriverFor(model)
+	then.AssertThat(t, err, is.Nil())
+	return driver
+}
+
+func newSwitch(t *testing.T, model netgear.NetgearModel) *Switch {
+	s, err := New(model, testPassword)
+	then.AssertThat(t, err, is.Nil())
+	return s
+}
+
+func testDataPage(t *testing.T, model netgear.NetgearModel, fileName string) string {
+	page, err := os.ReadFile(filepath.Join("..", "..", "test-data", string(model), fileName))
+	then.AssertThat(t, err, is.Nil())
+	return string(page)
+}
+
+func renderPage(t *testing.T, name string, data any) string {
+	var page strings.Builder
+	err := templates.ExecuteTemplate(&page, name, data)
+	then.AssertThat(t, err, is.Nil())
+	return page.String()
+}
+
+// codeOf finds the code of a text, shown by a switch; the GS316's texts differ in case and spaces only
+func codeOf(t *testing.T, texts map[string]string, text string) string {
+	normalized := func(text string) string {
+		return strings.ToLower(strings.ReplaceAll(text, " ", ""))
+	}
+	for code, candidate := range texts {
+		if normalized(candidate) == normalized(text) {
+			return code
+		}
+	}
+	t.Fatalf("unknown text '%s'", text)
+	return ""
+}
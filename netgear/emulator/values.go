@@ -0,0 +1,79 @@
+package emulator
+
+// the texts, shown by the switch for the numeric codes of the GS30x firmware
+var pwrModeTexts = map[string]string{
+	"0": "802.3af",
+	"1": "legacy",
+	"2": "pre-802.3at",
+	"3": "802.3at",
+}
+
+var portPrioTexts = map[string]string{
+	"0": "Low",
+	"2": "High",
+	"3": "Critical",
+}
+
+// GS316 uses `1:low`, `2:high`, `3:critical`
+var portPrioCodesGs316 = map[string]string{
+	"1": "0",
+	"2": "2",
+	"3": "3",
+}
+
+var limitTypeTexts = map[string]string{
+	"0": "None",
+	"1": "Class",
+	"2": "User",
+}
+
+var detecTypeTexts = map[string]string{
+	"1": "Legacy",
+	"2": "IEEE 802",
+	"3": "4pt 802.3af + Legacy",
+}
+
+var longerDetectTexts = map[string]string{
+	"2": "Disable",
+	"3": "Enable",
+}
+
+var portSpeedTexts = map[string]string{
+	"1": "Auto",
+	"2": "Disable",
+	"3": "10M half",
+	"4": "10M full",
+	"5": "100M half",
+	"6": "100M full",
+}
+
+var rateLimitTexts = map[string]string{
+	"1":  "No Limit",
+	"2":  "512 Kbit/s",
+	"3":  "1 Mbit/s",
+	"4":  "2 Mbit/s",
+	"5":  "4 Mbit/s",
+	"6":  "8 Mbit/s",
+	"7":  "16 Mbit/s",
+	"8":  "32 Mbit/s",
+	"9":  "64 Mbit/s",
+	"10": "128 Mbit/s",
+	"11": "256 Mbit/s",
+	"12": "512 Mbit/s",
+}
+
+var flowControlTexts = map[string]string{
+	"1": "ON",
+	"2": "OFF",
+}
+
+// GS316 uses `On=4`, `Off=1`
+var flowControlCodesGs316 = map[string]string{
+	"4": "1",
+	"1": "2",
+}
+
+func isKnownCode(code string, texts map[string]string) bool {
+	_, ok := texts[code]
+	return ok
+}
@@ -0,0 +1,109 @@
+package netgear
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrLoginRequired is returned, when the switch responds with a login page instead of the requested content.
+// Typically, the session token has expired.
+var ErrLoginRequired = errors.New("no content. please, (re-)login first")
+
+// ErrNoSession is returned, when a request requires a session, but the client has neither logged in
+// nor was configured with an existing session
+var ErrNoSession = errors.New("no session (token) exists. please login first")
+
+// ErrNoPassword is returned, when trying to log in with an empty password
+var ErrNoPassword = errors.New("no password given")
+
+// UnsupportedModelError is returned, when the switch model is unknown or not supported.
+// The model is empty, when it can't be detected from the switch's web page.
+type UnsupportedModelError struct {
+	Model NetgearModel
+}
+
+func (e *UnsupportedModelError) Error() string {
+	if e.Model == "" {
+		return "can't detect the Netgear model from the switch's web page, the model might not be supported, please contact the developers"
+	}
+	return fmt.Sprintf("model '%s' not supported, please contact the developers", e.Model)
+}
+
+func (e *UnsupportedModelError) Is(target error) bool {
+	return target == ErrNotSupported
+}
+
+// LoginFailedError is returned, when the switch did not hand out a session token
+type LoginFailedError struct {
+	StatusCode int
+	Reason     string
+}
+
+func (e *LoginFailedError) Error() string {
+	return e.Reason
+}
+
+// PortOutOfRangeError is returned, when a given port number does not exist on the switch
+type PortOutOfRangeError struct {
+	Port    int
+	MaxPort int
+}
+
+func (e *PortOutOfRangeError) Error() string {
+	return fmt.Sprintf("given port id %d, doesn't fit in range 1..%d", e.Port, e.MaxPort)
+}
+
+// InvalidSettingError is returned, when a requested setting value is not accepted
+type InvalidSettingError struct {
+	Setting string
+	Value   string
+	Reason  string
+}
+
+func (e *InvalidSettingError) Error() string {
+	return e.Reason
+}
+
+// ChangeRejectedError is returned, when the switch did not acknowledge a configuration change
+type ChangeRejectedError struct {
+	Response string
+}
+
+func (e *ChangeRejectedError) Error() string {
+	return e.Response
+}
+
+// ErrNotSupported matches all errors about unsupported models or operations, see errors.Is
+var ErrNotSupported = errors.New("not supported")
+
+// NotSupportedError is returned, when an operation is not (yet) supported for the switch model
+type NotSupportedError struct {
+	Model     NetgearModel
+	Operation string
+}
+
+func (e *NotSupportedError) Error() string {
+	return fmt.Sprintf("operation '%s' is not yet supported for your Netgear model '%s'. "+
+		"You might want to support the project by creating an issue on Github", e.Operation, e.Model)
+}
+
+func (e *NotSupportedError) Is(target error) bool {
+	return target == ErrNotSupported
+}
+
+// PowerUpTimeoutError is returned, when ports don't deliver power again within the timeout after a power cycle
+type PowerUpTimeoutError struct {
+	Ports   []int
+	Timeout time.Duration
+}
+
+func (e *PowerUpTimeoutError) Error() string {
+	var ports []string
+	for _, port := range e.Ports {
+		ports = append(ports, strconv.Itoa(port))
+	}
+	return fmt.Sprintf("port(s) %s didn't deliver power again within %s", strings.Join(ports, ", "), e.Timeout)
+}
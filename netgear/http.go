@@ -0,0 +1,84 @@
+package netgear
+
+import (
+	"io"
+	"net/http"
+	"strings"
+)
+
+// RequestPage fetches a page from the switch, using the client's session, and returns the response body as is
+func (c *Client) RequestPage(url string) (string, error) {
+	return c.requestPage(url)
+}
+
+// RequestPageUnauthenticated fetches a page from the switch, without using any session, and returns the response body as is
+func (c *Client) RequestPageUnauthenticated(url string) (string, error) {
+	return c.doUnauthenticatedHttpRequestAndReadResponse(http.MethodGet, url, "")
+}
+
+func (c *Client) requestPage(url string) (string, error) {
+	return c.doHttpRequestAndReadResponse(http.MethodGet, url, "")
+}
+
+// PostPage posts the form data to the switch, using the client's session, and returns the response body as is
+func (c *Client) PostPage(url string, requestBody string) (string, error) {
+	return c.doHttpRequestAndReadResponse(http.MethodPost, url, requestBody)
+}
+
+// changeResult checks the switch's response to a configuration change
+func changeResult(response string) error {
+	if response == "SUCCESS" {
+		return nil
+	}
+	if IsLoginRequired(response) {
+		return ErrLoginRequired
+	}
+	return &ChangeRejectedError{Response: response}
+}
+
+func (c *Client) doHttpRequestAndReadResponse(httpMethod string, requestUrl string, requestBody string) (string, error) {
+	driver, err := c.driver()
+	if err != nil {
+		return "", err
+	}
+
+	c.logf("send HTTP %s request to: %s", httpMethod, requestUrl)
+
+	req, err := http.NewRequest(httpMethod, requestUrl, strings.NewReader(requestBody))
+	if err != nil {
+		return "", err
+	}
+	driver.InjectSession(req, c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	c.logf("%s", resp.Status)
+	bytes, err := io.ReadAll(resp.Body)
+	return string(bytes), err
+}
+
+func (c *Client) doUnauthenticatedHttpRequestAndReadResponse(httpMethod string, requestUrl string, requestBody string) (string, error) {
+	c.logf("Fetching data from: %s", requestUrl)
+
+	req, err := http.NewRequest(httpMethod, requestUrl, strings.NewReader(requestBody))
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	c.logf("%s", resp.Status)
+	for name, values := range resp.Header {
+		for _, value := range values {
+			c.logf("Response header: '%s' -- '%s'", name, value)
+		}
+	}
+	bytes, err := io.ReadAll(resp.Body)
+	return string(bytes), err
+}
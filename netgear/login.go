@@ -0,0 +1,195 @@
+package netgear
+
+import (
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+)
+
+const FailedAttempt = "no SID cookie found in response header"
+
+// Login detects the switch model, authenticates with the admin console password
+// and keeps the session token for further requests
+func (c *Client) Login(password string) error {
+	if len(password) < 1 {
+		return ErrNoPassword
+	}
+
+	model, err := c.DetectModel()
+	if err != nil {
+		return err
+	}
+	driver, err := DriverFor(model)
+	if err != nil {
+		return err
+	}
+	c.model = model
+
+	seedValue, err := c.getSeedValueFromSwitch(driver)
+	if err != nil {
+		return err
+	}
+
+	encryptedPwd := encryptPassword(password, seedValue)
+
+	return c.doLogin(driver, encryptedPwd)
+}
+
+func (c *Client) doLogin(driver Driver, encryptedPwd string) error {
+	url, formData := driver.LoginRequest(c.address, encryptedPwd)
+	c.logf("login attempt: %s", url)
+
+	resp, err := c.httpClient.Post(url, "application/x-www-form-urlencoded", strings.NewReader(formData))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	c.logf("%s", resp.Status)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	token, err := driver.FindSessionToken(resp, string(body))
+	if err != nil {
+		return err
+	}
+
+	c.token = token
+	return nil
+}
+
+// Logout ends the session on the switch and forgets the session token
+func (c *Client) Logout() error {
+	driver, err := c.driver()
+	if err != nil {
+		return err
+	}
+	err = driver.Logout(c)
+	if err != nil {
+		return err
+	}
+	c.token = ""
+	return nil
+}
+
+// CheckSession requests a small page from the switch, to check if the session is still valid.
+// It returns ErrNoSession, when there's no session, or ErrLoginRequired, when the switch asks for a login.
+func (c *Client) CheckSession() error {
+	if c.token == "" {
+		return ErrNoSession
+	}
+	driver, err := c.driver()
+	if err != nil {
+		return err
+	}
+	url := driver.SessionProbeUrl(c.address)
+	if url == "" {
+		return &NotSupportedError{Model: c.model, Operation: "CheckSession"}
+	}
+	body, err := c.requestPage(url)
+	if err != nil {
+		return err
+	}
+	if IsLoginRequired(body) {
+		return ErrLoginRequired
+	}
+	return nil
+}
+
+// IsLoginRequired checks, if the switch responded with a login page instead of the requested content
+func IsLoginRequired(httpResponseBody string) bool {
+	return len(httpResponseBody) < 10 ||
+		strings.Contains(httpResponseBody, "/login.cgi") ||
+		strings.Contains(httpResponseBody, "/wmi/login") ||
+		strings.Contains(httpResponseBody, "/redirect.html")
+}
+
+func getSessionToken(resp *http.Response) string {
+	cookie := resp.Header.Get("Set-Cookie")
+	var sessionIdPrefixes = [...]string{
+		// can be extended, once GS316 will also use this pattern
+		"SID=", // GS305EPx, GS308EPx
+	}
+	for _, sessionIdPrefix := range sessionIdPrefixes {
+		if strings.HasPrefix(cookie, sessionIdPrefix) {
+			sidVal := cookie[len(sessionIdPrefix):]
+			split := strings.Split(sidVal, ";")
+			return split[0]
+		}
+	}
+	return FailedAttempt
+}
+
+func findGambitTokenInResponseHtml(reader io.Reader) (gambitToken string) {
+	gambitToken = FailedAttempt
+	doc, err := goquery.NewDocumentFromReader(reader)
+	if err == nil {
+		doc.Find("form").Each(func(i int, s *goquery.Selection) {
+			name, okName := s.Find("input[type=hidden]").Attr("name")
+			value, okValue := s.Find("input[type=hidden]").Attr("value")
+			if okName && name == "Gambit" && okValue {
+				gambitToken = value
+			}
+		})
+	}
+	return gambitToken
+}
+
+func (c *Client) getSeedValueFromSwitch(driver Driver) (string, error) {
+	url := driver.LoginPageUrl(c.address)
+	c.logf("fetch seed value from: %s", url)
+	resp, err := c.httpClient.Get(url)
+	if err != nil {
+		return "", err
+	}
+	c.logf("%s", resp.Status)
+	defer resp.Body.Close()
+
+	seedValue, err := findSeedValueInLoginHtml(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return seedValue, nil
+}
+
+func findSeedValueInLoginHtml(reader io.Reader) (string, error) {
+	doc, err := goquery.NewDocumentFromReader(reader)
+	if err != nil {
+		return "", err
+	}
+	randVal, exists := doc.Find("#rand").First().Attr("value")
+
+	if exists {
+		return randVal, nil
+	}
+	return "", errors.New("random seed value not found in login.cgi response. " +
+		"An element with id=rand and an attribute 'value' is expected")
+}
+
+// encryptPassword re-implements some logic from Netgear's GS305EP frontend component, see login.js
+func encryptPassword(password string, seedValue string) string {
+	mergedStr := specialMerge(password, seedValue)
+	hash := md5.New()
+	_, _ = io.WriteString(hash, mergedStr)
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+func specialMerge(password string, seedValue string) string {
+	result := strings.Builder{}
+	maxLen := int(math.Max(float64(len(password)), float64(len(seedValue))))
+	for i := 0; i < maxLen; i++ {
+		if i < len(password) {
+			result.WriteString(string([]rune(password)[i]))
+		}
+		if i < len(seedValue) {
+			result.WriteString(string([]rune(seedValue)[i]))
+		}
+	}
+	return result.String()
+}
@@ -1,4 +1,4 @@
-package main
+package netgear
 
 import (
 	"github.com/corbym/gocrest/is"
@@ -56,7 +56,7 @@ func TestFindGambitTokenInResponseHtml(t *testing.T) {
 }
 
 func loadTestFile(model string, fileName string) string {
-	fullFileName := filepath.Join("test-data", model, fileName)
+	fullFileName := filepath.Join("..", "test-data", model, fileName)
 	bytes, err := os.ReadFile(fullFileName)
 	if err != nil {
 		panic(err)
@@ -1,10 +1,8 @@
-package main
+package netgear
 
 import (
-	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"strings"
 )
 
@@ -20,32 +18,23 @@ const (
 	GS316EPP NetgearModel = "GS316EPP"
 )
 
-func isModel30x(nm NetgearModel) bool {
-	return nm == GS305EP || nm == GS305EPP || nm == GS308EP || nm == GS308EPP || nm == GS30xEPx
+// IsSupportedModel checks, if there's a driver registered for the model
+func IsSupportedModel(modelName string) bool {
+	_, err := DriverFor(NetgearModel(modelName))
+	return err == nil
 }
 
-func isModel316(nm NetgearModel) bool {
-	return nm == GS316EP || nm == GS316EPP
-}
-
-func isSupportedModel(modelName string) bool {
-	return isModel30x(NetgearModel(modelName)) || isModel316(NetgearModel(modelName))
-}
-
-func detectNetgearModel(args *GlobalOptions, host string) (NetgearModel, error) {
-	url := fmt.Sprintf("http://%s/", host)
-	if args.Verbose {
-		fmt.Println("detecting Netgear switch model: " + url)
-	}
-	resp, err := http.Get(url)
+// DetectModel fetches the switch's start page and detects the model from it
+func (c *Client) DetectModel() (NetgearModel, error) {
+	url := fmt.Sprintf("http://%s/", c.address)
+	c.logf("detecting Netgear switch model: %s", url)
+	resp, err := c.httpClient.Get(url)
 	if err != nil {
 		return "", err
 	}
-	if args.Verbose {
-		fmt.Println(fmt.Sprintf("HTTP response code %d", resp.StatusCode))
-	}
+	c.logf("HTTP response code %d", resp.StatusCode)
 	if resp.StatusCode != 200 {
-		fmt.Println(fmt.Sprintf("Warning: response code was not 200; unusual, but will attempt detection anyway"))
+		c.logf("Warning: response code was not 200; unusual, but will attempt detection anyway")
 	}
 	responseBody, err := io.ReadAll(resp.Body)
 	_ = resp.Body.Close()
@@ -54,11 +43,9 @@ func detectNetgearModel(args *GlobalOptions, host string) (NetgearModel, error)
 	}
 	model := detectNetgearModelFromResponse(string(responseBody))
 	if model == "" {
-		return "", errors.New("Can't auto-detect Netgear model from response. You may try using --model parameter ")
-	}
-	if args.Verbose {
-		fmt.Println(fmt.Sprintf("Detected model %s", model))
+		return "", &UnsupportedModelError{}
 	}
+	c.logf("Detected model %s", model)
 	return model, nil
 }
 
@@ -1,4 +1,4 @@
-package main
+package netgear
 
 import (
 	"github.com/corbym/gocrest/is"
@@ -38,7 +38,7 @@ func TestDetectNetgearModelFromResponse(t *testing.T) {
 }
 
 func TestIsSupportedModel(t *testing.T) {
-	then.AssertThat(t, isSupportedModel("xxx"), is.False())
+	then.AssertThat(t, IsSupportedModel("xxx"), is.False())
 
-	then.AssertThat(t, isSupportedModel("GS305EP"), is.True())
+	then.AssertThat(t, IsSupportedModel("GS305EP"), is.True())
 }
@@ -0,0 +1,128 @@
+package netgear
+
+import (
+	"strconv"
+	"strings"
+)
+
+// poePowerBudgets are the total PoE power budgets in watt from the models' data sheets,
+// because the switches' web pages don't show them
+var poePowerBudgets = map[NetgearModel]float32{
+	GS305EP:  63,
+	GS305EPP: 120,
+	GS308EP:  62,
+	GS308EPP: 123,
+	GS316EP:  180,
+	GS316EPP: 231,
+}
+
+// BudgetFromDataSheet is the PoeBudget's source of the budget, which is taken from the model's data sheet
+const BudgetFromDataSheet = "data sheet"
+
+// PoePowerBudget returns the model's total PoE power budget in watt, from its data sheet.
+// The budget is unknown for GS30xEPx, the family of models, which can't be told apart by detection.
+func PoePowerBudget(model NetgearModel) (float32, bool) {
+	budget, ok := poePowerBudgets[model]
+	return budget, ok
+}
+
+// PoePortBudget is the power, a port draws, and the power, which it may draw at most with its settings
+type PoePortBudget struct {
+	PortIndex     int8
+	PortName      string
+	PortPwr       bool
+	PoePowerClass string
+	PwrMode       string
+	LimitType     string
+	PwrLimit      string
+	PowerInWatt   float32
+	// AllocationInWatt is the worst case: nothing for a disabled port, the user-defined limit,
+	// the maximum of the detected power class, or else the maximum of the power mode
+	AllocationInWatt float32
+}
+
+// PoeBudget is the use of the switch's PoE power budget, in total and per port
+type PoeBudget struct {
+	Model NetgearModel
+	// BudgetInWatt is zero, when the model's budget is unknown, see PoePowerBudget;
+	// it's never reported by the switch, but the BudgetSource tells, where it's from
+	BudgetInWatt      float32
+	BudgetSource      string
+	ConsumptionInWatt float32
+	AllocationInWatt  float32
+	Ports             []PoePortBudget
+}
+
+// PoeBudget fetches the PoE status and settings of all ports, and sums up the power consumption and
+// the worst case allocation
+func (c *Client) PoeBudget() (PoeBudget, error) {
+	statuses, err := c.PoeStatus()
+	if err != nil {
+		return PoeBudget{}, err
+	}
+	settings, err := c.PoeSettings()
+	if err != nil {
+		return PoeBudget{}, err
+	}
+	return NewPoeBudget(c.model, statuses, settings), nil
+}
+
+// NewPoeBudget combines the PoE status and settings of the ports into the model's PoE budget,
+// with the total budget from the model's data sheet
+func NewPoeBudget(model NetgearModel, statuses []PoePortStatus, settings []PoePortSetting) PoeBudget {
+	budget := PoeBudget{Model: model}
+	if watts, ok := PoePowerBudget(model); ok {
+		budget.BudgetInWatt, budget.BudgetSource = watts, BudgetFromDataSheet
+	}
+	for _, setting := range settings {
+		port := PoePortBudget{
+			PortIndex: setting.PortIndex,
+			PortName:  setting.PortName,
+			PortPwr:   setting.PortPwr,
+			PwrMode:   setting.PwrMode,
+			LimitType: setting.LimitType,
+			PwrLimit:  setting.PwrLimit,
+		}
+		for _, status := range statuses {
+			if status.PortIndex == setting.PortIndex {
+				port.PoePowerClass = status.PoePowerClass
+				port.PowerInWatt = status.PowerInWatt
+			}
+		}
+		port.AllocationInWatt = worstCaseAllocation(port)
+		budget.ConsumptionInWatt += port.PowerInWatt
+		budget.AllocationInWatt += port.AllocationInWatt
+		budget.Ports = append(budget.Ports, port)
+	}
+	return budget
+}
+
+// poeClassMaxPower is the maximum power per IEEE 802.3af/at power class, at the switch's port
+var poeClassMaxPower = map[string]float32{
+	"0": 15.4,
+	"1": 4.0,
+	"2": 7.0,
+	"3": 15.4,
+	"4": 30.0,
+}
+
+func worstCaseAllocation(port PoePortBudget) float32 {
+	if !port.PortPwr {
+		return 0
+	}
+	switch strings.ToLower(port.LimitType) {
+	case "user":
+		limit, err := strconv.ParseFloat(strings.TrimSpace(port.PwrLimit), 32)
+		if err == nil {
+			return float32(limit)
+		}
+	case "class":
+		if limit, ok := poeClassMaxPower[port.PoePowerClass]; ok {
+			return limit
+		}
+	}
+	if strings.EqualFold(port.PwrMode, "802.3af") {
+		return poeClassMaxPower["0"]
+	}
+	return poeClassMaxPower["4"]
+}
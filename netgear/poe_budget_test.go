@@ -0,0 +1,41 @@
+package netgear
+
+import (
+	"testing"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+func TestNewPoeBudget(t *testing.T) {
+	statuses := []PoePortStatus{
+		{PortIndex: 1, PoePowerClass: "4", PowerInWatt: 5.5},
+		{PortIndex: 2, PoePowerClass: "2", PowerInWatt: 3.25},
+		{PortIndex: 3},
+		{PortIndex: 4},
+	}
+	settings := []PoePortSetting{
+		{PortIndex: 1, PortPwr: true, PwrMode: "802.3at", LimitType: "user", PwrLimit: "25.5"},
+		{PortIndex: 2, PortPwr: true, PwrMode: "802.3at", LimitType: "Class", PwrLimit: "30.0"},
+		{PortIndex: 3, PortPwr: true, PwrMode: "802.3af", LimitType: "none", PwrLimit: "30.0"},
+		{PortIndex: 4, PortPwr: false, PwrMode: "802.3at", LimitType: "none", PwrLimit: "30.0"},
+	}
+
+	budget := NewPoeBudget(GS305EP, statuses, settings)
+
+	then.AssertThat(t, budget.BudgetInWatt, is.EqualTo(float32(63)))
+	then.AssertThat(t, budget.ConsumptionInWatt, is.EqualTo(float32(8.75)))
+	then.AssertThat(t, budget.AllocationInWatt, is.EqualTo(float32(25.5+7.0+15.4)))
+	then.AssertThat(t, budget.Ports, has.Length[PoePortBudget](4))
+	then.AssertThat(t, budget.Ports[0].AllocationInWatt, is.EqualTo(float32(25.5)))
+	then.AssertThat(t, budget.Ports[1].AllocationInWatt, is.EqualTo(float32(7.0)))
+	then.AssertThat(t, budget.Ports[2].AllocationInWatt, is.EqualTo(float32(15.4)))
+	then.AssertThat(t, budget.Ports[3].AllocationInWatt, is.EqualTo(float32(0)))
+}
+
+func TestPoePowerBudget_is_unknown_for_the_GS30x_family(t *testing.T) {
+	_, ok := PoePowerBudget(GS30xEPx)
+
+	then.AssertThat(t, ok, is.False())
+}
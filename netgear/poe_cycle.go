@@ -0,0 +1,208 @@
+package netgear
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PoeDeliveringPower is the PoE status of a port, which powers a device
+const PoeDeliveringPower = "Delivering Power"
+
+const defaultPoePollInterval = 2 * time.Second
+
+// PoeCycleOptions control how CyclePoeWithOptions power cycles the ports
+type PoeCycleOptions struct {
+	// OffTime keeps the power off for this duration, by disabling and enabling the ports,
+	// instead of the switch's own, short, power cycle
+	OffTime time.Duration
+	// WaitTimeout, when positive, waits at most this long for the ports, which delivered power before,
+	// to deliver power again
+	WaitTimeout time.Duration
+	// PollInterval is the time between the PoE status requests while waiting; default is 2 seconds
+	PollInterval time.Duration
+}
+
+// CyclePoe power cycles all the given PoE ports (starting with 1)
+// and returns the PoE status of these ports right after the power cycle was triggered
+func (c *Client) CyclePoe(ports []int) ([]PoePortStatus, error) {
+	return c.CyclePoeWithOptions(ports, PoeCycleOptions{})
+}
+
+// CyclePoeWithOptions power cycles all the given PoE ports (starting with 1), optionally keeps the power off
+// and waits for the devices to be powered again, see PoeCycleOptions. It returns the PoE status of these ports;
+// when a port doesn't deliver power again in time, the statuses are returned with a PowerUpTimeoutError.
+func (c *Client) CyclePoeWithOptions(ports []int, options PoeCycleOptions) ([]PoePortStatus, error) {
+	err := c.checkPorts(ports, true)
+	if err != nil {
+		return nil, err
+	}
+	var powered []int
+	if options.WaitTimeout > 0 {
+		statuses, err := c.PoeStatus()
+		if err != nil {
+			return nil, err
+		}
+		powered = deliveringPower(statuses, ports)
+	}
+
+	if options.OffTime > 0 {
+		err = c.switchPoeOff(ports, options.OffTime)
+	} else {
+		err = c.retryOnLoginRequired(func() error {
+			driver, err := c.driver()
+			if err != nil {
+				return err
+			}
+			return driver.CyclePoe(c, ports)
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if options.WaitTimeout > 0 {
+		return c.waitForPower(ports, powered, options)
+	}
+	statuses, err := c.PoeStatus()
+	if err != nil {
+		return nil, err
+	}
+	return filterPorts(statuses, ports), nil
+}
+
+// switchPoeOff disables the ports, and enables them again after the off-time
+func (c *Client) switchPoeOff(ports []int, offTime time.Duration) error {
+	_, err := c.SetPoe(ports, PoePortSettingsUpdate{PortPwr: "disable"})
+	if err != nil {
+		return err
+	}
+	c.logf("PoE of ports %v is off, enabling it again in %s", ports, offTime)
+	time.Sleep(offTime)
+	_, err = c.SetPoe(ports, PoePortSettingsUpdate{PortPwr: "enable"})
+	return err
+}
+
+// waitForPower polls the PoE status until all the powered ports deliver power again, or the timeout is reached.
+// The first poll is after the poll interval, because the switch may still report the status before the power cycle.
+func (c *Client) waitForPower(ports []int, powered []int, options PoeCycleOptions) ([]PoePortStatus, error) {
+	interval := options.PollInterval
+	if interval <= 0 {
+		interval = defaultPoePollInterval
+	}
+	deadline := time.Now().Add(options.WaitTimeout)
+	for {
+		time.Sleep(min(interval, max(time.Until(deadline), 0)))
+		statuses, err := c.PoeStatus()
+		if err != nil {
+			return nil, err
+		}
+		statuses = filterPorts(statuses, ports)
+		delivering := deliveringPower(statuses, powered)
+		var missing []int
+		for _, port := range powered {
+			if !slices.Contains(delivering, port) {
+				missing = append(missing, port)
+			}
+		}
+		if len(missing) == 0 {
+			return statuses, nil
+		}
+		if !time.Now().Before(deadline) {
+			return statuses, &PowerUpTimeoutError{Ports: missing, Timeout: options.WaitTimeout}
+		}
+		c.logf("waiting for PoE of ports %v", missing)
+	}
+}
+
+// deliveringPower are those of the given ports, which deliver power
+func deliveringPower(statuses []PoePortStatus, ports []int) []int {
+	var powered []int
+	for _, status := range filterPorts(statuses, ports) {
+		if strings.EqualFold(status.PoePortStatus, PoeDeliveringPower) {
+			powered = append(powered, int(status.PortIndex))
+		}
+	}
+	return powered
+}
+
+func filterPorts(statuses []PoePortStatus, ports []int) []PoePortStatus {
+	return filter(statuses, func(status PoePortStatus) bool {
+		return slices.Contains(ports, int(status.PortIndex))
+	})
+}
+
+func (d *gs30xDriver) CyclePoe(c *Client, ports []int) error {
+	poeExt := &PoeExt{}
+
+	settings, err := d.requestPoeConfiguration(c, poeExt)
+	if err != nil {
+		return err
+	}
+
+	poeSettings := url.Values{
+		"hash":   {poeExt.Hash},
+		"ACTION": {"Reset"},
+	}
+
+	for _, switchPort := range ports {
+		if switchPort < 1 || switchPort > len(settings) {
+			return &PortOutOfRangeError{Port: switchPort, MaxPort: len(settings)}
+		}
+		poeSettings.Add(fmt.Sprintf("port%d", switchPort-1), "checked")
+	}
+
+	result, err := d.requestPoeSettingsUpdate(c, poeSettings.Encode())
+	if err != nil {
+		return err
+	}
+	err = changeResult(result)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (d *gs316Driver) CyclePoe(c *Client, ports []int) error {
+	for _, switchPort := range ports {
+		if switchPort < 1 || switchPort > gs316NoPoePorts {
+			return &PortOutOfRangeError{Port: switchPort, MaxPort: gs316NoPoePorts}
+		}
+	}
+
+	urlStr := fmt.Sprintf("http://%s/iss/specific/poePortConf.html", c.Address())
+	reqForm := url.Values{}
+	reqForm.Add("Gambit", c.Token())
+	reqForm.Add("TYPE", "resetPoe")
+	reqForm.Add("PoePort", createPortResetPayloadGs316EPx(ports))
+	result, err := c.PostPage(urlStr, reqForm.Encode())
+	if err != nil {
+		return err
+	}
+	c.logf("%s", result)
+	err = changeResult(result)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func createPortResetPayloadGs316EPx(poePorts []int) string {
+	result := strings.Builder{}
+	for i := 0; i < gs316NoPoePorts; i++ {
+		written := false
+		for _, p := range poePorts {
+			if p-1 == i {
+				result.WriteString("1")
+				written = true
+				break
+			}
+		}
+		if !written {
+			result.WriteString("0")
+		}
+	}
+	return result.String()
+}
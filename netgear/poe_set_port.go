@@ -0,0 +1,413 @@
+package netgear
+
+import (
+	"errors"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"io"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type PoeSettingKey string
+
+const (
+	PortPrio     PoeSettingKey = "PortPrio"
+	PwrMode      PoeSettingKey = "PwrMode"
+	LimitType    PoeSettingKey = "LimitType"
+	PwrLimit     PoeSettingKey = "PwrLimit"
+	DetecType    PoeSettingKey = "DetecType"
+	LongerDetect PoeSettingKey = "LongerDetect"
+)
+
+// PoePortSettingsUpdate holds the PoE settings to change.
+// Empty values keep the current setting of a port.
+type PoePortSettingsUpdate struct {
+	PortPwr      string // power state [enable, disable]
+	PwrMode      string // power mode [802.3af, legacy, pre-802.3at, 802.3at]
+	PortPrio     string // priority [low, high, critical]
+	LimitType    string // power limit type [none, class, user]
+	PwrLimit     string // power limit (W) [e.g. '30.0']
+	DetecType    string // detection type [IEEE 802, legacy, 4pt 802.3af + Legacy]
+	LongerDetect string // longer detection time [enable, disable]
+}
+
+type PoeExt struct {
+	Hash string
+}
+
+// SetPoe changes the PoE settings for all the given ports (starting with 1)
+// and returns the updated settings of these ports
+func (c *Client) SetPoe(ports []int, update PoePortSettingsUpdate) ([]PoePortSetting, error) {
+	err := c.checkPorts(ports, true)
+	if err != nil {
+		return nil, err
+	}
+	if update.PwrLimit != "" {
+		err = c.checkPwrLimit(ports, update.PwrLimit)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = c.retryOnLoginRequired(func() error {
+		driver, err := c.driver()
+		if err != nil {
+			return err
+		}
+		return driver.SetPoe(c, ports, update)
+	})
+	if err != nil {
+		return nil, err
+	}
+	settings, err := c.PoeSettings()
+	if err != nil {
+		return nil, err
+	}
+	return collectChangedPoePortConfiguration(ports, settings), nil
+}
+
+func (d *gs30xDriver) SetPoe(c *Client, ports []int, update PoePortSettingsUpdate) error {
+	poeExt := &PoeExt{}
+	var adminMode string
+
+	currentPoeConfigs, err := d.requestPoeConfiguration(c, poeExt)
+	if err != nil {
+		return err
+	}
+
+	for _, portId := range ports {
+		if portId > len(currentPoeConfigs) || portId < 1 {
+			return &PortOutOfRangeError{Port: portId, MaxPort: len(currentPoeConfigs)}
+		}
+
+		poeConfig := currentPoeConfigs[portId-1]
+
+		if update.PortPwr == "enabled" || update.PortPwr == "enable" {
+			adminMode = "1"
+		} else if update.PortPwr == "disabled" || update.PortPwr == "disable" {
+			adminMode = "0"
+		} else {
+			if poeConfig.PortPwr {
+				adminMode = "1"
+			} else {
+				adminMode = "0"
+			}
+		}
+
+		portPrio, err := comparePoeSettings(PortPrio, poeConfig.PortPrio, update.PortPrio)
+		if err != nil {
+			return err
+		}
+
+		pwrMode, err := comparePoeSettings(PwrMode, poeConfig.PwrMode, update.PwrMode)
+		if err != nil {
+			return err
+		}
+
+		pwrLimitType, err := comparePoeSettings(LimitType, poeConfig.LimitType, update.LimitType)
+		if err != nil {
+			return err
+		}
+
+		pwrLimit := poeConfig.PwrLimit
+		if update.PwrLimit != "" {
+			limit, err := checkPwrLimit(poeConfig, update.PwrLimit)
+			if err != nil {
+				return err
+			}
+			pwrLimit = strconv.FormatFloat(limit, 'f', 1, 64)
+		}
+
+		detecType, err := comparePoeSettings(DetecType, poeConfig.DetecType, update.DetecType)
+		if err != nil {
+			return err
+		}
+
+		longerDetect, err := comparePoeSettings(LongerDetect, poeConfig.LongerDetect, update.LongerDetect)
+		if err != nil {
+			return err
+		}
+
+		poeSettings := url.Values{
+			"hash":           {poeExt.Hash},
+			"ACTION":         {"Apply"},
+			"portID":         {strconv.Itoa(int(portId - 1))},
+			"ADMIN_MODE":     {adminMode},
+			"PORT_PRIO":      {portPrio},
+			"POW_MOD":        {pwrMode},
+			"POW_LIMT_TYP":   {pwrLimitType},
+			"POW_LIMT":       {pwrLimit},
+			"DETEC_TYP":      {detecType},
+			"DISCONNECT_TYP": {longerDetect},
+		}
+
+		result, err := d.requestPoeSettingsUpdate(c, poeSettings.Encode())
+		if err != nil {
+			return err
+		}
+
+		err = changeResult(result)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *gs316Driver) SetPoe(c *Client, ports []int, update PoePortSettingsUpdate) error {
+	for _, portId := range ports {
+		if portId < 1 || portId > gs316NoPoePorts {
+			return &PortOutOfRangeError{Port: portId, MaxPort: gs316NoPoePorts}
+		}
+
+		newPoeConfig, err := createPoeSetConfigPayloadGs316(update, c.Token(), portId)
+		if err != nil {
+			return err
+		}
+
+		urlStr := fmt.Sprintf("http://%s/iss/specific/poePortConf.html", c.Address())
+		result, err := c.PostPage(urlStr, newPoeConfig)
+		if err != nil {
+			return err
+		}
+
+		err = changeResult(result)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func createPoeSetConfigPayloadGs316(update PoePortSettingsUpdate, token string, portId int) (string, error) {
+	// it seems the ORDER IS IMPORTANT, so we craft the payload by hand.
+	newPoeConfig := fmt.Sprintf("Gambit=%s&TYPE=%s&PORT_NO=%s", token, "submitPoe", strconv.Itoa(portId))
+
+	if update.PwrLimit != "" {
+		update.LimitType = "user" // must be set, else nothing happens
+		pwrLimit, err := strconv.ParseFloat(update.PwrLimit, 64)
+		if err != nil {
+			return "", &InvalidSettingError{Setting: string(PwrLimit), Value: update.PwrLimit,
+				Reason: fmt.Sprintf("invalid power limit value: '%s', allowed are: 3.0, 3.2, 3.4, 3.6, and so on", update.PwrLimit)}
+		}
+		// deci watts, rounded, because e.g. 15.3 * 10 is 152.99999999999997
+		newPoeConfig += fmt.Sprintf("&POWER_LIMIT_VALUE=%s", strconv.Itoa(int(math.Round(pwrLimit*10))))
+	} else {
+		newPoeConfig += fmt.Sprintf("&POWER_LIMIT_VALUE=%s", "NOTSET")
+	}
+
+	if update.PortPrio != "" {
+		portPrio, err := mapPoePrioGs316(update.PortPrio)
+		if err != nil {
+			return "", err
+		}
+		newPoeConfig += fmt.Sprintf("&PRIORITY=%s", portPrio)
+	} else {
+		newPoeConfig += fmt.Sprintf("&PRIORITY=%s", "NOTSET")
+	}
+
+	if update.PwrMode != "" {
+		pwerMode := bidiMapLookup(strings.ToLower(update.PwrMode), pwrModeMap)
+		if pwerMode == unknown {
+			return "", &InvalidSettingError{Setting: string(PwrMode), Value: update.PwrMode,
+				Reason: fmt.Sprintf("power mode %s not supported; allowed values: %s", update.PwrMode, valuesAsString(pwrModeMap))}
+		}
+		newPoeConfig += fmt.Sprintf("&POWER_MODE=%s", pwerMode)
+	} else {
+		newPoeConfig += fmt.Sprintf("&POWER_MODE=%s", "NOTSET")
+	}
+
+	if update.LimitType != "" {
+		limitType := bidiMapLookup(update.LimitType, limitTypeMap)
+		if limitType == unknown {
+			return "", &InvalidSettingError{Setting: string(LimitType), Value: update.LimitType,
+				Reason: fmt.Sprintf("limit type %s not supported; allowed values: %s", update.LimitType, valuesAsString(limitTypeMap))}
+		}
+		newPoeConfig += fmt.Sprintf("&POWER_LIMIT_TYPE=%s", limitType)
+	} else {
+		newPoeConfig += fmt.Sprintf("&POWER_LIMIT_TYPE=%s", "NOTSET")
+	}
+
+	if update.DetecType != "" {
+		if update.DetecType == "IEEE802" {
+			// the GS316EP series does not use a space,
+			// hence we created make it compatible to GS30x, by adding a space
+			update.DetecType = "IEEE 802"
+		}
+		detecType := bidiMapLookup(update.DetecType, detecTypeMap)
+		if detecType == unknown {
+			return "", &InvalidSettingError{Setting: string(DetecType), Value: update.DetecType,
+				Reason: fmt.Sprintf("detection type %s not supported; allowed values: %s", update.DetecType, valuesAsString(detecTypeMap))}
+		}
+		newPoeConfig += fmt.Sprintf("&DETECTION=%s", detecType)
+	} else {
+		newPoeConfig += fmt.Sprintf("&DETECTION=%s", "NOTSET")
+	}
+
+	if update.PortPwr != "" {
+		adminState := "0"
+		if strings.Contains(strings.ToLower(update.PortPwr), "enable") {
+			adminState = "1"
+		}
+		newPoeConfig += fmt.Sprintf("&ADMIN_STATE=%s", adminState)
+	} else {
+		newPoeConfig += fmt.Sprintf("&ADMIN_STATE=%s", "NOTSET")
+	}
+
+	if update.LongerDetect != "" {
+		disconnectType := bidiMapLookup(update.LongerDetect, longerDetectMap)
+		if disconnectType == unknown {
+			return "", &InvalidSettingError{Setting: string(LongerDetect), Value: update.LongerDetect,
+				Reason: fmt.Sprintf("detection type %s not supported; allowed values: %s", update.LongerDetect, valuesAsString(longerDetectMap))}
+		}
+		newPoeConfig += fmt.Sprintf("&DISCONNECT_TYPE=%s", disconnectType)
+	} else {
+		newPoeConfig += fmt.Sprintf("&DISCONNECT_TYPE=%s", "NOTSET")
+	}
+	return newPoeConfig, nil
+}
+
+func collectChangedPoePortConfiguration(poePorts []int, settings []PoePortSetting) (changedPorts []PoePortSetting) {
+	for _, configuredPort := range poePorts {
+		for _, portSetting := range settings {
+			if int(portSetting.PortIndex) == configuredPort {
+				changedPorts = append(changedPorts, portSetting)
+			}
+		}
+	}
+
+	return changedPorts
+}
+
+// requestPoeConfiguration fetches the PoE settings with the numeric values, as required to change them,
+// plus the extra values needed to post changes
+func (d *gs30xDriver) requestPoeConfiguration(c *Client, poeExt *PoeExt) ([]PoePortSetting, error) {
+	settingsPage, err := c.requestContent("PoeSettings", d.PoeSettingsPageUrl(c.Address()))
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := findPortPortConfInHtmlGs30x(strings.NewReader(settingsPage))
+	if err != nil {
+		return settings, err
+	}
+
+	poeExt.Hash, err = findHashInHtml(strings.NewReader(settingsPage))
+	if err != nil {
+		return settings, err
+	}
+
+	return settings, nil
+}
+
+func (d *gs30xDriver) requestPoeSettingsUpdate(c *Client, data string) (string, error) {
+	url := fmt.Sprintf("http://%s/PoEPortConfig.cgi", c.Address())
+	return c.PostPage(url, data)
+}
+
+func findHashInHtml(reader io.Reader) (string, error) {
+	doc, err := goquery.NewDocumentFromReader(reader)
+	if err != nil {
+		return "", err
+	}
+
+	hash, exists := doc.Find("input#hash").Attr("value")
+	if !exists {
+		return "", errors.New("could not find hash")
+	}
+	return hash, err
+}
+
+func comparePoeSettings(name PoeSettingKey, defaultValue string, newValue string) (string, error) {
+	if len(newValue) == 0 {
+		return defaultValue, nil
+	}
+
+	switch name {
+	case PortPrio:
+		portPrio := bidiMapLookup(newValue, portPrioMap)
+		if portPrio == unknown {
+			return portPrio, &InvalidSettingError{Setting: string(name), Value: newValue, Reason: "port priority could not be set. Accepted values are: " + valuesAsString(portPrioMap)}
+		}
+		return portPrio, nil
+	case PwrMode:
+		pwrMode := bidiMapLookup(newValue, pwrModeMap)
+		if pwrMode == unknown {
+			return pwrMode, &InvalidSettingError{Setting: string(name), Value: newValue, Reason: "power mode could not be set. Accepted values are: " + valuesAsString(pwrModeMap)}
+		}
+		return pwrMode, nil
+	case LimitType:
+		limitType := bidiMapLookup(newValue, limitTypeMap)
+		if limitType == unknown {
+			return limitType, &InvalidSettingError{Setting: string(name), Value: newValue, Reason: "limit type could not be set. Accepted values are: " + valuesAsString(limitTypeMap)}
+		}
+		return limitType, nil
+	case DetecType:
+		detecType := bidiMapLookup(newValue, detecTypeMap)
+		if detecType == unknown {
+			return detecType, &InvalidSettingError{Setting: string(name), Value: newValue, Reason: "detection type could not be set. Accepted values are: " + valuesAsString(detecTypeMap)}
+		}
+		return detecType, nil
+	case LongerDetect:
+		longerDetect := bidiMapLookup(newValue, longerDetectMap)
+		if longerDetect == unknown {
+			return longerDetect, &InvalidSettingError{Setting: string(name), Value: newValue, Reason: "longer detection type value could not be set. Accepted values are: " + valuesAsString(longerDetectMap)}
+		}
+		return longerDetect, nil
+	default:
+		return defaultValue, errors.New("could not find port setting")
+	}
+
+}
+
+// checkPwrLimit checks the power limit (W) against the ranges of the ports, before any change is sent
+func (c *Client) checkPwrLimit(ports []int, pwrLimit string) error {
+	settings, err := c.PoeSettings()
+	if err != nil {
+		return err
+	}
+	for _, setting := range collectChangedPoePortConfiguration(ports, settings) {
+		_, err = checkPwrLimit(setting, pwrLimit)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkPwrLimit parses the power limit (W) and checks, that it's in the port's range and a multiple of the port's step size;
+// NaN and infinity are rejected explicitly, because they pass any comparison.
+// When the port's range is unknown, any positive limit with one decimal place is sent, and the switch checks the range.
+func checkPwrLimit(setting PoePortSetting, pwrLimit string) (float64, error) {
+	if setting.MaxPwrLimit <= 0 {
+		return checkPwrLimitWithUnknownRange(setting, pwrLimit)
+	}
+	minLimit, maxLimit, step := float64(setting.MinPwrLimit), float64(setting.MaxPwrLimit), float64(setting.PwrLimitStep)
+	limit, err := strconv.ParseFloat(strings.TrimSpace(pwrLimit), 64)
+	// the tolerances cover the float32 values of the range, e.g. 0.2 is 0.20000000298
+	steps := (limit - minLimit) / step
+	if err != nil || math.IsNaN(limit) || math.IsInf(limit, 0) || limit < minLimit-0.0001 || limit > maxLimit+0.0001 || math.Abs(steps-math.Round(steps)) > 0.001 {
+		return 0, &InvalidSettingError{Setting: string(PwrLimit), Value: pwrLimit,
+			Reason: fmt.Sprintf("invalid power limit '%s' for port %d, allowed are %s to %s watts in steps of %s watts",
+				pwrLimit, setting.PortIndex, formatWatts(setting.MinPwrLimit), formatWatts(setting.MaxPwrLimit), formatWatts(setting.PwrLimitStep))}
+	}
+	return limit, nil
+}
+
+func checkPwrLimitWithUnknownRange(setting PoePortSetting, pwrLimit string) (float64, error) {
+	limit, err := strconv.ParseFloat(strings.TrimSpace(pwrLimit), 64)
+	tenths := limit * 10
+	if err != nil || math.IsNaN(limit) || math.IsInf(limit, 0) || limit <= 0 || math.Abs(tenths-math.Round(tenths)) > 0.001 {
+		return 0, &InvalidSettingError{Setting: string(PwrLimit), Value: pwrLimit,
+			Reason: fmt.Sprintf("invalid power limit '%s' for port %d, allowed are positive watts with one decimal place", pwrLimit, setting.PortIndex)}
+	}
+	return limit, nil
+}
+
+func formatWatts(watts float32) string {
+	return strconv.FormatFloat(float64(watts), 'f', -1, 32)
+}
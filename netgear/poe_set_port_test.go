@@ -1,17 +1,13 @@
-package main
+package netgear
 
 import (
+	"fmt"
 	"github.com/corbym/gocrest/is"
 	"github.com/corbym/gocrest/then"
 	"strings"
 	"testing"
 )
 
-var poeExt = &PoeExt{
-	Hash:         "4f11f5d64ef3fd75a92a9f2ad1de3060",
-	PortMaxPower: "30.0",
-}
-
 func TestFindHashInHtml(t *testing.T) {
 	tests := []struct {
 		model       string
@@ -31,7 +27,7 @@ func TestFindHashInHtml(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.model, func(t *testing.T) {
-			hash, err := findHashInHtml(NetgearModel(test.model), strings.NewReader(loadTestFile(test.model, test.fileName)))
+			hash, err := findHashInHtml(strings.NewReader(loadTestFile(test.model, test.fileName)))
 
 			then.AssertThat(t, err, is.Nil())
 			then.AssertThat(t, hash, is.EqualTo(test.expectedVal))
@@ -39,171 +35,149 @@ func TestFindHashInHtml(t *testing.T) {
 	}
 }
 
-func TestFindMaxPoePowerLimit(t *testing.T) {
-	tests := []struct {
-		model       string
-		fileName    string
-		expectedVal string
-	}{
-		{
-			model:       "GS305EP",
-			fileName:    "PoEPortConfig.cgi.html",
-			expectedVal: "30.0",
-		},
-		{
-			model:       "GS308EPP",
-			fileName:    "PoEPortConfig.cgi.html",
-			expectedVal: "30.0",
-		},
-	}
-	for _, test := range tests {
-		t.Run(test.model, func(t *testing.T) {
-			pwrLimit, err := findMaxPwrLimitInHtml(NetgearModel(test.model), strings.NewReader(loadTestFile(test.model, test.fileName)))
-
-			then.AssertThat(t, err, is.Nil())
-			then.AssertThat(t, pwrLimit, is.EqualTo(test.expectedVal))
-		})
-	}
-}
-
 func TestComparePoeSettingsUnknown(t *testing.T) {
 
 	for _, setting := range []PoeSettingKey{PortPrio, PwrMode, LimitType, DetecType, LongerDetect} {
-		setting, _ := comparePoeSettings(setting, "defaultValue", "newValue", poeExt)
+		setting, _ := comparePoeSettings(setting, "defaultValue", "newValue")
 		then.AssertThat(t, setting, is.EqualTo(unknown).Reason("when providing a value that does not exist, return unknown to the caller"))
 	}
 }
 
-func TestComparePoeSettingsPwrLimit(t *testing.T) {
+func TestCheckPwrLimit(t *testing.T) {
+	gs316Port := PoePortSetting{PortIndex: 3, MinPwrLimit: 3.0, MaxPwrLimit: 30.0, PwrLimitStep: 0.2}
+	// the GS30x pages don't state the range
+	gs30xPort := PoePortSetting{PortIndex: 1}
 
-	pwrLimit, err := comparePoeSettings(PwrLimit, "3.0", "30.0", poeExt)
-	then.AssertThat(t, err, is.Nil())
-	then.AssertThat(t, pwrLimit, is.EqualTo("30.0").Reason("allow values up to the maximum power in PortMaxPower"))
-
-	pwrLimitDefault, err := comparePoeSettings(PwrLimit, "15.0", "15.0", poeExt)
+	for _, pwrLimit := range []string{"3.0", "15", "17.4", "30.0"} {
+		_, err := checkPwrLimit(gs316Port, pwrLimit)
+		then.AssertThat(t, err, is.Nil().Reason(pwrLimit))
+	}
+	limit, err := checkPwrLimit(gs30xPort, "15.3")
 	then.AssertThat(t, err, is.Nil())
-	then.AssertThat(t, pwrLimitDefault, is.EqualTo("15.0").Reason("pass the default back if user did not change value"))
-
-	pwrLimitOutOfRange, _ := comparePoeSettings(PwrLimit, "30.0", "99999999.0", poeExt)
-	then.AssertThat(t, pwrLimitOutOfRange, is.EqualTo("30.0").Reason("use the default value if power limit is out of range"))
-
-	pwrLimitMidRange, _ := comparePoeSettings(PwrLimit, "30.0", "15", poeExt)
-	then.AssertThat(t, pwrLimitMidRange, is.EqualTo("15").Reason("integer values should work"))
+	then.AssertThat(t, limit, is.EqualTo(15.3))
 
+	for _, pwrLimit := range []string{"2.8", "30.2", "15.3", "99999999.0", "15,0", "", "NaN", "nan", "Inf", "+Inf", "-Inf"} {
+		_, err := checkPwrLimit(gs316Port, pwrLimit)
+		then.AssertThat(t, err.Error(), is.EqualTo(fmt.Sprintf("invalid power limit '%s' for port 3, allowed are 3 to 30 watts in steps of 0.2 watts", pwrLimit)))
+	}
+	for _, pwrLimit := range []string{"0", "-5", "15.35", "15,0", "", "NaN", "Inf"} {
+		_, err := checkPwrLimit(gs30xPort, pwrLimit)
+		then.AssertThat(t, err.Error(), is.EqualTo(fmt.Sprintf("invalid power limit '%s' for port 1, allowed are positive watts with one decimal place", pwrLimit)))
+	}
 }
 
 func TestComparePoePortPrio(t *testing.T) {
 
-	setting, err := comparePoeSettings(PortPrio, "critical", "low", poeExt)
+	setting, err := comparePoeSettings(PortPrio, "critical", "low")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("0").Reason("allow user to change port priority to low"))
 
-	setting, err = comparePoeSettings(PortPrio, "low", "critical", poeExt)
+	setting, err = comparePoeSettings(PortPrio, "low", "critical")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("3").Reason("allow user to change port priority to critical"))
 
-	setting, err = comparePoeSettings(PortPrio, "low", "high", poeExt)
+	setting, err = comparePoeSettings(PortPrio, "low", "high")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("2").Reason("allow user to change port priority to high"))
 
-	setting, err = comparePoeSettings(PortPrio, "low", "low", poeExt)
+	setting, err = comparePoeSettings(PortPrio, "low", "low")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("0").Reason("maintain the same port priority"))
 
-	setting, err = comparePoeSettings(PortPrio, "0", "", poeExt)
+	setting, err = comparePoeSettings(PortPrio, "0", "")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("0").Reason("maintain the prior value when new nothing is specified"))
 }
 
 func TestComparePoePwrMode(t *testing.T) {
-	setting, err := comparePoeSettings(PwrMode, "802.3af", "legacy", poeExt)
+	setting, err := comparePoeSettings(PwrMode, "802.3af", "legacy")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("1").Reason("allow user to change the power mode to legacy"))
 
-	setting, err = comparePoeSettings(PwrMode, "legacy", "pre-802.3at", poeExt)
+	setting, err = comparePoeSettings(PwrMode, "legacy", "pre-802.3at")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("2").Reason("allow user to change the power mode to pre-802.3at"))
 
-	setting, err = comparePoeSettings(PwrMode, "pre-802.3at", "802.3at", poeExt)
+	setting, err = comparePoeSettings(PwrMode, "pre-802.3at", "802.3at")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("3").Reason("allow user to change the power mode to 802.3at"))
 
-	setting, err = comparePoeSettings(PwrMode, "802.3af", "802.3af", poeExt)
+	setting, err = comparePoeSettings(PwrMode, "802.3af", "802.3af")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("0").Reason("maintain the same power mode"))
 
-	setting, err = comparePoeSettings(PwrMode, "0", "", poeExt)
+	setting, err = comparePoeSettings(PwrMode, "0", "")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("0").Reason("maintain the prior value when nothing new is specified"))
 }
 
 func TestComparePoeLimitType(t *testing.T) {
-	setting, err := comparePoeSettings(LimitType, "user", "none", poeExt)
+	setting, err := comparePoeSettings(LimitType, "user", "none")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("0").Reason("allow user to change the limit type to none"))
 
-	setting, err = comparePoeSettings(LimitType, "none", "class", poeExt)
+	setting, err = comparePoeSettings(LimitType, "none", "class")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("1").Reason("allow user to change the limit type to class"))
 
-	setting, err = comparePoeSettings(LimitType, "class", "user", poeExt)
+	setting, err = comparePoeSettings(LimitType, "class", "user")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("2").Reason("allow user to change the limit type to user"))
 
-	setting, err = comparePoeSettings(LimitType, "user", "user", poeExt)
+	setting, err = comparePoeSettings(LimitType, "user", "user")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("2").Reason("maintain the same limit type"))
 
-	setting, err = comparePoeSettings(LimitType, "2", "", poeExt)
+	setting, err = comparePoeSettings(LimitType, "2", "")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("2").Reason("maintain the prior value when nothing new is specified"))
 }
 
 func TestComparePoeDetecType(t *testing.T) {
-	setting, err := comparePoeSettings(DetecType, "IEEE 802", "Legacy", poeExt)
+	setting, err := comparePoeSettings(DetecType, "IEEE 802", "Legacy")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("1").Reason("allow user to change the detect type to Legacy"))
 
-	setting, err = comparePoeSettings(DetecType, "Legacy", "4pt 802.3af + Legacy", poeExt)
+	setting, err = comparePoeSettings(DetecType, "Legacy", "4pt 802.3af + Legacy")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("3").Reason("allow user to change the detect type to 4pt 802.3af + Legacy"))
 
-	setting, err = comparePoeSettings(DetecType, "4pt 802.3af + Legacy", "IEEE 802", poeExt)
+	setting, err = comparePoeSettings(DetecType, "4pt 802.3af + Legacy", "IEEE 802")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("2").Reason("allow user to change the detect type to IEEE 802"))
 
-	setting, err = comparePoeSettings(DetecType, "IEEE 802", "IEEE 802", poeExt)
+	setting, err = comparePoeSettings(DetecType, "IEEE 802", "IEEE 802")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("2").Reason("maintain the same detect type"))
 
-	setting, err = comparePoeSettings(DetecType, "1", "", poeExt)
+	setting, err = comparePoeSettings(DetecType, "1", "")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("1").Reason("maintain the prior value when nothing new is specified"))
 }
 
 func TestComparePoeLongerDetect(t *testing.T) {
 
-	setting, err := comparePoeSettings(LongerDetect, "Get Value Fault", "disable", poeExt)
+	setting, err := comparePoeSettings(LongerDetect, "Get Value Fault", "disable")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("2").Reason("allow user to change the longer detection time to Disable from Get Value Fault"))
 
-	setting, err = comparePoeSettings(LongerDetect, "Get Value Fault", "enable", poeExt)
+	setting, err = comparePoeSettings(LongerDetect, "Get Value Fault", "enable")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("3").Reason("allow user to change the longer detection time to Enable from Get Value Fault"))
 
-	setting, err = comparePoeSettings(LongerDetect, "enable", "disable", poeExt)
+	setting, err = comparePoeSettings(LongerDetect, "enable", "disable")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("2").Reason("allow user to change the longer detection time to Disable"))
 
-	setting, err = comparePoeSettings(LongerDetect, "disable", "enable", poeExt)
+	setting, err = comparePoeSettings(LongerDetect, "disable", "enable")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("3").Reason("allow user to change the longer detection time to Enable"))
 
-	setting, err = comparePoeSettings(LongerDetect, "enable", "", poeExt)
+	setting, err = comparePoeSettings(LongerDetect, "enable", "")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("enable").Reason("maintain the same longer detect type when nothing new is specified"))
 
-	setting, err = comparePoeSettings(LongerDetect, "2", "", poeExt)
+	setting, err = comparePoeSettings(LongerDetect, "2", "")
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, setting, is.EqualTo("2").Reason("maintain the same longer detect type when nothing new is specified"))
 }
@@ -227,9 +201,7 @@ func TestCollectChangedPoePortConfiguration(t *testing.T) {
 }
 
 func TestCreatePoeSetConfigPayloadGs316_all_fields(t *testing.T) {
-	poe := PoeSetConfigCommand{
-		Address:      "192.168.0.239",
-		Ports:        []int{1},
+	update := PoePortSettingsUpdate{
 		PortPwr:      "enable",
 		PwrMode:      "legacy",
 		PortPrio:     "high",
@@ -241,7 +213,7 @@ func TestCreatePoeSetConfigPayloadGs316_all_fields(t *testing.T) {
 	token := "xyz123"
 	portId := 1
 
-	payload, err := poe.createPoeSetConfigPayloadGs316(token, portId)
+	payload, err := createPoeSetConfigPayloadGs316(update, token, portId)
 
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, payload, is.StringContaining("Gambit=xyz"))
@@ -256,9 +228,7 @@ func TestCreatePoeSetConfigPayloadGs316_all_fields(t *testing.T) {
 }
 
 func TestCreatePoeSetConfigPayloadGs316_just_mandatory_no_optional_fields(t *testing.T) {
-	poe := PoeSetConfigCommand{
-		Address:      "192.168.0.239",
-		Ports:        []int{1},
+	update := PoePortSettingsUpdate{
 		PortPwr:      "",
 		PwrMode:      "",
 		PortPrio:     "",
@@ -270,7 +240,7 @@ func TestCreatePoeSetConfigPayloadGs316_just_mandatory_no_optional_fields(t *tes
 	token := "xyz123"
 	portId := 2
 
-	payload, err := poe.createPoeSetConfigPayloadGs316(token, portId)
+	payload, err := createPoeSetConfigPayloadGs316(update, token, portId)
 
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, payload, is.StringContaining("Gambit=xyz"))
@@ -0,0 +1,139 @@
+package netgear
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+type PoePortSetting struct {
+	PortIndex int8
+	PortName  string
+	PortPwr   bool
+	PwrMode   string
+	PortPrio  string
+	LimitType string
+	PwrLimit  string
+	// the range of the power limit (W), which the port accepts, in steps of PwrLimitStep;
+	// zero, when the page doesn't state it, like the pages of GS30x models
+	MinPwrLimit  float32
+	MaxPwrLimit  float32
+	PwrLimitStep float32
+	DetecType    string
+	LongerDetect string
+}
+
+// PoeSettings fetches the current PoE settings of all ports
+func (c *Client) PoeSettings() ([]PoePortSetting, error) {
+	return withReLogin(c, c.poeSettings)
+}
+
+func (c *Client) poeSettings() ([]PoePortSetting, error) {
+	driver, err := c.driver()
+	if err != nil {
+		return nil, err
+	}
+	confPage, err := c.requestContent("PoeSettings", driver.PoeSettingsPageUrl(c.address))
+	if err != nil {
+		return nil, err
+	}
+	return driver.ParsePoeSettings(strings.NewReader(confPage))
+}
+
+// toReadablePoePortSettingsGs30x translates the numeric values, used by GS30x models, into human-readable values.
+// GS316 models already report human-readable values.
+func toReadablePoePortSettingsGs30x(settings []PoePortSetting) []PoePortSetting {
+	var readable []PoePortSetting
+	for _, setting := range settings {
+		setting.PwrMode = bidiMapLookup(setting.PwrMode, pwrModeMap)
+		setting.PortPrio = bidiMapLookup(setting.PortPrio, portPrioMap)
+		setting.LimitType = bidiMapLookup(setting.LimitType, limitTypeMap)
+		setting.DetecType = bidiMapLookup(setting.DetecType, detecTypeMap)
+		setting.LongerDetect = bidiMapLookup(setting.LongerDetect, longerDetectMap)
+		readable = append(readable, setting)
+	}
+	return readable
+}
+
+func findPortPortConfInHtmlGs30x(reader io.Reader) ([]PoePortSetting, error) {
+	doc, err := goquery.NewDocumentFromReader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []PoePortSetting
+	doc.Find("li.poePortSettingListItem").Each(func(i int, s *goquery.Selection) {
+		config := PoePortSetting{}
+		id, _ := s.Find("input[type=hidden].port").Attr("value")
+		var id64, _ = strconv.ParseInt(id, 10, 8)
+		config.PortIndex = int8(id64)
+		config.PortName, _ = s.Find("input[type=hidden].portName").Attr("value")
+		portWr, exists := s.Find("input#hidPortPwr").Attr("value")
+		config.PortPwr = exists && portWr == "1"
+		config.PwrMode, _ = s.Find("input#hidPwrMode").Attr("value")
+		config.PortPrio, _ = s.Find("input#hidPortPrio").Attr("value")
+		config.LimitType, _ = s.Find("input#hidLimitType").Attr("value")
+		config.PwrLimit, _ = s.Find("input.pwrLimit").Attr("value")
+		config.DetecType, _ = s.Find("input#hidDetecType").Attr("value")
+		config.LongerDetect, _ = s.Find("input.longerDetect").Attr("value")
+		configs = append(configs, config)
+	})
+	return configs, nil
+}
+
+func findPortPortConfInHtmlGs316(reader io.Reader) ([]PoePortSetting, error) {
+	doc, err := goquery.NewDocumentFromReader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []PoePortSetting
+	var rangeErr error
+	doc.Find("div#POE_SETTING div.port-wrap").Each(func(i int, s *goquery.Selection) {
+		config := PoePortSetting{}
+		idAndName := strings.TrimSpace(s.Find("span.port-number").Text())
+		config.PortIndex, config.PortName = parsePortIdAndName(idAndName)
+		config.PortPwr = strings.ToLower(s.Find("span.admin-state").Text()) == "enable"
+		config.PwrMode = s.Find("span.Power-Mode-text").Text()
+		config.PortPrio = s.Find("p.port-priority").Text()
+		config.LimitType = s.Find("p.Power-Limit-Type-text").Text()
+		config.PwrLimit = s.Find("p.Power-Limit-text").Text()
+		var err error
+		config.MinPwrLimit, config.MaxPwrLimit, config.PwrLimitStep, err = parsePwrLimitRangeGs316(s.Find("p.powerLimitDesc").Text())
+		if err != nil && rangeErr == nil {
+			rangeErr = fmt.Errorf("port %d: %w", config.PortIndex, err)
+		}
+		config.DetecType = s.Find("p.Detection-Type-text").Text()
+		config.LongerDetect = s.Find("p.Longer-Detection-text").Text()
+		configs = append(configs, config)
+	})
+	if rangeErr != nil {
+		return nil, rangeErr
+	}
+	return configs, nil
+}
+
+var gs316PwrLimitRangeRegexp = regexp.MustCompile(`from ([0-9.]+) watts to ([0-9.]+) watts with step increments of ([0-9.]+) watts`)
+
+// parsePwrLimitRangeGs316 parses the range of the power limit (W) of a port from its description,
+// e.g. "(The range is from 3.0 watts to 30.0 watts with step increments of 0.2 watts)";
+// a description without it means, the page's format changed, thus the range is unknown
+func parsePwrLimitRangeGs316(description string) (float32, float32, float32, error) {
+	match := gs316PwrLimitRangeRegexp.FindStringSubmatch(description)
+	if match == nil {
+		return 0, 0, 0, fmt.Errorf("can't find the power limit range in the description '%s'", strings.TrimSpace(description))
+	}
+	var values []float32
+	for _, text := range match[1:] {
+		value, err := strconv.ParseFloat(text, 32)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("invalid power limit range '%s': %w", match[0], err)
+		}
+		values = append(values, float32(value))
+	}
+	return values[0], values[1], values[2], nil
+}
@@ -0,0 +1,133 @@
+package netgear
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+func TestFindPortConfigInHtml(t *testing.T) {
+	tests := []struct {
+		model                  string
+		fileName               string
+		parse                  func(reader io.Reader) ([]PoePortSetting, error)
+		expectedSettingsLength int
+		expectedPortIndex      string
+		expectedPort0Pwr       bool
+		expectedPort1Pwr       bool
+		expectedPwrMode        string
+		expectedPortPrio       string
+		expectedLimitType      string
+		expectedPwrLimit       string
+		expectedDetecType      string
+		expectedPortName       string
+	}{
+		{
+			model:                  "GS305EP",
+			fileName:               "PoEPortConfig.cgi.html",
+			parse:                  findPortPortConfInHtmlGs30x,
+			expectedSettingsLength: 4,
+			expectedPortIndex:      "",
+			expectedPort0Pwr:       false,
+			expectedPort1Pwr:       true,
+			expectedPwrMode:        "3",
+			expectedPortPrio:       "0",
+			expectedLimitType:      "2",
+			expectedPwrLimit:       "30.0",
+			expectedDetecType:      "2",
+			expectedPortName:       "link to - sw128 ",
+		},
+		{
+			model:                  "GS308EPP",
+			fileName:               "PoEPortConfig.cgi.html",
+			parse:                  findPortPortConfInHtmlGs30x,
+			expectedSettingsLength: 8,
+			expectedPortIndex:      "",
+			expectedPort0Pwr:       false,
+			expectedPort1Pwr:       false,
+			expectedPwrMode:        "3",
+			expectedPortPrio:       "0",
+			expectedLimitType:      "2",
+			expectedPwrLimit:       "30.0",
+			expectedDetecType:      "2",
+			expectedPortName:       "",
+		},
+		{
+			model:                  "GS316EP",
+			fileName:               "poePortConf.html",
+			parse:                  findPortPortConfInHtmlGs316,
+			expectedSettingsLength: gs316NoPoePorts,
+			expectedPortIndex:      "",
+			expectedPort0Pwr:       false,
+			expectedPort1Pwr:       true,
+			expectedPwrMode:        "802.3at",
+			expectedPortPrio:       "Low",
+			expectedLimitType:      "User",
+			expectedPwrLimit:       "30.0",
+			expectedDetecType:      "IEEE802",
+			expectedPortName:       "AGER 31 SUR Tech",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.model, func(t *testing.T) {
+			// from type inference, settings is of type []PoePortSetting
+			settings, err := test.parse(strings.NewReader(loadTestFile(test.model, test.fileName)))
+
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, settings, has.Length[PoePortSetting](test.expectedSettingsLength))
+
+			setting := settings[0]
+			then.AssertThat(t, setting.PortIndex, is.EqualTo(int8(1)))
+			then.AssertThat(t, setting.PortPwr, is.EqualTo(test.expectedPort0Pwr))
+			then.AssertThat(t, setting.PwrMode, is.EqualTo(test.expectedPwrMode))
+			then.AssertThat(t, setting.PortPrio, is.EqualTo(test.expectedPortPrio))
+			then.AssertThat(t, setting.LimitType, is.EqualTo(test.expectedLimitType))
+			then.AssertThat(t, setting.PwrLimit, is.EqualTo(test.expectedPwrLimit))
+			then.AssertThat(t, setting.DetecType, is.EqualTo(test.expectedDetecType))
+
+			setting = settings[1]
+			then.AssertThat(t, setting.PortPwr, is.EqualTo(test.expectedPort1Pwr))
+
+			// Tests that the space is not removed if the user has deliberately added it
+			then.AssertThat(t, setting.PortName, is.EqualTo(test.expectedPortName))
+		})
+	}
+}
+
+func TestParsePoeSettingsGs30xIsHumanReadable(t *testing.T) {
+	driver, err := DriverFor(GS305EP)
+	then.AssertThat(t, err, is.Nil())
+
+	settings, err := driver.ParsePoeSettings(strings.NewReader(loadTestFile("GS305EP", "PoEPortConfig.cgi.html")))
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, settings[0].PwrMode, is.EqualTo("802.3at"))
+	then.AssertThat(t, settings[0].PortPrio, is.EqualTo("low"))
+	then.AssertThat(t, settings[0].LimitType, is.EqualTo("user"))
+	then.AssertThat(t, settings[0].DetecType, is.EqualTo("IEEE 802"))
+}
+
+func TestFindPwrLimitRangeInHtml(t *testing.T) {
+	gs30x, err := findPortPortConfInHtmlGs30x(strings.NewReader(loadTestFile("GS308EPP", "PoEPortConfig.cgi.html")))
+	then.AssertThat(t, err, is.Nil())
+	gs316, err := findPortPortConfInHtmlGs316(strings.NewReader(loadTestFile("GS316EP", "poePortConf.html")))
+	then.AssertThat(t, err, is.Nil())
+
+	then.AssertThat(t, []float32{gs30x[7].MinPwrLimit, gs30x[7].MaxPwrLimit, gs30x[7].PwrLimitStep}, is.EqualTo([]float32{0, 0, 0}).Reason("the GS30x page doesn't state the range"))
+	then.AssertThat(t, []float32{gs316[14].MinPwrLimit, gs316[14].MaxPwrLimit, gs316[14].PwrLimitStep}, is.EqualTo([]float32{3.0, 30.0, 0.2}))
+	minLimit, maxLimit, step, err := parsePwrLimitRangeGs316("(The range is from 3.0 watts to 15.4 watts with step increments of 0.1 watts)")
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, []float32{minLimit, maxLimit, step}, is.EqualTo([]float32{3.0, 15.4, 0.1}))
+}
+
+func TestFindPwrLimitRangeInHtmlFailsForAnUnknownDescription(t *testing.T) {
+	page := strings.Replace(loadTestFile("GS316EP", "poePortConf.html"), "with step increments of", "in steps of", 1)
+
+	_, err := findPortPortConfInHtmlGs316(strings.NewReader(page))
+
+	then.AssertThat(t, err.Error(), is.EqualTo("port 1: can't find the power limit range in the description '(The range is from 3.0 watts to 30.0 watts in steps of 0.2 watts)'"))
+}
@@ -1,7 +1,6 @@
-package main
+package netgear
 
 import (
-	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -59,7 +58,8 @@ func mapPoePrioGs316(prio string) (string, error) {
 	case "critical":
 		return "3", nil
 	}
-	return "", errors.New(fmt.Sprintf("invalid port priority '%s'; valid values", valuesAsString(portPrioMap)))
+	return "", &InvalidSettingError{Setting: string(PortPrio), Value: prio,
+		Reason: fmt.Sprintf("invalid port priority '%s'; valid values: %s", prio, valuesAsString(portPrioMap))}
 }
 
 var limitTypeMap = map[string]string{
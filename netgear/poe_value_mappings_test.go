@@ -1,4 +1,4 @@
-package main
+package netgear
 
 import (
 	"github.com/corbym/gocrest/is"
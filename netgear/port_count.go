@@ -0,0 +1,64 @@
+package netgear
+
+const (
+	gs316MaxPorts   = 16
+	gs316NoPoePorts = 15
+)
+
+// PortCount is the number of ports of a switch, and of its PoE ports, which are the first ports
+type PortCount struct {
+	Ports    int
+	PoePorts int
+}
+
+var portCounts = map[NetgearModel]PortCount{
+	GS305EP:  {Ports: 5, PoePorts: 4},
+	GS305EPP: {Ports: 5, PoePorts: 4},
+	GS308EP:  {Ports: 8, PoePorts: 8},
+	GS308EPP: {Ports: 8, PoePorts: 8},
+	GS316EP:  {Ports: gs316MaxPorts, PoePorts: gs316NoPoePorts},
+	GS316EPP: {Ports: gs316MaxPorts, PoePorts: gs316NoPoePorts},
+}
+
+// PortCountOf returns the model's number of ports and PoE ports.
+// They are unknown for GS30xEPx, the family of models, which can't be told apart by detection.
+func PortCountOf(model NetgearModel) (PortCount, bool) {
+	count, ok := portCounts[model]
+	return count, ok
+}
+
+// PortCount returns the switch's number of ports and PoE ports, from its model or, for the GS30xEPx family,
+// from the switch's port and PoE settings
+func (c *Client) PortCount() (PortCount, error) {
+	if count, ok := PortCountOf(c.Model()); ok {
+		return count, nil
+	}
+	settings, err := c.PortSettings()
+	if err != nil {
+		return PortCount{}, err
+	}
+	poeSettings, err := c.PoeSettings()
+	if err != nil {
+		return PortCount{}, err
+	}
+	return PortCount{Ports: len(settings), PoePorts: len(poeSettings)}, nil
+}
+
+// checkPorts returns a PortOutOfRangeError for the first port, which the switch's model doesn't have,
+// before any request is sent; ports of the GS30xEPx family are checked by the switch
+func (c *Client) checkPorts(ports []int, poe bool) error {
+	count, ok := PortCountOf(c.Model())
+	if !ok {
+		return nil
+	}
+	maxPort := count.Ports
+	if poe {
+		maxPort = count.PoePorts
+	}
+	for _, port := range ports {
+		if port < 1 || port > maxPort {
+			return &PortOutOfRangeError{Port: port, MaxPort: maxPort}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package netgear
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+func TestPortsOutOfRangeAreRejectedBeforeAnyRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL.Path)
+	}))
+	defer server.Close()
+	client := NewClient(strings.TrimPrefix(server.URL, "http://"), WithSession(GS305EP, "abc123"))
+
+	_, err := client.SetPoe([]int{1, 5}, PoePortSettingsUpdate{PortPwr: "disable"})
+	then.AssertThat(t, err, is.EqualTo[error](&PortOutOfRangeError{Port: 5, MaxPort: 4}))
+
+	_, err = client.CyclePoe([]int{0})
+	then.AssertThat(t, err, is.EqualTo[error](&PortOutOfRangeError{Port: 0, MaxPort: 4}))
+
+	_, err = client.SetPort([]int{5, 6}, PortSettingsUpdate{FlowControl: "On"})
+	then.AssertThat(t, err, is.EqualTo[error](&PortOutOfRangeError{Port: 6, MaxPort: 5}))
+}
+
+func TestPortCountOfModelFamilyIsUnknown(t *testing.T) {
+	count, ok := PortCountOf(GS316EPP)
+	then.AssertThat(t, ok, is.True())
+	then.AssertThat(t, count, is.EqualTo(PortCount{Ports: 16, PoePorts: 15}))
+
+	_, ok = PortCountOf(GS30xEPx)
+	then.AssertThat(t, ok, is.False())
+}
@@ -0,0 +1,327 @@
+package netgear
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type PortSettingKey string
+
+const (
+	Index            PortSettingKey = "Index"
+	Name             PortSettingKey = "Name"
+	Speed            PortSettingKey = "Speed"
+	IngressRateLimit PortSettingKey = "IngressRateLimit"
+	EgressRateLimit  PortSettingKey = "EgressRateLimit"
+	FlowControl      PortSettingKey = "FlowControl"
+)
+
+type PortSetting struct {
+	Index            int8
+	Name             string
+	Speed            string
+	IngressRateLimit string
+	EgressRateLimit  string
+	FlowControl      string
+	// read only values (can't be set)
+	LinkSpeed  string
+	PortStatus string
+}
+
+// PortSettingsUpdate holds the port settings to change.
+// Empty values keep the current setting of a port; a nil Name keeps the current port name.
+type PortSettingsUpdate struct {
+	Name             *string // name of a port, 1-16 character limit
+	Speed            string  // speed and duplex ['100M full', '100M half', '10M full', '10M half', 'Auto', 'Disable']
+	IngressRateLimit string  // incoming rate limit, e.g. ['No Limit', '512 Kbit/s', '1 Mbit/s', ...]
+	EgressRateLimit  string  // outgoing rate limit, e.g. ['No Limit', '512 Kbit/s', '1 Mbit/s', ...]
+	FlowControl      string  // flow control ['Off', 'On']
+}
+
+// SetPort changes the settings for all the given ports (starting with 1)
+// and returns the updated settings of these ports
+func (c *Client) SetPort(ports []int, update PortSettingsUpdate) ([]PortSetting, error) {
+	err := c.checkPorts(ports, false)
+	if err != nil {
+		return nil, err
+	}
+	err = c.retryOnLoginRequired(func() error {
+		driver, err := c.driver()
+		if err != nil {
+			return err
+		}
+		return driver.SetPort(c, ports, update)
+	})
+	if err != nil {
+		return nil, err
+	}
+	settings, err := c.PortSettings()
+	if err != nil {
+		return nil, err
+	}
+	return collectChangedPortConfiguration(ports, settings), nil
+}
+
+func (d *gs30xDriver) SetPort(c *Client, ports []int, update PortSettingsUpdate) error {
+	dashboardData, err := c.requestContent("PortSettings", d.PortSettingsPageUrl(c.Address()))
+	if err != nil {
+		return err
+	}
+	hash, err := findHashInHtml(strings.NewReader(dashboardData))
+	if err != nil {
+		return err
+	}
+	settings, err := findPortSettingsInGs30xEPxHtml(strings.NewReader(dashboardData))
+	if err != nil {
+		return err
+	}
+
+	for _, switchPort := range ports {
+
+		if switchPort > len(settings) || switchPort < 1 {
+			return &PortOutOfRangeError{Port: switchPort, MaxPort: len(settings)}
+		}
+
+		portSetting := settings[switchPort-1]
+
+		// If the port name was not set by the user, set it to the existing name (otherwise an empty port name is always considered to be the
+		// "new" value which blanks the port name on the setting next update)
+		newName := portSetting.Name
+		if update.Name != nil {
+			newName = *update.Name
+		}
+
+		name, err := comparePortSettings(Name, portSetting.Name, newName)
+		if err != nil {
+			return err
+		}
+
+		speed, err := comparePortSettings(Speed, portSetting.Speed, update.Speed)
+		if err != nil {
+			return err
+		}
+
+		ingressRateLimit, err := comparePortSettings(IngressRateLimit, portSetting.IngressRateLimit, update.IngressRateLimit)
+		if err != nil {
+			return err
+		}
+
+		egressRateLimit, err := comparePortSettings(EgressRateLimit, portSetting.EgressRateLimit, update.EgressRateLimit)
+		if err != nil {
+			return err
+		}
+
+		flowControl, err := comparePortSettings(FlowControl, portSetting.FlowControl, update.FlowControl)
+		if err != nil {
+			return err
+		}
+
+		portUpdateValues := url.Values{
+			"hash": {hash},
+			fmt.Sprintf("%s%d", "port", portSetting.Index): {"checked"},
+			"SPEED":        {speed},
+			"FLOW_CONTROL": {flowControl},
+			"DESCRIPTION":  {name},
+			"IngressRate":  {ingressRateLimit},
+			"EgressRate":   {egressRateLimit},
+			"priority":     {"0"},
+		}
+
+		requestUrl := fmt.Sprintf("http://%s/port_status.cgi", c.Address())
+		result, err := c.PostPage(requestUrl, portUpdateValues.Encode())
+		if err != nil {
+			return err
+		}
+
+		err = changeResult(result)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *gs316Driver) SetPort(c *Client, ports []int, update PortSettingsUpdate) error {
+	dashboardData, err := c.requestContent("PortSettings", d.PortSettingsPageUrl(c.Address()))
+	if err != nil {
+		return err
+	}
+	currentSettings, err := findPortSettingsInGs316EPxHtml(strings.NewReader(dashboardData))
+	if err != nil {
+		return err
+	}
+
+	for _, portId := range ports {
+		if portId < 1 || portId > gs316MaxPorts {
+			return &PortOutOfRangeError{Port: portId, MaxPort: gs316MaxPorts}
+		}
+
+		currentSetting := currentSettings[portId-1]
+
+		newSetting, err := createPortSettingUpdatePayloadGs316ep(update, currentSetting, c.Token(), strconv.Itoa(portId))
+		if err != nil {
+			return err
+		}
+
+		requestUrl := fmt.Sprintf("http://%s/iss/specific/dashboard.html", c.Address())
+		result, err := c.PostPage(requestUrl, newSetting.Encode())
+		if err != nil {
+			return err
+		}
+
+		err = changeResult(result)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func createPortSettingUpdatePayloadGs316ep(update PortSettingsUpdate, currentSetting PortSetting, token string, portId string) (url.Values, error) {
+	// If the port name was not set by the user, set it to the existing name (otherwise an empty port name is always considered to be the
+	// "new" value which blanks the port name on the setting next update)
+	portName := currentSetting.Name
+	if update.Name != nil {
+		portName = *update.Name
+	}
+
+	newSetting := url.Values{
+		"Gambit":    {token},
+		"TYPE":      {"portInfo"},
+		"PORT_NO":   {portId},
+		"PORT_NAME": {portName},
+		// default values, for all requests (not entirely sure about the meaning)
+		"COLOR1G":    {"NOTSET"},
+		"COLOR100M":  {"NOTSET"},
+		"FREQUENCY":  {"-1"},
+		"BRIGHTNESS": {"undefined"},
+		"STATUS":     {"0"},
+	}
+
+	if update.IngressRateLimit != "" {
+		newVal := bidiMapLookup(update.IngressRateLimit, portRateLimitMap)
+		if newVal == unknown {
+			return nil, &InvalidSettingError{Setting: string(IngressRateLimit), Value: update.IngressRateLimit,
+				Reason: fmt.Sprintf("port ingres setting '%s' could not be set. Accepted values are: %s", update.IngressRateLimit, valuesAsString(portRateLimitMap))}
+		}
+		newSetting.Add("INGRESS", newVal)
+	} else {
+		newSetting.Add("INGRESS", "NOTSET")
+	}
+
+	if update.EgressRateLimit != "" {
+		newVal := bidiMapLookup(update.EgressRateLimit, portRateLimitMap)
+		if newVal == unknown {
+			return nil, &InvalidSettingError{Setting: string(EgressRateLimit), Value: update.EgressRateLimit,
+				Reason: fmt.Sprintf("port egress setting '%s' could not be set. Accepted values are: %s", update.EgressRateLimit, valuesAsString(portRateLimitMap))}
+		}
+		newSetting.Add("EGRESS", newVal)
+	} else {
+		newSetting.Add("EGRESS", "NOTSET")
+	}
+
+	if update.FlowControl != "" {
+		flowControlValue := "4"
+		if strings.ToLower(update.FlowControl) == "off" {
+			flowControlValue = "1"
+		}
+		newSetting.Add("FLOW_CONTROL", flowControlValue)
+	} else {
+		newSetting.Add("FLOW_CONTROL", "NOTSET")
+	}
+
+	if update.Speed != "" {
+		switch update.Speed {
+		case portSpeedAuto:
+			newSetting.Add("PORT_CTRL_MODE", "1")
+		case portSpeedDisable:
+			newSetting.Add("PORT_CTRL_MODE", "3")
+		case portSpeed10Mhalf:
+			newSetting.Add("PORT_CTRL_MODE", "2")
+			newSetting.Add("PORT_CTRL_SPEED", "1")
+			newSetting.Add("PORT_CTRL_DUPLEX", "2")
+		case portSpeed10Mfull:
+			newSetting.Add("PORT_CTRL_MODE", "2")
+			newSetting.Add("PORT_CTRL_SPEED", "1")
+			newSetting.Add("PORT_CTRL_DUPLEX", "1")
+		case portSpeed100Nhalf:
+			newSetting.Add("PORT_CTRL_MODE", "2")
+			newSetting.Add("PORT_CTRL_SPEED", "2")
+			newSetting.Add("PORT_CTRL_DUPLEX", "2")
+		case portSpeed100Mfull:
+			newSetting.Add("PORT_CTRL_MODE", "2")
+			newSetting.Add("PORT_CTRL_SPEED", "2")
+			newSetting.Add("PORT_CTRL_DUPLEX", "1")
+		default:
+			return nil, &InvalidSettingError{Setting: string(Speed), Value: update.Speed,
+				Reason: fmt.Sprintf("port speed setting '%s' could not be set. Accepted values are: %s", update.Speed, valuesAsString(portSpeedMap))}
+		}
+	} else {
+		newSetting.Add("PORT_CTRL_MODE", "NOTSET")
+		newSetting.Add("PORT_CTRL_SPEED", "NOTSET")
+		newSetting.Add("PORT_CTRL_DUPLEX", "NOTSET")
+	}
+	return newSetting, nil
+}
+
+func collectChangedPortConfiguration(ports []int, settings []PortSetting) (changedPorts []PortSetting) {
+	for _, configuredPort := range ports {
+		for _, portSetting := range settings {
+			if int(portSetting.Index) == configuredPort {
+				changedPorts = append(changedPorts, portSetting)
+			}
+		}
+	}
+
+	return changedPorts
+}
+
+func comparePortSettings(name PortSettingKey, defaultValue string, newValue string) (string, error) {
+	if len(newValue) == 0 && name != Name {
+		return defaultValue, nil
+	}
+
+	switch name {
+	case Name:
+		if defaultValue != newValue {
+			if len(newValue) <= 16 {
+				return newValue, nil
+			} else {
+				return defaultValue, &InvalidSettingError{Setting: string(name), Value: newValue, Reason: "port name could not be set. PortSetting name must be 16 characters or less"}
+			}
+		}
+		return defaultValue, nil
+	case Speed:
+		speed := bidiMapLookup(newValue, portSpeedMap)
+		if speed == unknown {
+			return speed, &InvalidSettingError{Setting: string(name), Value: newValue, Reason: "port speed could not be set. Accepted values are: " + valuesAsString(portSpeedMap)}
+		}
+		return speed, nil
+	case IngressRateLimit:
+		inRateLimit := bidiMapLookup(newValue, portRateLimitMap)
+		if inRateLimit == unknown {
+			return inRateLimit, &InvalidSettingError{Setting: string(name), Value: newValue, Reason: "ingress rate limit could not be set. Accepted values are: " + valuesAsString(portRateLimitMap)}
+		}
+		return inRateLimit, nil
+	case EgressRateLimit:
+		outRateLimit := bidiMapLookup(newValue, portRateLimitMap)
+		if outRateLimit == unknown {
+			return outRateLimit, &InvalidSettingError{Setting: string(name), Value: newValue, Reason: "egress rate limit could not be set. Accepted values are: " + valuesAsString(portRateLimitMap)}
+		}
+		return outRateLimit, nil
+	case FlowControl:
+		flowControl := bidiMapLookup(newValue, portFlowControlMap)
+		if flowControl == unknown {
+			return flowControl, &InvalidSettingError{Setting: string(name), Value: newValue, Reason: "flow control could not be set. Accepted values are: " + valuesAsString(portFlowControlMap)}
+		}
+		return flowControl, nil
+	default:
+		return defaultValue, errors.New("could not find port setting")
+	}
+
+}
@@ -1,4 +1,4 @@
-package main
+package netgear
 
 import (
 	"strings"
@@ -9,7 +9,7 @@ import (
 )
 
 func TestFindHashInPortHtml(t *testing.T) {
-	hash, err := findHashInHtml(GS308EPP, strings.NewReader(loadTestFile("GS308EPP", "dashboard.cgi.html")))
+	hash, err := findHashInHtml(strings.NewReader(loadTestFile("GS308EPP", "dashboard.cgi.html")))
 
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, hash, is.EqualTo("4f11f5d64ef3fd75a92a9f2ad1de3060"))
@@ -152,9 +152,8 @@ func TestCollectChangedPortConfiguration(t *testing.T) {
 func TestCreatePortSettingUpdatePayloadGs316ep(t *testing.T) {
 	token := "xyz123"
 	newName := "newName"
-	portSet := PortSetCommand{
+	update := PortSettingsUpdate{
 		Name:             &newName,
-		Ports:            []int{16},
 		Speed:            "10M half",
 		IngressRateLimit: "1 Mbit/s",
 		EgressRateLimit:  "16 Mbit/s",
@@ -163,7 +162,7 @@ func TestCreatePortSettingUpdatePayloadGs316ep(t *testing.T) {
 	currentSetting := PortSetting{
 		Name: "oldName",
 	}
-	value, err := createPortSettingUpdatePayloadGs316ep(&portSet, currentSetting, token, "16")
+	value, err := createPortSettingUpdatePayloadGs316ep(update, currentSetting, token, "16")
 	then.AssertThat(t, err, is.Nil())
 
 	then.AssertThat(t, value.Encode(), is.StringContaining("Gambit=xyz123"))
@@ -0,0 +1,107 @@
+package netgear
+
+import (
+	"github.com/PuerkitoBio/goquery"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// PortSettings fetches the current settings and link status of all ports
+func (c *Client) PortSettings() ([]PortSetting, error) {
+	return withReLogin(c, c.portSettings)
+}
+
+func (c *Client) portSettings() ([]PortSetting, error) {
+	driver, err := c.driver()
+	if err != nil {
+		return nil, err
+	}
+	dashboardData, err := c.requestContent("PortSettings", driver.PortSettingsPageUrl(c.address))
+	if err != nil {
+		return nil, err
+	}
+	return driver.ParsePortSettings(strings.NewReader(dashboardData))
+}
+
+// toReadablePortSettingsGs30x translates the numeric values, used by GS30x models, into human-readable values.
+// GS316 models already report human-readable values.
+func toReadablePortSettingsGs30x(settings []PortSetting) []PortSetting {
+	var readable []PortSetting
+	for _, setting := range settings {
+		setting.Speed = bidiMapLookup(setting.Speed, portSpeedMap)
+		setting.IngressRateLimit = bidiMapLookup(setting.IngressRateLimit, portRateLimitMap)
+		setting.EgressRateLimit = bidiMapLookup(setting.EgressRateLimit, portRateLimitMap)
+		setting.FlowControl = bidiMapLookup(setting.FlowControl, portFlowControlMap)
+		readable = append(readable, setting)
+	}
+	return readable
+}
+
+func findPortSettingsInGs30xEPxHtml(reader io.Reader) (ports []PortSetting, err error) {
+
+	doc, err := goquery.NewDocumentFromReader(reader)
+	if err != nil {
+		return ports, err
+	}
+
+	doc.Find("li.list_item").Each(func(i int, s *goquery.Selection) {
+		portCfg := PortSetting{}
+
+		id, _ := s.Find("input[type=hidden].port").Attr("value")
+		var id64, _ = strconv.ParseInt(id, 10, 8)
+		portCfg.Index = int8(id64)
+		portCfg.Name, _ = s.Find("input[type=hidden].portName").Attr("value")
+		portCfg.Speed, _ = s.Find("input[type=hidden].Speed").Attr("value")
+		portCfg.IngressRateLimit, _ = s.Find("input[type=hidden].ingressRate").Attr("value")
+		portCfg.EgressRateLimit, _ = s.Find("input[type=hidden].egressRate").Attr("value")
+		portCfg.FlowControl, _ = s.Find("input[type=hidden].flowCtr").Attr("value")
+		portCfg.LinkSpeed, _ = s.Find("input[type=hidden].LinkedSpeed").Attr("value")
+		portCfg.PortStatus = strings.TrimSpace(s.Find("span.pull-right").Text())
+		ports = append(ports, portCfg)
+	})
+
+	return ports, nil
+}
+
+func findPortSettingsInGs316EPxHtml(reader io.Reader) (ports []PortSetting, err error) {
+
+	doc, err := goquery.NewDocumentFromReader(reader)
+	if err != nil {
+		return ports, err
+	}
+
+	doc.Find("div.dashboard-port-status").Each(func(i int, s *goquery.Selection) {
+		s.Find("span.port-number").Each(func(i int, selection *goquery.Selection) {
+			ports = append(ports, PortSetting{})
+		})
+
+		s.Find("span.port-number").Each(func(i int, selection *goquery.Selection) {
+			var id64, _ = strconv.ParseInt(strings.TrimSpace(selection.Text()), 10, 8)
+			ports[i].Index = int8(id64)
+		})
+		s.Find("span.port-name span.name").Each(func(i int, selection *goquery.Selection) {
+			ports[i].Name = strings.TrimSpace(selection.Text())
+		})
+		s.Find("p.speed-text").Each(func(i int, selection *goquery.Selection) {
+			ports[i].Speed = strings.TrimSpace(selection.Text())
+		})
+		s.Find("p.ingress-text").Each(func(i int, selection *goquery.Selection) {
+			ports[i].IngressRateLimit = strings.TrimSpace(selection.Text())
+		})
+		s.Find("p.egress-text").Each(func(i int, selection *goquery.Selection) {
+			ports[i].EgressRateLimit = strings.TrimSpace(selection.Text())
+		})
+		s.Find("p.flow-text").Each(func(i int, selection *goquery.Selection) {
+			ports[i].FlowControl = strings.TrimSpace(selection.Text())
+		})
+		s.Find("span.status-on-port").Each(func(i int, selection *goquery.Selection) {
+			ports[i].PortStatus = strings.TrimSpace(selection.Text())
+		})
+		s.Find("p.link-speed-text").Each(func(i int, selection *goquery.Selection) {
+			ports[i].LinkSpeed = strings.TrimSpace(selection.Text())
+		})
+	})
+
+	return ports, nil
+}
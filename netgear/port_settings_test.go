@@ -1,9 +1,10 @@
-package main
+package netgear
 
 import (
 	"github.com/corbym/gocrest/has"
 	"github.com/corbym/gocrest/is"
 	"github.com/corbym/gocrest/then"
+	"io"
 	"strings"
 	"testing"
 )
@@ -12,6 +13,7 @@ func TestFindPortSettingsInHtml(t *testing.T) {
 	tests := []struct {
 		model                    string
 		fileName                 string
+		parse                    func(reader io.Reader) ([]PortSetting, error)
 		expectedSettingsLength   int
 		expectedIndex            int8
 		expectedName             string
@@ -25,6 +27,7 @@ func TestFindPortSettingsInHtml(t *testing.T) {
 		{
 			model:                    "GS308EPP",
 			fileName:                 "dashboard.cgi.html",
+			parse:                    findPortSettingsInGs30xEPxHtml,
 			expectedSettingsLength:   8,
 			expectedIndex:            1,
 			expectedName:             "port name 1",
@@ -38,6 +41,7 @@ func TestFindPortSettingsInHtml(t *testing.T) {
 		{
 			model:                    "GS316EP",
 			fileName:                 "dashboard.html",
+			parse:                    findPortSettingsInGs316EPxHtml,
 			expectedSettingsLength:   16,
 			expectedIndex:            1,
 			expectedName:             "AGER 31 SUR Tech",
@@ -51,7 +55,7 @@ func TestFindPortSettingsInHtml(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.model, func(t *testing.T) {
-			portSetting, err := findPortSettingsInHtml(NetgearModel(test.model), strings.NewReader(loadTestFile(test.model, test.fileName)))
+			portSetting, err := test.parse(strings.NewReader(loadTestFile(test.model, test.fileName)))
 
 			then.AssertThat(t, err, is.Nil())
 			then.AssertThat(t, portSetting, has.Length[PortSetting](test.expectedSettingsLength))
@@ -1,4 +1,4 @@
-package main
+package netgear
 
 // helper functions for handling map lookup and dumping values in poe_value_mappings.go
 var portSpeedMap = map[string]string{
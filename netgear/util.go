@@ -0,0 +1,24 @@
+package netgear
+
+import (
+	"strconv"
+)
+
+func parseFloat32(text string) float32 {
+	i64, _ := strconv.ParseFloat(text, 32)
+	return float32(i64)
+}
+
+func parseInt32(text string) int32 {
+	i64, _ := strconv.ParseInt(text, 10, 32)
+	return int32(i64)
+}
+
+func filter[T any](ss []T, test func(T) bool) (ret []T) {
+	for _, s := range ss {
+		if test(s) {
+			ret = append(ret, s)
+		}
+	}
+	return
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NotificationSink sends the notifications of "poe monitor", by one of: a webhook, a command or an email
+type NotificationSink struct {
+	Name string `yaml:"name"`
+	// Webhook is a URL, which the event is posted to as JSON
+	Webhook string `yaml:"webhook"`
+	// Exec runs the command with the shell, with the event in NTGRRC_* environment variables
+	Exec string `yaml:"exec"`
+	// SMTP is the host:port of a mail relay, which accepts mails without authentication, e.g. 'localhost:25'
+	SMTP string   `yaml:"smtp"`
+	From string   `yaml:"from"`
+	To   []string `yaml:"to"`
+	// Timeout of a single notification, default 10s
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+func (sink *NotificationSink) validate() error {
+	sinks := 0
+	for _, target := range []string{sink.Webhook, sink.Exec, sink.SMTP} {
+		if target != "" {
+			sinks++
+		}
+	}
+	switch {
+	case sinks != 1:
+		return fmt.Errorf("needs exactly one of 'webhook', 'exec' or 'smtp'")
+	case sink.SMTP != "" && (sink.From == "" || len(sink.To) == 0):
+		return fmt.Errorf("needs 'from' and 'to' for 'smtp'")
+	case sink.Timeout < 0:
+		return fmt.Errorf("has a negative timeout")
+	}
+	return nil
+}
+
+// poeEvent is the start of a PoE fault of a port, or its end, as sent to the notification sinks
+type poeEvent struct {
+	Time time.Time `json:"time"`
+	// Event is 'fault' or 'recovered'
+	Event       string `json:"event"`
+	Switch      string `json:"switch"`
+	Address     string `json:"address"`
+	Port        int    `json:"port"`
+	PortName    string `json:"port_name"`
+	Status      string `json:"status"`
+	ErrorStatus string `json:"error_status"`
+	// Problem is the fault, or the fault, which ended with the recovery
+	Problem string `json:"problem"`
+	Message string `json:"message"`
+}
+
+const (
+	poeEventFault     = "fault"
+	poeEventRecovered = "recovered"
+)
+
+// environment are the event's NTGRRC_* environment variables for exec hooks
+func (event *poeEvent) environment() []string {
+	return []string{
+		"NTGRRC_EVENT=" + event.Event,
+		"NTGRRC_TIME=" + event.Time.Format(time.RFC3339),
+		"NTGRRC_SWITCH=" + event.Switch,
+		"NTGRRC_ADDRESS=" + event.Address,
+		"NTGRRC_PORT=" + strconv.Itoa(event.Port),
+		"NTGRRC_PORT_NAME=" + event.PortName,
+		"NTGRRC_STATUS=" + event.Status,
+		"NTGRRC_ERROR_STATUS=" + event.ErrorStatus,
+		"NTGRRC_PROBLEM=" + event.Problem,
+		"NTGRRC_MESSAGE=" + event.Message,
+	}
+}
+
+// mail is the event as plain text email
+func (event *poeEvent) mail(from string, to []string) string {
+	lines := []string{
+		"From: " + from,
+		"To: " + strings.Join(to, ", "),
+		"Subject: ntgrrc: " + event.Message,
+		"Date: " + event.Time.Format(time.RFC1123Z),
+		"Content-Type: text/plain; charset=utf-8",
+		"",
+		event.Message,
+		"",
+		fmt.Sprintf("Switch: %s (%s)", event.Switch, event.Address),
+		fmt.Sprintf("Port: %d %s", event.Port, event.PortName),
+		"Status: " + event.Status,
+		"Error status: " + event.ErrorStatus,
+		"Time: " + event.Time.Format(time.DateTime),
+	}
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+// notify sends the event to the sink
+func (sink *NotificationSink) notify(event poeEvent) error {
+	timeout := sink.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	switch {
+	case sink.Webhook != "":
+		return postWebhook(ctx, sink.Webhook, event)
+	case sink.Exec != "":
+		cmd := shellCommand(ctx, sink.Exec)
+		cmd.Env = append(os.Environ(), event.environment()...)
+		output, err := cmd.CombinedOutput()
+		if err != nil && len(output) > 0 {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+		}
+		return err
+	default:
+		return sendMail(ctx, sink.SMTP, sink.From, sink.To, event.mail(sink.From, sink.To))
+	}
+}
+
+func postWebhook(ctx context.Context, url string, event poeEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("HTTP status %s", resp.Status)
+	}
+	return nil
+}
+
+// sendMail sends the mail through the relay, like smtp.SendMail, but within the context's deadline
+func sendMail(ctx context.Context, relay string, from string, to []string, mail string) error {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", relay)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	host, _, _ := net.SplitHostPort(relay)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	err = client.Mail(from)
+	if err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		err = client.Rcpt(recipient)
+		if err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write([]byte(mail))
+	if err != nil {
+		return err
+	}
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
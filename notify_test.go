@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+func testPoeEvent() poeEvent {
+	event := poeEvent{
+		Time:        time.Date(2026, 10, 18, 12, 30, 0, 0, time.UTC),
+		Event:       poeEventFault,
+		Switch:      "office-1",
+		Address:     "192.168.0.2",
+		Port:        3,
+		PortName:    "cam-lobby",
+		Status:      "Fault",
+		ErrorStatus: "Overload",
+		Problem:     "error status 'Overload'",
+	}
+	event.Message = event.describe()
+	return event
+}
+
+func Test_poe_event_message_names_the_port(t *testing.T) {
+	then.AssertThat(t, testPoeEvent().Message, is.EqualTo("office-1 port 3 'cam-lobby': PoE fault, error status 'Overload'"))
+}
+
+func Test_exec_notification_gets_the_event_in_the_environment(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	fileName := filepath.Join(t.TempDir(), "event.txt")
+	sink := NotificationSink{Name: "log", Exec: `echo "$NTGRRC_EVENT $NTGRRC_SWITCH $NTGRRC_PORT $NTGRRC_ERROR_STATUS" > ` + fileName}
+
+	err := sink.notify(testPoeEvent())
+
+	then.AssertThat(t, err, is.Nil())
+	content, err := os.ReadFile(fileName)
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, string(content), is.EqualTo("fault office-1 3 Overload\n"))
+}
+
+func Test_poe_event_mail(t *testing.T) {
+	event := testPoeEvent()
+
+	mail := event.mail("ntgrrc@example.com", []string{"ops@example.com", "admin@example.com"})
+
+	then.AssertThat(t, strings.Split(mail, "\r\n")[:3], is.EqualTo([]string{
+		"From: ntgrrc@example.com",
+		"To: ops@example.com, admin@example.com",
+		"Subject: ntgrrc: office-1 port 3 'cam-lobby': PoE fault, error status 'Overload'",
+	}))
+	then.AssertThat(t, mail, is.StringContaining("\r\nSwitch: office-1 (192.168.0.2)\r\n"))
+}
+
+func Test_config_file_with_invalid_notification_is_rejected(t *testing.T) {
+	var tests = []struct {
+		notification string
+		expected     string
+	}{
+		{"  - webhook: http://localhost/hook", "config file: notification #1 has no name"},
+		{"  - name: chat\n    webhook: http://localhost/hook\n    exec: notify-send", "config file: notification 'chat' needs exactly one of 'webhook', 'exec' or 'smtp'"},
+		{"  - name: mail\n    smtp: localhost:25\n    from: ntgrrc@example.com", "config file: notification 'mail' needs 'from' and 'to' for 'smtp'"},
+	}
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			_, err := loadTestConfig(t, testConfig+"notifications:\n"+test.notification+"\n")
+
+			then.AssertThat(t, err.Error(), is.EqualTo(test.expected))
+		})
+	}
+}
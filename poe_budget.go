@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"github.com/nitram509/ntgrrc/netgear"
+	"math"
+)
+
+type PoeBudgetCommand struct {
+	SwitchSelector `embed:""`
+	Parallel       int     `help:"maximum number of switches to query at the same time" default:"4"`
+	Budget         float64 `help:"the switch's total PoE power budget in watts, e.g. for GS30x switches, which are detected as family GS30xEPx; by default from 'poe-budget' in the config file or the model's data sheet, because the switches don't report it" placeholder:"WATTS"`
+	Ports          bool    `help:"show the power and the worst case allocation per port, instead of the totals"`
+	TableOptions   `embed:""`
+}
+
+func (poe *PoeBudgetCommand) Run(args *GlobalOptions) error {
+	item := "poe_budget"
+	if poe.Ports {
+		item = "poe_budget_ports"
+	}
+	return poe.queryAll(args, poe.Parallel, &poe.TableOptions, item, func(client *netgear.Client, switchName string) (table, error) {
+		budget, err := client.PoeBudget()
+		if err != nil {
+			return table{}, err
+		}
+		budget.BudgetInWatt, budget.BudgetSource = poe.budgetOf(args, switchName, budget.BudgetInWatt, budget.BudgetSource)
+		if poe.Ports {
+			return poePortBudgetTable(switchName, budget.Ports), nil
+		}
+		return poeBudgetTable(switchName, budget), nil
+	})
+}
+
+// budgetOf is the switch's budget and its source: the command line, the config file, or else the data sheet of the model
+// from the config file, which is more specific than the detected GS30xEPx family, or of the detected model
+func (poe *PoeBudgetCommand) budgetOf(args *GlobalOptions, switchName string, modelBudget float32, modelSource string) (float32, string) {
+	if poe.Budget > 0 {
+		return float32(poe.Budget), "--budget"
+	}
+	target := args.config.lookupSwitch(switchName)
+	if target.config != nil && target.config.PoeBudget > 0 {
+		return float32(target.config.PoeBudget), "config file"
+	}
+	if target.config != nil {
+		if budget, ok := netgear.PoePowerBudget(target.config.Model); ok {
+			return budget, netgear.BudgetFromDataSheet
+		}
+	}
+	return modelBudget, modelSource
+}
+
+// poeBudgetValue is a row for templates, e.g. {{.Switch}}, with the fields of netgear.PoeBudget
+type poeBudgetValue struct {
+	Switch string
+	netgear.PoeBudget
+}
+
+var poeBudgetColumns = []column[netgear.PoeBudget]{
+	{name: "model", header: "Model", value: func(b netgear.PoeBudget) any { return b.Model }},
+	{name: "budget_w", influx: influxField, alias: "budget", header: "Budget (W)", value: func(b netgear.PoeBudget) any { return knownWatts(b.BudgetInWatt, 0) },
+		text: func(b netgear.PoeBudget) string { return wattsText(knownWatts(b.BudgetInWatt, 0)) }},
+	{name: "budget_source", header: "Budget Source", value: func(b netgear.PoeBudget) any { return b.BudgetSource }},
+	{name: "consumption_w", influx: influxField, alias: "consumption", header: "Consumption (W)", value: func(b netgear.PoeBudget) any { return b.ConsumptionInWatt },
+		text: func(b netgear.PoeBudget) string { return fmt.Sprintf("%.2f", b.ConsumptionInWatt) }},
+	{name: "headroom_w", influx: influxField, alias: "headroom", header: "Headroom (W)", value: func(b netgear.PoeBudget) any { return knownWatts(b.BudgetInWatt, b.ConsumptionInWatt) },
+		text: func(b netgear.PoeBudget) string { return wattsText(knownWatts(b.BudgetInWatt, b.ConsumptionInWatt)) }},
+	{name: "allocated_w", influx: influxField, alias: "allocated", header: "Worst case (W)", value: func(b netgear.PoeBudget) any { return b.AllocationInWatt },
+		text: func(b netgear.PoeBudget) string { return fmt.Sprintf("%.2f", b.AllocationInWatt) }},
+	{name: "unallocated_w", influx: influxField, alias: "unallocated", header: "Unallocated (W)", value: func(b netgear.PoeBudget) any { return knownWatts(b.BudgetInWatt, b.AllocationInWatt) },
+		text: func(b netgear.PoeBudget) string { return wattsText(knownWatts(b.BudgetInWatt, b.AllocationInWatt)) }},
+}
+
+func poeBudgetTable(switchName string, budget netgear.PoeBudget) table {
+	return newTable("poe_budget", switchName, poeBudgetColumns, []netgear.PoeBudget{budget}, func(budget netgear.PoeBudget) any {
+		return poeBudgetValue{Switch: switchName, PoeBudget: budget}
+	})
+}
+
+// poePortBudgetValue is a row for templates, e.g. {{.Switch}}, with the fields of netgear.PoePortBudget
+type poePortBudgetValue struct {
+	Switch string
+	netgear.PoePortBudget
+}
+
+var poePortBudgetColumns = []column[netgear.PoePortBudget]{
+	{name: "port_id", influx: influxTag, alias: "port", header: "Port ID", value: func(p netgear.PoePortBudget) any { return p.PortIndex }},
+	{name: "port_name", influx: influxTag, alias: "name", header: "Port Name", value: func(p netgear.PoePortBudget) any { return p.PortName }},
+	{name: "port_power", header: "Port Power", value: func(p netgear.PoePortBudget) any { return p.PortPwr },
+		text: func(p netgear.PoePortBudget) string { return asTextPortPower(p.PortPwr) }},
+	{name: "power_class", alias: "class", header: "PortPwr class", value: func(p netgear.PoePortBudget) any { return p.PoePowerClass }},
+	{name: "limit_type", header: "Limit Type", value: func(p netgear.PoePortBudget) any { return p.LimitType }},
+	{name: "limit_w", alias: "limit", header: "Limit (W)", value: func(p netgear.PoePortBudget) any { return parseOptionalFloat(p.PwrLimit) },
+		text: func(p netgear.PoePortBudget) string { return p.PwrLimit }},
+	{name: "power_w", influx: influxField, alias: "power", header: "PortPwr (W)", value: func(p netgear.PoePortBudget) any { return p.PowerInWatt },
+		text: func(p netgear.PoePortBudget) string { return fmt.Sprintf("%.2f", p.PowerInWatt) }},
+	{name: "allocation_w", influx: influxField, alias: "allocation", header: "Worst case (W)", value: func(p netgear.PoePortBudget) any { return p.AllocationInWatt },
+		text: func(p netgear.PoePortBudget) string { return fmt.Sprintf("%.2f", p.AllocationInWatt) }},
+}
+
+func poePortBudgetTable(switchName string, ports []netgear.PoePortBudget) table {
+	return newTable("poe_budget_ports", switchName, poePortBudgetColumns, ports, func(port netgear.PoePortBudget) any {
+		return poePortBudgetValue{Switch: switchName, PoePortBudget: port}
+	})
+}
+
+// knownWatts is the budget minus the used watts, rounded to milliwatts, or nil, which is null in JSON, when the budget is unknown
+func knownWatts(budget float32, used float32) *float64 {
+	if budget <= 0 {
+		return nil
+	}
+	watts := math.Round((exactFloat64(budget)-exactFloat64(used))*1000) / 1000
+	return &watts
+}
+
+func wattsText(watts *float64) string {
+	if watts == nil {
+		return "unknown"
+	}
+	return fmt.Sprintf("%.2f", *watts)
+}
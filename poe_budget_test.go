@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/ntgrrc/netgear"
+)
+
+func Test_poe_budget_from_command_line_config_file_or_model(t *testing.T) {
+	config, err := loadTestConfig(t, testConfig)
+	then.AssertThat(t, err, is.Nil())
+	args := GlobalOptions{config: config}
+	poe := PoeBudgetCommand{}
+
+	budgetOf := func(switchName string, modelBudget float32, modelSource string) []any {
+		budget, source := poe.budgetOf(&args, switchName, modelBudget, modelSource)
+		return []any{budget, source}
+	}
+
+	then.AssertThat(t, budgetOf("office-1", 0, ""), is.EqualTo([]any{float32(123), "data sheet"}))
+	then.AssertThat(t, budgetOf("office-2", 0, ""), is.EqualTo([]any{float32(60), "config file"}))
+	then.AssertThat(t, budgetOf("lab-1", 180, "data sheet"), is.EqualTo([]any{float32(180), "data sheet"}))
+	poe.Budget = 100
+	then.AssertThat(t, budgetOf("office-2", 0, ""), is.EqualTo([]any{float32(100), "--budget"}))
+}
+
+func Test_poe_budget_headroom_is_unknown_without_budget(t *testing.T) {
+	budget := netgear.PoeBudget{Model: netgear.GS30xEPx, ConsumptionInWatt: 5.8, AllocationInWatt: 30}
+
+	unknown := poeBudgetTable("", budget)
+	budget.BudgetInWatt, budget.BudgetSource = 63, "config file"
+	known := poeBudgetTable("", budget)
+
+	then.AssertThat(t, unknown.content()[0], is.EqualTo([]string{"GS30xEPx", "unknown", "", "5.80", "unknown", "30.00", "unknown"}))
+	then.AssertThat(t, known.content()[0], is.EqualTo([]string{"GS30xEPx", "63.00", "config file", "5.80", "57.20", "30.00", "33.00"}))
+	then.AssertThat(t, *known.records[0].values[4].(*float64), is.EqualTo(57.2))
+}
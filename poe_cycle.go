@@ -1,130 +1,50 @@
 package main
 
 import (
-	"errors"
-	"fmt"
-	"net/http"
-	"net/url"
-	"slices"
-	"strings"
+	"github.com/nitram509/ntgrrc/netgear"
+	"time"
 )
 
 type PoeCyclePowerCommand struct {
-	Address string `required:"" help:"the Netgear switch's IP address or host name to connect to" short:"a"`
-	Ports   []int  `required:"" help:"port number (starting with 1), use multiple times for cycling multiple ports at once" short:"p" name:"port"`
+	Address   string        `required:"" help:"the Netgear switch's IP address or host name to connect to, or its name from the config file" short:"a"`
+	Ports     portList      `help:"port numbers (starting with 1) or ranges, e.g. '1-4,7' or 'all', use multiple times for cycling multiple ports at once" short:"p" name:"port" placeholder:"PORTS"`
+	PortNames []string      `help:"port name or glob, e.g. 'ap-*', instead of or in addition to the port number, use multiple times for several names" name:"port-name" placeholder:"NAME"`
+	OffTime   time.Duration `help:"keep the power off for this duration, e.g. '10s', by disabling and enabling the ports, instead of the switch's short power cycle" placeholder:"DURATION"`
+	Wait      bool          `help:"wait until the ports, which delivered power before, deliver power again; fails, when they don't within the timeout"`
+	Timeout   time.Duration `help:"the maximum time to wait for the ports to deliver power again, with --wait" default:"2m" placeholder:"DURATION"`
 }
 
 func (poe *PoeCyclePowerCommand) Run(args *GlobalOptions) error {
-	model := args.model
-	if len(model) == 0 {
-		var err error
-		model, err = detectNetgearModel(args, poe.Address)
-		if err != nil {
-			return err
-		}
-		args.model = model
-
-	}
-	if isModel30x(model) {
-		return poe.cyclePowerGs30xEPx(args)
+	if poe.Wait && poe.Timeout <= 0 {
+		return newCommandError(categoryInvalidArgument, "invalid --timeout %s, it must be positive", poe.Timeout)
 	}
-	if isModel316(model) {
-		return poe.cyclePowerGs316EPx(args)
-	}
-	panic("model not supported")
-}
-
-func (poe *PoeCyclePowerCommand) cyclePowerGs30xEPx(args *GlobalOptions) error {
-	poeExt := &PoeExt{}
-
-	settings, err := requestPoeConfiguration(args, poe.Address, poeExt)
+	client, err := newClient(args, poe.Address)
 	if err != nil {
 		return err
 	}
-
-	poeSettings := url.Values{
-		"hash":   {poeExt.Hash},
-		"ACTION": {"Reset"},
-	}
-
-	for _, switchPort := range poe.Ports {
-		if switchPort < 1 || switchPort > len(settings) {
-			return errors.New(fmt.Sprintf("given port id %d, doesn't fit in range 1..%d", switchPort, len(settings)))
-		}
-		poeSettings.Add(fmt.Sprintf("port%d", switchPort-1), "checked")
-	}
-
-	result, err := requestPoeSettingsUpdate(args, poe.Address, poeSettings.Encode())
+	ports, err := selectPorts(poe.Ports, poe.PortNames, newSwitchPorts(args, poe.Address, client, true))
 	if err != nil {
 		return err
 	}
-	if result != "SUCCESS" {
-		return errors.New(result)
-	}
-
-	statuses, err := requestPoeStatus(args, poe.Address)
-	if err != nil {
+	// the statuses are printed, even when a port doesn't deliver power again
+	statuses, err := poe.cycle(client, ports)
+	if err != nil && statuses == nil {
 		return err
 	}
-	statuses = filter(statuses, func(status PoePortStatus) bool {
-		return slices.Contains(poe.Ports, int(status.PortIndex))
+	printErr := printClientTable(args, client, poe.Address, func(switchName string) table {
+		return poePortStatusTable(switchName, statuses)
 	})
-
-	return nil
-}
-
-func (poe *PoeCyclePowerCommand) cyclePowerGs316EPx(args *GlobalOptions) error {
-	for _, switchPort := range poe.Ports {
-		if switchPort < 1 || switchPort > gs316NoPoePorts {
-			return errors.New(fmt.Sprintf("given port id %d, doesn't fit in range 1..%d", switchPort, gs316NoPoePorts))
-		}
-	}
-
-	_, token, err := readTokenAndModel2GlobalOptions(args, poe.Address)
-	if err != nil {
-		return err
-	}
-	urlStr := fmt.Sprintf("http://%s/iss/specific/poePortConf.html", poe.Address)
-	reqForm := url.Values{}
-	reqForm.Add("Gambit", token)
-	reqForm.Add("TYPE", "resetPoe")
-	reqForm.Add("PoePort", createPortResetPayloadGs316EPx(poe.Ports))
-	result, err := doHttpRequestAndReadResponse(args, http.MethodPost, poe.Address, urlStr, reqForm.Encode())
-	if err != nil {
-		return err
+	if printErr != nil {
+		return printErr
 	}
-	if args.Verbose {
-		fmt.Println(result)
-	}
-	if result != "SUCCESS" {
-		return errors.New(result)
-	}
-
-	statuses, err := requestPoeStatus(args, poe.Address)
-	if err != nil {
-		return err
-	}
-	statuses = filter(statuses, func(status PoePortStatus) bool {
-		return slices.Contains(poe.Ports, int(status.PortIndex))
-	})
-	prettyPrintPoePortStatus(args.OutputFormat, statuses)
-	return nil
+	return err
 }
 
-func createPortResetPayloadGs316EPx(poePorts []int) string {
-	result := strings.Builder{}
-	for i := 0; i < gs316NoPoePorts; i++ {
-		written := false
-		for _, p := range poePorts {
-			if p-1 == i {
-				result.WriteString("1")
-				written = true
-				break
-			}
-		}
-		if !written {
-			result.WriteString("0")
-		}
+// cycle power cycles the ports, with the off-time, and waits for the ports to deliver power again, with --wait
+func (poe *PoeCyclePowerCommand) cycle(client *netgear.Client, ports []int) ([]netgear.PoePortStatus, error) {
+	options := netgear.PoeCycleOptions{OffTime: poe.OffTime}
+	if poe.Wait {
+		options.WaitTimeout = poe.Timeout
 	}
-	return result.String()
+	return client.CyclePoeWithOptions(ports, options)
 }
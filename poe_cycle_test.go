@@ -1,15 +1,19 @@
 package main
 
 import (
+	"testing"
+
 	"github.com/corbym/gocrest/is"
 	"github.com/corbym/gocrest/then"
-	"testing"
+	"github.com/nitram509/ntgrrc/netgear"
 )
 
-func TestPoePortReset(t *testing.T) {
-	s := createPortResetPayloadGs316EPx([]int{3, 5})
-	then.AssertThat(t, s, is.EqualTo("001010000000000"))
+func Test_poe_cycle_rejects_a_non_positive_timeout(t *testing.T) {
+	args, host := loggedInEmulator(t, netgear.GS308EPP)
+	cycle := PoeCyclePowerCommand{Address: host, Ports: portList{"2"}, Wait: true}
+
+	err := cycle.Run(args)
 
-	s = createPortResetPayloadGs316EPx([]int{1})
-	then.AssertThat(t, s, is.EqualTo("100000000000000"))
+	then.AssertThat(t, errorCategoryOf(err), is.EqualTo(categoryInvalidArgument))
+	then.AssertThat(t, err.Error(), is.EqualTo("invalid --timeout 0s, it must be positive"))
 }
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nitram509/ntgrrc/netgear"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type PoeEnergyCommand struct {
+	PoeEnergyShowCommand   PoeEnergyShowCommand   `cmd:"" name:"show" help:"show the recorded PoE energy per switch or per port, and its cost" default:"withargs"`
+	PoeEnergyRecordCommand PoeEnergyRecordCommand `cmd:"" name:"record" help:"sample the PoE power of the ports and add up the energy, until interrupted"`
+}
+
+type PoeEnergyShowCommand struct {
+	Since        string  `help:"show the energy of the last days, e.g. '30d' including today, or since a date, e.g. '2026-10-01'; by default all recorded days" placeholder:"DAYS|DATE"`
+	Tariff       float64 `help:"the price of a kWh, to show the cost" placeholder:"PRICE"`
+	Currency     string  `help:"the currency of the tariff, shown in the header of the cost, e.g. 'EUR'"`
+	Ports        bool    `help:"show the energy per port, instead of the totals per switch"`
+	TableOptions `embed:""`
+}
+
+type PoeEnergyRecordCommand struct {
+	SwitchSelector `embed:""`
+	Interval       time.Duration `help:"how often to sample the PoE power of the ports" default:"1m"`
+	Once           bool          `help:"sample only once, e.g. to be run by cron with the same interval"`
+}
+
+const energyFileName = "energy.json"
+
+// maxEnergyGaps is the number of missed samples in a row, after which the energy isn't added up across the gap,
+// because the power in between is unknown
+const maxEnergyGaps = 3
+
+// energyStore is the recorded energy, which is kept in the token directory, thus the totals survive restarts
+type energyStore struct {
+	// Switches by address
+	Switches map[string]*energySwitch `json:"switches"`
+}
+
+type energySwitch struct {
+	Name  string              `json:"name"`
+	Ports map[int]*energyPort `json:"ports"`
+}
+
+type energyPort struct {
+	Name string `json:"name"`
+	// Days is the energy in Wh per local date, e.g. "2026-10-18"
+	Days map[string]float64 `json:"days"`
+	// SampledAt and PowerInWatt are the last sample, the energy of the next one is added up from
+	SampledAt   time.Time `json:"sampled_at"`
+	PowerInWatt float64   `json:"power_w"`
+}
+
+func energyFilePath(tokenDir string) string {
+	return filepath.Join(dotConfigDirName(tokenDir), energyFileName)
+}
+
+// loadEnergyStore reads the recorded energy; a missing file is an empty store
+func loadEnergyStore(fileName string) (*energyStore, error) {
+	store := &energyStore{Switches: map[string]*energySwitch{}}
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, store)
+	if err != nil {
+		return nil, fmt.Errorf("invalid energy file '%s': %w", fileName, err)
+	}
+	if store.Switches == nil {
+		store.Switches = map[string]*energySwitch{}
+	}
+	return store, nil
+}
+
+// save replaces the file, by writing a new one first, thus an interrupted write doesn't lose the totals
+func (store *energyStore) save(fileName string) error {
+	err := os.MkdirAll(filepath.Dir(fileName), os.ModeDir|0700)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(store)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(fileName+".tmp", data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(fileName+".tmp", fileName)
+}
+
+// sampled adds up the energy of each port since its last sample, as the average power of both samples times
+// the time in between, to the day of the new sample. Samples more than maxGap apart only start a new sum.
+func (store *energyStore) sampled(target switchTarget, statuses []netgear.PoePortStatus, now time.Time, maxGap time.Duration) {
+	sw, ok := store.Switches[target.Address]
+	if !ok {
+		sw = &energySwitch{Ports: map[int]*energyPort{}}
+		store.Switches[target.Address] = sw
+	}
+	sw.Name = target.Name
+	day := now.Format(time.DateOnly)
+	for _, status := range statuses {
+		port, ok := sw.Ports[int(status.PortIndex)]
+		if !ok {
+			port = &energyPort{Days: map[string]float64{}}
+			sw.Ports[int(status.PortIndex)] = port
+		}
+		power := exactFloat64(status.PowerInWatt)
+		elapsed := now.Sub(port.SampledAt)
+		if !port.SampledAt.IsZero() && elapsed > 0 && elapsed <= maxGap {
+			port.Days[day] += (port.PowerInWatt + power) / 2 * elapsed.Hours()
+		}
+		port.Name = status.PortName
+		port.SampledAt = now
+		port.PowerInWatt = power
+	}
+}
+
+// parseSince is the first day of --since, e.g. '30d' are the last 30 days including today, or "" for all days
+func parseSince(since string, now time.Time) (string, error) {
+	if since == "" {
+		return "", nil
+	}
+	if days, ok := strings.CutSuffix(since, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err == nil && n > 0 {
+			return now.AddDate(0, 0, 1-n).Format(time.DateOnly), nil
+		}
+	} else if _, err := time.Parse(time.DateOnly, since); err == nil {
+		return since, nil
+	}
+	return "", newCommandError(categoryInvalidArgument, "invalid --since '%s', use days like '30d' or a date like '2026-10-01'", since)
+}
+
+// poePortEnergyValue is a row for templates, e.g. {{.EnergyInWh}}
+type poePortEnergyValue struct {
+	Switch     string
+	Address    string
+	PortIndex  int
+	PortName   string
+	From       string
+	To         string
+	EnergyInWh float64
+	Cost       *float64
+}
+
+// poeEnergyValue is a row for templates, with the totals of a switch
+type poeEnergyValue struct {
+	Switch     string
+	Address    string
+	Ports      int
+	From       string
+	To         string
+	EnergyInWh float64
+	Cost       *float64
+}
+
+// portTotals are the energy of the ports from the first day on, sorted by switch name and port;
+// ports without energy in that time are left out
+func (store *energyStore) portTotals(firstDay string, tariff float64) []poePortEnergyValue {
+	var totals []poePortEnergyValue
+	for address, sw := range store.Switches {
+		for index, port := range sw.Ports {
+			total := poePortEnergyValue{Switch: sw.Name, Address: address, PortIndex: index, PortName: port.Name}
+			found := false
+			for day, energy := range port.Days {
+				if day < firstDay {
+					continue
+				}
+				total.EnergyInWh += energy
+				if !found || day < total.From {
+					total.From = day
+				}
+				if day > total.To {
+					total.To = day
+				}
+				found = true
+			}
+			if found {
+				total.Cost = energyCost(total.EnergyInWh, tariff)
+				totals = append(totals, total)
+			}
+		}
+	}
+	slices.SortFunc(totals, func(a, b poePortEnergyValue) int {
+		return cmp.Or(cmp.Compare(a.Switch, b.Switch), cmp.Compare(a.Address, b.Address), cmp.Compare(a.PortIndex, b.PortIndex))
+	})
+	return totals
+}
+
+// switchTotals sums up the sorted port totals per switch
+func switchTotals(ports []poePortEnergyValue, tariff float64) []poeEnergyValue {
+	var totals []poeEnergyValue
+	for _, port := range ports {
+		last := len(totals) - 1
+		if last < 0 || totals[last].Address != port.Address {
+			totals = append(totals, poeEnergyValue{Switch: port.Switch, Address: port.Address, From: port.From, To: port.To})
+			last++
+		}
+		total := &totals[last]
+		total.Ports++
+		total.EnergyInWh += port.EnergyInWh
+		if port.From < total.From {
+			total.From = port.From
+		}
+		if port.To > total.To {
+			total.To = port.To
+		}
+	}
+	for i := range totals {
+		totals[i].Cost = energyCost(totals[i].EnergyInWh, tariff)
+	}
+	return totals
+}
+
+// energyCost is the cost of the energy, or nil, which is null in JSON, without a tariff
+func energyCost(energyInWh float64, tariff float64) *float64 {
+	if tariff <= 0 {
+		return nil
+	}
+	cost := math.Round(energyInWh/1000*tariff*10000) / 10000
+	return &cost
+}
+
+func roundWh(energyInWh float64) float64 {
+	return math.Round(energyInWh*1000) / 1000
+}
+
+func kWh(energyInWh float64) float64 {
+	return math.Round(energyInWh) / 1000
+}
+
+func costText(cost *float64) string {
+	if cost == nil {
+		return "unknown"
+	}
+	return fmt.Sprintf("%.2f", *cost)
+}
+
+// costHeader names the currency, when given; without a tariff, the cost is hidden
+func (poe *PoeEnergyShowCommand) costHeader() string {
+	if poe.Currency != "" {
+		return fmt.Sprintf("Cost (%s)", poe.Currency)
+	}
+	return "Cost"
+}
+
+func (poe *PoeEnergyShowCommand) portColumns() []column[poePortEnergyValue] {
+	return []column[poePortEnergyValue]{
+		{name: "switch", influx: influxTag, header: "Switch", value: func(p poePortEnergyValue) any { return p.Switch }},
+		{name: "port_id", influx: influxTag, alias: "port", header: "Port ID", value: func(p poePortEnergyValue) any { return p.PortIndex }},
+		{name: "port_name", influx: influxTag, alias: "name", header: "Port Name", value: func(p poePortEnergyValue) any { return p.PortName }},
+		{name: "from", header: "From", value: func(p poePortEnergyValue) any { return p.From }},
+		{name: "to", header: "To", value: func(p poePortEnergyValue) any { return p.To }},
+		{name: "energy_wh", influx: influxField, hidden: true, header: "Energy (Wh)", value: func(p poePortEnergyValue) any { return roundWh(p.EnergyInWh) },
+			text: func(p poePortEnergyValue) string { return fmt.Sprintf("%.1f", p.EnergyInWh) }},
+		{name: "energy_kwh", influx: influxField, alias: "energy", header: "Energy (kWh)", value: func(p poePortEnergyValue) any { return kWh(p.EnergyInWh) },
+			text: func(p poePortEnergyValue) string { return fmt.Sprintf("%.3f", p.EnergyInWh/1000) }},
+		{name: "cost", influx: influxField, hidden: poe.Tariff <= 0, header: poe.costHeader(), value: func(p poePortEnergyValue) any { return p.Cost },
+			text: func(p poePortEnergyValue) string { return costText(p.Cost) }},
+	}
+}
+
+func (poe *PoeEnergyShowCommand) switchColumns() []column[poeEnergyValue] {
+	return []column[poeEnergyValue]{
+		{name: "switch", influx: influxTag, header: "Switch", value: func(s poeEnergyValue) any { return s.Switch }},
+		{name: "address", hidden: true, header: "Address", value: func(s poeEnergyValue) any { return s.Address }},
+		{name: "ports", header: "Ports", value: func(s poeEnergyValue) any { return s.Ports }},
+		{name: "from", header: "From", value: func(s poeEnergyValue) any { return s.From }},
+		{name: "to", header: "To", value: func(s poeEnergyValue) any { return s.To }},
+		{name: "energy_wh", influx: influxField, hidden: true, header: "Energy (Wh)", value: func(s poeEnergyValue) any { return roundWh(s.EnergyInWh) },
+			text: func(s poeEnergyValue) string { return fmt.Sprintf("%.1f", s.EnergyInWh) }},
+		{name: "energy_kwh", influx: influxField, alias: "energy", header: "Energy (kWh)", value: func(s poeEnergyValue) any { return kWh(s.EnergyInWh) },
+			text: func(s poeEnergyValue) string { return fmt.Sprintf("%.3f", s.EnergyInWh/1000) }},
+		{name: "cost", influx: influxField, hidden: poe.Tariff <= 0, header: poe.costHeader(), value: func(s poeEnergyValue) any { return s.Cost },
+			text: func(s poeEnergyValue) string { return costText(s.Cost) }},
+	}
+}
+
+// table are the totals of the recorded energy, per switch or per port
+func (poe *PoeEnergyShowCommand) table(store *energyStore, now time.Time) (table, error) {
+	firstDay, err := parseSince(poe.Since, now)
+	if err != nil {
+		return table{}, err
+	}
+	if poe.Tariff < 0 {
+		return table{}, newCommandError(categoryInvalidArgument, "invalid --tariff %g, it must not be negative", poe.Tariff)
+	}
+	ports := store.portTotals(firstDay, poe.Tariff)
+	if poe.Ports {
+		return newTable("poe_energy_ports", "", poe.portColumns(), ports, func(port poePortEnergyValue) any { return port }), nil
+	}
+	switches := switchTotals(ports, poe.Tariff)
+	return newTable("poe_energy", "", poe.switchColumns(), switches, func(sw poeEnergyValue) any { return sw }), nil
+}
+
+// Run shows the energy, which "poe energy record" added up; it doesn't query the switches
+func (poe *PoeEnergyShowCommand) Run(args *GlobalOptions) error {
+	store, err := loadEnergyStore(energyFilePath(args.TokenDir))
+	if err != nil {
+		return err
+	}
+	t, err := poe.table(store, time.Now())
+	if err != nil {
+		return err
+	}
+	t, err = poe.apply(t)
+	if err != nil {
+		return err
+	}
+	return printTable(tableOutput(args, ""), t)
+}
+
+// Run samples the PoE power of the switches' ports on the interval, until interrupted, and saves the energy
+// after every sample. Failures are logged, and retried on the next sample.
+func (poe *PoeEnergyRecordCommand) Run(args *GlobalOptions) error {
+	// the interval limits the gaps, which the energy is added up across, with --once, too
+	err := checkInterval(poe.Interval)
+	if err != nil {
+		return err
+	}
+	daemonOptions(args)
+	targets, err := poe.targets(args)
+	if err != nil {
+		return err
+	}
+	fileName := energyFilePath(args.TokenDir)
+	store, err := loadEnergyStore(fileName)
+	if err != nil {
+		return err
+	}
+	recorder := energyRecorder{args: args, clients: newClientCache(), store: store, fileName: fileName, maxGap: maxEnergyGaps * poe.Interval}
+	if poe.Once {
+		return recorder.sample(targets, time.Now())
+	}
+	if !args.Quiet {
+		logEvent("recording the PoE energy of %d switch(es), sampling every %s, to %s", len(targets), poe.Interval, fileName)
+	}
+	ticker := time.NewTicker(poe.Interval)
+	defer ticker.Stop()
+	for {
+		_ = recorder.sample(targets, time.Now())
+		<-ticker.C
+	}
+}
+
+type energyRecorder struct {
+	args     *GlobalOptions
+	clients  *clientCache
+	store    *energyStore
+	fileName string
+	maxGap   time.Duration
+}
+
+// sample queries the switches, one after the other, and saves the energy. It returns an error, when a switch failed.
+func (recorder *energyRecorder) sample(targets []switchTarget, now time.Time) error {
+	failures := 0
+	for _, target := range targets {
+		statuses, err := pollPoeStatus(recorder.args, recorder.clients, target)
+		if err != nil {
+			failures++
+			logEventError(target.Name, err)
+			continue
+		}
+		recorder.store.sampled(target, statuses, now, recorder.maxGap)
+	}
+	err := recorder.store.save(recorder.fileName)
+	if err != nil {
+		logEventError(recorder.fileName, err)
+		return err
+	}
+	if failures > 0 {
+		return newCommandError(categorySwitchesFailed, "%d of %d switches failed", failures, len(targets))
+	}
+	return nil
+}
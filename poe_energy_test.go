@@ -0,0 +1,143 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/ntgrrc/netgear"
+)
+
+func testPowers(watts ...float32) []netgear.PoePortStatus {
+	var statuses []netgear.PoePortStatus
+	for i, power := range watts {
+		statuses = append(statuses, netgear.PoePortStatus{PortIndex: int8(i + 1), PortName: "port-" + string(rune('a'+i)), PowerInWatt: power})
+	}
+	return statuses
+}
+
+func Test_energy_adds_up_the_average_power_and_skips_gaps(t *testing.T) {
+	store := &energyStore{Switches: map[string]*energySwitch{}}
+	target := switchTarget{Name: "office-1", Address: "192.168.0.2"}
+	now := time.Date(2026, 10, 17, 23, 30, 0, 0, time.Local)
+
+	store.sampled(target, testPowers(4, 0), now, 3*time.Hour)
+	store.sampled(target, testPowers(6, 2), now.Add(time.Hour), 3*time.Hour)
+	store.sampled(target, testPowers(6, 2), now.Add(5*time.Hour), 3*time.Hour)
+	store.sampled(target, testPowers(6, 2), now.Add(5*time.Hour+30*time.Minute), 3*time.Hour)
+
+	ports := store.Switches["192.168.0.2"].Ports
+	then.AssertThat(t, ports[1].Days, is.EqualTo(map[string]float64{"2026-10-18": 5 + 3}))
+	then.AssertThat(t, ports[2].Days, is.EqualTo(map[string]float64{"2026-10-18": 1 + 1}))
+	then.AssertThat(t, ports[1].Name, is.EqualTo("port-a"))
+}
+
+func Test_energy_store_survives_restarts(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "state", energyFileName)
+	store, err := loadEnergyStore(fileName)
+	then.AssertThat(t, err, is.Nil())
+	store.sampled(switchTarget{Name: "office-1", Address: "192.168.0.2"}, testPowers(4), time.Now(), time.Hour)
+
+	then.AssertThat(t, store.save(fileName), is.Nil())
+	loaded, err := loadEnergyStore(fileName)
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, loaded.Switches["192.168.0.2"].Ports[1].PowerInWatt, is.EqualTo(4.0))
+	then.AssertThat(t, loaded.Switches["192.168.0.2"].Ports[1].SampledAt.Equal(store.Switches["192.168.0.2"].Ports[1].SampledAt), is.True())
+}
+
+func Test_energy_since(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local)
+	var tests = []struct {
+		since    string
+		expected string
+	}{
+		{"", ""},
+		{"1d", "2026-10-18"},
+		{"30d", "2026-09-19"},
+		{"2026-10-01", "2026-10-01"},
+	}
+	for _, test := range tests {
+		t.Run(test.since, func(t *testing.T) {
+			firstDay, err := parseSince(test.since, now)
+
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, firstDay, is.EqualTo(test.expected))
+		})
+	}
+	for _, since := range []string{"0d", "30", "1w", "2026-13-01"} {
+		_, err := parseSince(since, now)
+		then.AssertThat(t, err.Error(), is.EqualTo("invalid --since '"+since+"', use days like '30d' or a date like '2026-10-01'"))
+	}
+}
+
+func Test_energy_totals_per_port_and_switch_with_cost(t *testing.T) {
+	store := &energyStore{Switches: map[string]*energySwitch{
+		"192.168.0.3": {Name: "office-2", Ports: map[int]*energyPort{
+			1: {Name: "ap-hall", Days: map[string]float64{"2026-10-01": 500}},
+		}},
+		"192.168.0.2": {Name: "office-1", Ports: map[int]*energyPort{
+			3: {Name: "cam-lobby", Days: map[string]float64{"2026-09-30": 1000, "2026-10-01": 120, "2026-10-02": 130}},
+			1: {Name: "ap-lobby", Days: map[string]float64{"2026-10-02": 250}},
+			2: {Name: "idle", Days: map[string]float64{"2026-09-30": 80}},
+		}},
+	}}
+
+	ports := store.portTotals("2026-10-01", 0.3)
+	switches := switchTotals(ports, 0.3)
+
+	cost := func(cost float64) *float64 { return &cost }
+	then.AssertThat(t, ports, is.EqualTo([]poePortEnergyValue{
+		{Switch: "office-1", Address: "192.168.0.2", PortIndex: 1, PortName: "ap-lobby", From: "2026-10-02", To: "2026-10-02", EnergyInWh: 250, Cost: cost(0.075)},
+		{Switch: "office-1", Address: "192.168.0.2", PortIndex: 3, PortName: "cam-lobby", From: "2026-10-01", To: "2026-10-02", EnergyInWh: 250, Cost: cost(0.075)},
+		{Switch: "office-2", Address: "192.168.0.3", PortIndex: 1, PortName: "ap-hall", From: "2026-10-01", To: "2026-10-01", EnergyInWh: 500, Cost: cost(0.15)},
+	}))
+	then.AssertThat(t, switches, is.EqualTo([]poeEnergyValue{
+		{Switch: "office-1", Address: "192.168.0.2", Ports: 2, From: "2026-10-01", To: "2026-10-02", EnergyInWh: 500, Cost: cost(0.15)},
+		{Switch: "office-2", Address: "192.168.0.3", Ports: 1, From: "2026-10-01", To: "2026-10-01", EnergyInWh: 500, Cost: cost(0.15)},
+	}))
+}
+
+func Test_energy_cost_is_hidden_without_tariff(t *testing.T) {
+	store := &energyStore{Switches: map[string]*energySwitch{
+		"192.168.0.2": {Name: "office-1", Ports: map[int]*energyPort{1: {Days: map[string]float64{"2026-10-01": 1234.5}}}},
+	}}
+	show := PoeEnergyShowCommand{}
+
+	energy, err := show.table(store, time.Now())
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, energy.header(), is.EqualTo([]string{"Switch", "Ports", "From", "To", "Energy (kWh)"}))
+	then.AssertThat(t, energy.content(), is.EqualTo([][]string{{"office-1", "1", "2026-10-01", "2026-10-01", "1.234"}}))
+	then.AssertThat(t, energy.rows()[0].values[5:], is.EqualTo([]any{1234.5, 1.235, (*float64)(nil)}))
+}
+
+func Test_energy_recorder_samples_the_switch(t *testing.T) {
+	// setup
+	args, host := loggedInEmulator(t, netgear.GS308EPP)
+	fileName := energyFilePath(args.TokenDir)
+	recorder := energyRecorder{args: args, clients: newClientCache(), store: &energyStore{Switches: map[string]*energySwitch{}}, fileName: fileName, maxGap: time.Hour}
+	targets := []switchTarget{{Name: host, Address: host}}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local)
+
+	// when
+	then.AssertThat(t, recorder.sample(targets, now), is.Nil())
+	then.AssertThat(t, recorder.sample(targets, now.Add(30*time.Minute)), is.Nil())
+
+	// then
+	store, err := loadEnergyStore(fileName)
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, store.Switches[host].Ports[1].Days["2026-10-18"], is.EqualTo(2.9))
+	then.AssertThat(t, store.Switches[host].Ports[2].Days["2026-10-18"], is.EqualTo(0.0))
+}
+
+func Test_energy_record_rejects_a_non_positive_interval(t *testing.T) {
+	record := PoeEnergyRecordCommand{SwitchSelector: SwitchSelector{Address: []string{"192.168.0.2"}}, Once: true}
+
+	err := record.Run(&GlobalOptions{TokenDir: t.TempDir()})
+
+	then.AssertThat(t, errorCategoryOf(err), is.EqualTo(categoryInvalidArgument))
+	then.AssertThat(t, err.Error(), is.EqualTo("invalid --interval 0s, it must be positive"))
+}
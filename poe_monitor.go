@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"github.com/nitram509/ntgrrc/netgear"
+	"strings"
+	"time"
+)
+
+type PoeMonitorCommand struct {
+	SwitchSelector `embed:""`
+	Interval       time.Duration `help:"how often to poll the PoE status of the switches" default:"30s"`
+}
+
+// Run polls the PoE status of the switches on the interval, until interrupted, and notifies the sinks from the config file,
+// when a port's PoE fault starts, changes or ends.
+func (poe *PoeMonitorCommand) Run(args *GlobalOptions) error {
+	err := checkInterval(poe.Interval)
+	if err != nil {
+		return err
+	}
+	daemonOptions(args)
+	targets, err := poe.targets(args)
+	if err != nil {
+		return err
+	}
+	monitor := newPoeMonitor(args, args.configFile().Notifications)
+	if !args.Quiet {
+		logEvent("monitoring the PoE status of %d switch(es), polling every %s, notifying %d sink(s)", len(targets), poe.Interval, len(monitor.sinks))
+	}
+	ticker := time.NewTicker(poe.Interval)
+	defer ticker.Stop()
+	for {
+		monitor.poll(targets, time.Now())
+		<-ticker.C
+	}
+}
+
+type poeMonitor struct {
+	args    *GlobalOptions
+	clients *clientCache
+	sinks   []NotificationSink
+	// ports are the states of the ports per switch address and port number
+	ports map[string]map[int]*poePortState
+}
+
+func newPoeMonitor(args *GlobalOptions, sinks []NotificationSink) *poeMonitor {
+	return &poeMonitor{args: args, clients: newClientCache(), sinks: sinks, ports: map[string]map[int]*poePortState{}}
+}
+
+// poePortState is, what the monitor knows about a port from the previous polls
+type poePortState struct {
+	// delivered is true, when the port delivered power before; a port, which was disabled, didn't stop delivering power
+	delivered bool
+	problem   string
+}
+
+// problemOf is the port's PoE fault, or "" when there is none
+func (state *poePortState) problemOf(status netgear.PoePortStatus) string {
+	switch {
+	case status.ErrorStatus != "" && !strings.EqualFold(status.ErrorStatus, "No Error"):
+		return fmt.Sprintf("error status '%s'", status.ErrorStatus)
+	case strings.Contains(strings.ToLower(status.PoePortStatus), "fault"):
+		return fmt.Sprintf("status '%s'", status.PoePortStatus)
+	case state.delivered && status.PoePortStatus != netgear.PoeDeliveringPower && !strings.EqualFold(status.PoePortStatus, "Disabled"):
+		return "stopped delivering power"
+	}
+	return ""
+}
+
+// observed records the port's status of a poll, and returns the event, when a fault started, changed or ended;
+// a fault, which persists, is reported only once
+func (state *poePortState) observed(status netgear.PoePortStatus) (event string, problem string) {
+	problem = state.problemOf(status)
+	if problem == "" {
+		state.delivered = status.PoePortStatus == netgear.PoeDeliveringPower
+	}
+	previous := state.problem
+	state.problem = problem
+	switch {
+	case problem == previous:
+		return "", ""
+	case problem == "":
+		return poeEventRecovered, previous
+	default:
+		return poeEventFault, problem
+	}
+}
+
+// poll queries the switches, one after the other, and notifies the sinks of the events
+func (monitor *poeMonitor) poll(targets []switchTarget, now time.Time) {
+	for _, target := range targets {
+		statuses, err := pollPoeStatus(monitor.args, monitor.clients, target)
+		if err != nil {
+			logEventError(target.Name, err)
+			continue
+		}
+		for _, event := range monitor.observed(target, statuses, now) {
+			logEvent("%s", event.Message)
+			monitor.notify(event)
+		}
+	}
+}
+
+// pollPoeStatus queries the PoE status of a switch for long-running commands, which keep the clients in the cache
+func pollPoeStatus(args *GlobalOptions, clients *clientCache, target switchTarget) ([]netgear.PoePortStatus, error) {
+	client, switchArgs, created, err := clients.client(args, target)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := client.PoeStatus()
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		_ = touchToken(switchArgs, target.Address)
+	}
+	return statuses, nil
+}
+
+// observed records the statuses of a switch's ports, and returns the events
+func (monitor *poeMonitor) observed(target switchTarget, statuses []netgear.PoePortStatus, now time.Time) []poeEvent {
+	ports, ok := monitor.ports[target.Address]
+	if !ok {
+		ports = map[int]*poePortState{}
+		monitor.ports[target.Address] = ports
+	}
+	var events []poeEvent
+	for _, status := range statuses {
+		state, ok := ports[int(status.PortIndex)]
+		if !ok {
+			state = &poePortState{}
+			ports[int(status.PortIndex)] = state
+		}
+		kind, problem := state.observed(status)
+		if kind == "" {
+			continue
+		}
+		event := poeEvent{
+			Time:        now,
+			Event:       kind,
+			Switch:      target.Name,
+			Address:     target.Address,
+			Port:        int(status.PortIndex),
+			PortName:    status.PortName,
+			Status:      status.PoePortStatus,
+			ErrorStatus: status.ErrorStatus,
+			Problem:     problem,
+		}
+		event.Message = event.describe()
+		events = append(events, event)
+	}
+	return events
+}
+
+func (event *poeEvent) describe() string {
+	port := fmt.Sprintf("%s port %d", event.Switch, event.Port)
+	if event.PortName != "" {
+		port += fmt.Sprintf(" '%s'", event.PortName)
+	}
+	if event.Event == poeEventRecovered {
+		return fmt.Sprintf("%s: PoE recovered from %s, status '%s'", port, event.Problem, event.Status)
+	}
+	return fmt.Sprintf("%s: PoE fault, %s", port, event.Problem)
+}
+
+// notify sends the event to all sinks; failures are logged
+func (monitor *poeMonitor) notify(event poeEvent) {
+	for _, sink := range monitor.sinks {
+		err := sink.notify(event)
+		if err != nil {
+			logEventError(sink.Name, fmt.Errorf("notification failed: %w", err))
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/ntgrrc/netgear"
+)
+
+func Test_poe_port_state_reports_faults_once_and_recoveries(t *testing.T) {
+	state := poePortState{}
+	delivering := netgear.PoePortStatus{PoePortStatus: "Delivering Power", ErrorStatus: "No Error"}
+	searching := netgear.PoePortStatus{PoePortStatus: "Searching", ErrorStatus: "No Error"}
+	disabled := netgear.PoePortStatus{PoePortStatus: "Disabled", ErrorStatus: "No Error"}
+	overload := netgear.PoePortStatus{PoePortStatus: "Fault", ErrorStatus: "Overload"}
+
+	expect := func(status netgear.PoePortStatus, event string, problem string) {
+		actualEvent, actualProblem := state.observed(status)
+		then.AssertThat(t, actualEvent, is.EqualTo(event))
+		then.AssertThat(t, actualProblem, is.EqualTo(problem))
+	}
+	expect(searching, "", "")
+	expect(delivering, "", "")
+	expect(overload, poeEventFault, "error status 'Overload'")
+	expect(overload, "", "")
+	expect(delivering, poeEventRecovered, "error status 'Overload'")
+	expect(searching, poeEventFault, "stopped delivering power")
+	expect(searching, "", "")
+	expect(disabled, poeEventRecovered, "stopped delivering power")
+	expect(searching, "", "")
+}
+
+func Test_poe_monitor_notifies_the_webhook_of_a_fault_and_the_recovery(t *testing.T) {
+	// setup
+	sw, args, host := loggedInEmulatorSwitch(t, netgear.GS308EPP)
+	var events []poeEvent
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event := poeEvent{}
+		then.AssertThat(t, json.NewDecoder(r.Body).Decode(&event), is.Nil())
+		events = append(events, event)
+	}))
+	defer webhook.Close()
+	monitor := newPoeMonitor(args, []NotificationSink{{Name: "chat", Webhook: webhook.URL}})
+	targets := []switchTarget{{Name: host, Address: host}}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	// when
+	monitor.poll(targets, now)
+	sw.SetPoeFault(1, "Overload")
+	monitor.poll(targets, now)
+	monitor.poll(targets, now)
+	sw.SetPoeFault(1, "")
+	monitor.poll(targets, now)
+
+	// then
+	then.AssertThat(t, len(events), is.EqualTo(2))
+	then.AssertThat(t, events[0], is.EqualTo(poeEvent{
+		Time:        now,
+		Event:       poeEventFault,
+		Switch:      host,
+		Address:     host,
+		Port:        1,
+		Status:      "Fault",
+		ErrorStatus: "Overload",
+		Problem:     "error status 'Overload'",
+		Message:     host + " port 1: PoE fault, error status 'Overload'",
+	}))
+	then.AssertThat(t, events[1].Event, is.EqualTo(poeEventRecovered))
+	then.AssertThat(t, events[1].Message, is.EqualTo(host+" port 1: PoE recovered from error status 'Overload', status 'Delivering Power'"))
+}
+
+func Test_poe_monitor_rejects_a_non_positive_interval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		monitor := PoeMonitorCommand{SwitchSelector: SwitchSelector{Address: []string{"192.168.0.2"}}, Interval: interval}
+
+		err := monitor.Run(&GlobalOptions{})
+
+		then.AssertThat(t, errorCategoryOf(err), is.EqualTo(categoryInvalidArgument))
+		then.AssertThat(t, err.Error(), is.EqualTo("invalid --interval "+interval.String()+", it must be positive"))
+	}
+}
@@ -4,149 +4,57 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/corbym/gocrest/has"
 	"github.com/corbym/gocrest/is"
 	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/ntgrrc/netgear"
 )
 
-func TestFindPortConfigInHtml(t *testing.T) {
-	tests := []struct {
-		model                  string
-		fileName               string
-		expectedSettingsLength int
-		expectedPortIndex      string
-		expectedPort0Pwr       bool
-		expectedPort1Pwr       bool
-		expectedPwrMode        string
-		expectedPortPrio       string
-		expectedLimitType      string
-		expectedPwrLimit       string
-		expectedDetecType      string
-		expectedPortName       string
-	}{
-		{
-			model:                  "GS305EP",
-			fileName:               "PoEPortConfig.cgi.html",
-			expectedSettingsLength: 4,
-			expectedPortIndex:      "",
-			expectedPort0Pwr:       false,
-			expectedPort1Pwr:       true,
-			expectedPwrMode:        "3",
-			expectedPortPrio:       "0",
-			expectedLimitType:      "2",
-			expectedPwrLimit:       "30.0",
-			expectedDetecType:      "2",
-			expectedPortName:       "link to - sw128 ",
-		},
-		{
-			model:                  "GS308EPP",
-			fileName:               "PoEPortConfig.cgi.html",
-			expectedSettingsLength: 8,
-			expectedPortIndex:      "",
-			expectedPort0Pwr:       false,
-			expectedPort1Pwr:       false,
-			expectedPwrMode:        "3",
-			expectedPortPrio:       "0",
-			expectedLimitType:      "2",
-			expectedPwrLimit:       "30.0",
-			expectedDetecType:      "2",
-			expectedPortName:       "",
-		},
-		{
-			model:                  "GS316EP",
-			fileName:               "poePortConf.html",
-			expectedSettingsLength: gs316NoPoePorts,
-			expectedPortIndex:      "",
-			expectedPort0Pwr:       false,
-			expectedPort1Pwr:       true,
-			expectedPwrMode:        "802.3at",
-			expectedPortPrio:       "Low",
-			expectedLimitType:      "User",
-			expectedPwrLimit:       "30.0",
-			expectedDetecType:      "IEEE802",
-			expectedPortName:       "AGER 31 SUR Tech",
-		},
-	}
-	for _, test := range tests {
-		t.Run(test.model, func(t *testing.T) {
-			// from type inference, settings is of type []PoePortSetting
-			settings, err := findPoePortConfInHtml(NetgearModel(test.model), strings.NewReader(loadTestFile(test.model, test.fileName)))
-
-			then.AssertThat(t, err, is.Nil())
-			then.AssertThat(t, settings, has.Length[PoePortSetting](test.expectedSettingsLength))
-
-			setting := settings[0]
-			then.AssertThat(t, setting.PortIndex, is.EqualTo(int8(1)))
-			then.AssertThat(t, setting.PortPwr, is.EqualTo(test.expectedPort0Pwr))
-			then.AssertThat(t, setting.PwrMode, is.EqualTo(test.expectedPwrMode))
-			then.AssertThat(t, setting.PortPrio, is.EqualTo(test.expectedPortPrio))
-			then.AssertThat(t, setting.LimitType, is.EqualTo(test.expectedLimitType))
-			then.AssertThat(t, setting.PwrLimit, is.EqualTo(test.expectedPwrLimit))
-			then.AssertThat(t, setting.DetecType, is.EqualTo(test.expectedDetecType))
-
-			setting = settings[1]
-			then.AssertThat(t, setting.PortPwr, is.EqualTo(test.expectedPort1Pwr))
-
-			// Tests that the space is not removed if the user has deliberately added it
-			then.AssertThat(t, setting.PortName, is.EqualTo(test.expectedPortName))
-		})
-	}
+var testPoePortSettings = []netgear.PoePortSetting{
+	{
+		PortIndex:    1,
+		PortName:     "link to - sw128 ",
+		PortPwr:      false,
+		PwrMode:      "802.3at",
+		PortPrio:     "low",
+		LimitType:    "user",
+		PwrLimit:     "30.0",
+		DetecType:    "IEEE 802",
+		LongerDetect: "disable",
+	},
+	{
+		PortIndex:    2,
+		PortPwr:      true,
+		PwrMode:      "802.3at",
+		PortPrio:     "low",
+		LimitType:    "user",
+		PwrLimit:     "15.4",
+		MinPwrLimit:  3.0,
+		MaxPwrLimit:  30.0,
+		PwrLimitStep: 0.2,
+		DetecType:    "IEEE 802",
+		LongerDetect: "enable",
+	},
 }
 
-func TestPrettyPrintSettings(t *testing.T) {
-	tests := []struct {
-		model       string
-		fileName    string
-		expectedVal int
-	}{
-		{
-			model:       "GS305EP",
-			fileName:    "PoEPortConfig.cgi.html",
-			expectedVal: 4,
-		},
-		{
-			model:       "GS308EPP",
-			fileName:    "PoEPortConfig.cgi.html",
-			expectedVal: 8,
-		},
-	}
-	for _, test := range tests {
-		t.Run(test.model, func(t *testing.T) {
-			settings, err := findPoePortConfInHtml(NetgearModel(test.model), strings.NewReader(loadTestFile(test.model, test.fileName)))
+func TestPrintMarkdownSettings(t *testing.T) {
+	settings := poePortSettingsTable("", testPoePortSettings)
 
-			then.AssertThat(t, err, is.Nil())
-			then.AssertThat(t, settings, has.Length[PoePortSetting](test.expectedVal))
+	markdown := markdownTable(settings.header(), settings.content(), nil)
 
-			prettyPrintPoePortSettings(NetgearModel(test.model), MarkdownFormat, settings)
-		})
-	}
+	then.AssertThat(t, markdown, is.EqualTo(""+
+		"| Port ID | Port Name        | Port Power | Mode    | Priority | Limit Type | Limit (W) | Max Limit (W) | Type     | Longer Detection Time |\n"+
+		"|---------|------------------|------------|---------|----------|------------|-----------|---------------|----------|-----------------------|\n"+
+		"| 1       | link to - sw128  | disabled   | 802.3at | low      | user       | 30.0      | unknown       | IEEE 802 | disable               |\n"+
+		"| 2       |                  | enabled    | 802.3at | low      | user       | 15.4      | 30.0          | IEEE 802 | enable                |\n"))
 }
 
-func TestPrettyPrintJsonSettings(t *testing.T) {
-	tests := []struct {
-		model       string
-		fileName    string
-		expectedVal int
-	}{
-		{
-			model:       "GS305EP",
-			fileName:    "PoEPortConfig.cgi.html",
-			expectedVal: 4,
-		},
-		{
-			model:       "GS308EPP",
-			fileName:    "PoEPortConfig.cgi.html",
-			expectedVal: 8,
-		},
-	}
-	for _, test := range tests {
-		t.Run(test.model, func(t *testing.T) {
-			settings, err := findPoePortConfInHtml(NetgearModel(test.model), strings.NewReader(loadTestFile(test.model, test.fileName)))
+func TestPrintJsonSettings(t *testing.T) {
+	sb := strings.Builder{}
 
-			then.AssertThat(t, err, is.Nil())
-			then.AssertThat(t, settings, has.Length[PoePortSetting](test.expectedVal))
+	err := writeJsonDocument(&sb, jsonDocument{table: poePortSettingsTable("", testPoePortSettings)})
 
-			prettyPrintPoePortSettings(NetgearModel(test.model), JsonFormat, settings)
-		})
-	}
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, sb.String(), is.EqualTo(`{"schema_version":1,"poe_settings":[`+
+		`{"port_id":1,"port_name":"link to - sw128 ","port_power":false,"mode":"802.3at","priority":"low","limit_type":"user","limit_w":30,"max_limit_w":null,"detection_type":"IEEE 802","longer_detection_time":false},`+
+		`{"port_id":2,"port_name":"","port_power":true,"mode":"802.3at","priority":"low","limit_type":"user","limit_w":15.4,"max_limit_w":30,"detection_type":"IEEE 802","longer_detection_time":true}]}`+"\n"))
 }
@@ -1,198 +1,63 @@
 package main
 
 import (
-	"errors"
 	"fmt"
-	"github.com/PuerkitoBio/goquery"
-	"io"
-	"strconv"
-	"strings"
+	"github.com/nitram509/ntgrrc/netgear"
 )
 
-type PoePortStatus struct {
-	PortIndex            int8
-	PortName             string
-	PoePowerClass        string
-	PoePortStatus        string
-	ErrorStatus          string
-	VoltageInVolt        int32
-	CurrentInMilliAmps   int32
-	PowerInWatt          float32
-	TemperatureInCelsius int32
-}
-
 type PoeCommand struct {
 	PoeStatusCommand       PoeStatusCommand       `cmd:"" name:"status" help:"show current PoE status for all ports" default:"1"`
 	PoeShowSettingsCommand PoeShowSettingsCommand `cmd:"" name:"settings" help:"show current PoE settings for all ports"`
 	PoeSetPowerCommand     PoeSetConfigCommand    `cmd:"" name:"set" help:"set new PoE settings per each PORT number"`
 	PoeCyclePowerCommand   PoeCyclePowerCommand   `cmd:"" name:"cycle" help:"power cycle one or more PoE ports"`
+	PoeBudgetCommand       PoeBudgetCommand       `cmd:"" name:"budget" help:"show the PoE power budget from the data sheet or the config file, the consumption and the headroom"`
+	PoeWatchdogCommand     PoeWatchdogCommand     `cmd:"" name:"watchdog" help:"check the devices behind ports and power cycle the ports of hung devices, according to the watchdogs from the config file"`
+	PoeMonitorCommand      PoeMonitorCommand      `cmd:"" name:"monitor" help:"watch the PoE status of the ports and notify about faults and recoveries, by the notifications from the config file"`
+	PoeEnergyCommand       PoeEnergyCommand       `cmd:"" name:"energy" help:"record the PoE energy per port, and show the totals and their cost"`
 }
 
 type PoeStatusCommand struct {
-	Address string `required:"" help:"the Netgear switch's IP address or host name to connect to" short:"a"`
+	SwitchSelector `embed:""`
+	Parallel       int `help:"maximum number of switches to query at the same time" default:"4"`
+	TableOptions   `embed:""`
+	WatchOptions   `embed:""`
 }
 
 func (poe *PoeStatusCommand) Run(args *GlobalOptions) error {
-	statuses, err := requestPoeStatus(args, poe.Address)
-	if err != nil {
-		return err
-	}
-	prettyPrintPoePortStatus(args.OutputFormat, statuses)
-	return nil
-
-}
-
-func requestPoeStatus(args *GlobalOptions, address string) ([]PoePortStatus, error) {
-	var result []PoePortStatus
-	statusPage, err := requestPoePortStatusPage(args, address)
-	if err != nil {
-		return result, err
-	}
-	if checkIsLoginRequired(statusPage) {
-		return result, errors.New("no content. please, (re-)login first")
+	query := func(client *netgear.Client, switchName string) (table, error) {
+		statuses, err := client.PoeStatus()
+		if err != nil {
+			return table{}, err
+		}
+		return poePortStatusTable(switchName, statuses), nil
 	}
-	result, err = findPortStatusInHtml(args.model, strings.NewReader(statusPage))
-	if err != nil {
-		return result, err
+	if poe.Watch > 0 {
+		return poe.watchAll(args, poe.Parallel, &poe.TableOptions, poe.Watch, "poe_status", query)
 	}
-	return result, nil
+	return poe.queryAll(args, poe.Parallel, &poe.TableOptions, "poe_status", query)
 }
 
-func prettyPrintPoePortStatus(format OutputFormat, statuses []PoePortStatus) {
-	var header = []string{"Port ID", "Port Name", "Status", "PortPwr class", "Voltage (V)", "Current (mA)", "PortPwr (W)", "Temp. (°C)", "Error status"}
-	var content [][]string
-	for _, status := range statuses {
-		var row []string
-		row = append(row, fmt.Sprintf("%d", status.PortIndex))
-		row = append(row, status.PortName)
-		row = append(row, status.PoePortStatus)
-		row = append(row, status.PoePowerClass)
-		row = append(row, fmt.Sprintf("%d", status.VoltageInVolt))
-		row = append(row, fmt.Sprintf("%d", status.CurrentInMilliAmps))
-		row = append(row, fmt.Sprintf("%.2f", status.PowerInWatt))
-		row = append(row, fmt.Sprintf("%d", status.TemperatureInCelsius))
-		row = append(row, status.ErrorStatus)
-		content = append(content, row)
-	}
-	switch format {
-	case MarkdownFormat:
-		printMarkdownTable(header, content)
-	case JsonFormat:
-		printJsonDataTable("poe_status", header, content)
-	default:
-		panic("not implemented format: " + format)
-	}
+// poePortStatusValue is a row for templates, e.g. {{.Switch}}, with the fields of netgear.PoePortStatus
+type poePortStatusValue struct {
+	Switch string
+	netgear.PoePortStatus
 }
 
-func requestPoePortStatusPage(args *GlobalOptions, host string) (string, error) {
-	model, _, err := readTokenAndModel2GlobalOptions(args, host)
-	if err != nil {
-		return "", err
-	}
-	if isModel30x(model) {
-		url := fmt.Sprintf("http://%s/getPoePortStatus.cgi", host)
-		return requestPage(args, host, url)
-	}
-	if isModel316(model) {
-		url := fmt.Sprintf("http://%s/iss/specific/poePortStatus.html?GetData=TRUE", host)
-		return requestPage(args, host, url)
-	}
-	panic("model not supported")
+var poePortStatusColumns = []column[netgear.PoePortStatus]{
+	{name: "port_id", influx: influxTag, alias: "port", header: "Port ID", value: func(s netgear.PoePortStatus) any { return s.PortIndex }},
+	{name: "port_name", influx: influxTag, alias: "name", header: "Port Name", value: func(s netgear.PoePortStatus) any { return s.PortName }},
+	{name: "status", header: "Status", value: func(s netgear.PoePortStatus) any { return s.PoePortStatus }},
+	{name: "power_class", alias: "class", header: "PortPwr class", value: func(s netgear.PoePortStatus) any { return s.PoePowerClass }},
+	{name: "voltage_v", influx: influxField, alias: "voltage", header: "Voltage (V)", value: func(s netgear.PoePortStatus) any { return s.VoltageInVolt }},
+	{name: "current_ma", influx: influxField, alias: "current", header: "Current (mA)", value: func(s netgear.PoePortStatus) any { return s.CurrentInMilliAmps }},
+	{name: "power_w", influx: influxField, alias: "power", header: "PortPwr (W)", value: func(s netgear.PoePortStatus) any { return s.PowerInWatt },
+		text: func(s netgear.PoePortStatus) string { return fmt.Sprintf("%.2f", s.PowerInWatt) }},
+	{name: "temperature_c", influx: influxField, alias: "temperature", header: "Temp. (°C)", value: func(s netgear.PoePortStatus) any { return s.TemperatureInCelsius }},
+	{name: "error_status", alias: "error", header: "Error status", value: func(s netgear.PoePortStatus) any { return s.ErrorStatus }},
 }
 
-func findPortStatusInHtml(model NetgearModel, reader io.Reader) ([]PoePortStatus, error) {
-	if isModel30x(model) {
-		return findPortStatusInGs30xEPxHtml(reader)
-	}
-	if isModel316(model) {
-		return findPortStatusInGs316EPxHtml(reader)
-	}
-	panic("model not supported")
-}
-
-func findPortStatusInGs30xEPxHtml(reader io.Reader) ([]PoePortStatus, error) {
-	doc, err := goquery.NewDocumentFromReader(reader)
-	if err != nil {
-		return nil, err
-	}
-
-	var statuses []PoePortStatus
-	doc.Find("li.poePortStatusListItem").Each(func(i int, s *goquery.Selection) {
-		stat := PoePortStatus{}
-
-		id, _ := s.Find("input[type=hidden].port").Attr("value")
-		var id64, _ = strconv.ParseInt(id, 10, 8)
-		stat.PortIndex = int8(id64)
-
-		portData := s.Find("span.poe-port-index span").Text()
-		_, stat.PortName = parsePortIdAndName(portData)
-
-		stat.PoePortStatus = s.Find("span.poe-power-mode span").Text()
-		powerClassText := s.Find("span.poe-portPwr-width span").Text()
-		stat.PoePowerClass = getPowerClassFromI18nString(powerClassText)
-
-		s.Find("div.poe_port_status div div span").Each(func(i int, s *goquery.Selection) {
-			switch i {
-			case 1:
-				stat.VoltageInVolt = parseInt32(s.Text())
-			case 3:
-				stat.CurrentInMilliAmps = parseInt32(s.Text())
-			case 5:
-				stat.PowerInWatt = parseFloat32(s.Text())
-			case 7:
-				stat.TemperatureInCelsius = parseInt32(s.Text())
-			case 9:
-				stat.ErrorStatus = strings.TrimSpace(s.Text())
-			}
-		})
-		statuses = append(statuses, stat)
-	})
-
-	return statuses, nil
-}
-
-func findPortStatusInGs316EPxHtml(reader io.Reader) ([]PoePortStatus, error) {
-	doc, err := goquery.NewDocumentFromReader(reader)
-	if err != nil {
-		return nil, err
-	}
-
-	var statuses []PoePortStatus
-	doc.Find("div.port-wrap").Each(func(i int, s *goquery.Selection) {
-		stat := PoePortStatus{}
-
-		stat.PortIndex, stat.PortName = parsePortIdAndName(s.Find("span.port-number").Text())
-		stat.PoePortStatus = s.Find("span.Status-text").Text()
-		stat.PoePowerClass = getPowerClassFromI18nString(s.Find("span.Class-text").Text())
-		stat.VoltageInVolt = parseInt32(s.Find("p.OutputVoltage-text").Text())
-		stat.CurrentInMilliAmps = parseInt32(s.Find("p.OutputCurrent-text").Text())
-		stat.PowerInWatt = parseFloat32(s.Find("p.OutputPower-text").Text())
-		stat.TemperatureInCelsius = parseInt32(s.Find("p.Temperature-text").Text())
-		stat.ErrorStatus = s.Find("p.Fault-Status-text").Text()
-		statuses = append(statuses, stat)
+func poePortStatusTable(switchName string, statuses []netgear.PoePortStatus) table {
+	return newTable("poe_status", switchName, poePortStatusColumns, statuses, func(status netgear.PoePortStatus) any {
+		return poePortStatusValue{Switch: switchName, PoePortStatus: status}
 	})
-
-	return statuses, nil
-}
-
-// getPowerClassFromI18nString parses the POE power class from a string, like e.g. "ml003@0@"
-func getPowerClassFromI18nString(class string) string {
-	split := strings.Split(class, "@")
-	if len(split) > 1 {
-		return split[1]
-	}
-	return ""
-}
-
-// parsePortIdAndName parses the port number and port name on the status page
-func parsePortIdAndName(str string) (int8, string) {
-	str = strings.ReplaceAll(str, "\u00a0", " ")
-	index := strings.Index(str, " - ")
-	if index >= 0 {
-		portId, _ := strconv.ParseInt(str[:index], 10, 8)
-		return int8(portId), strings.TrimSpace(str[index+3:])
-	}
-
-	portId, _ := strconv.ParseInt(str, 10, 8)
-	return int8(portId), ""
 }
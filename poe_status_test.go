@@ -4,156 +4,50 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/corbym/gocrest/has"
 	"github.com/corbym/gocrest/is"
 	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/ntgrrc/netgear"
 )
 
-func TestFindPortStatusInHtml(t *testing.T) {
-	tests := []struct {
-		model                        string
-		fileName                     string
-		expectedNumberOfStatuses     int
-		expectedPoePowerClass        string
-		expectedPoePortStatus        string
-		expectedVoltageInVolt        int
-		expectedCurrentInMilliAmps   int
-		expectedPowerInWatt          float32
-		expectedTemperatureInCelsius int
-		expectedErrorStatus          string
-		expectedPortName             string
-	}{
-		{
-			model:                        "GS305EP",
-			fileName:                     "getPoePortStatus.cgi.html",
-			expectedNumberOfStatuses:     4,
-			expectedPoePowerClass:        "0",
-			expectedPoePortStatus:        "Delivering Power",
-			expectedVoltageInVolt:        53,
-			expectedCurrentInMilliAmps:   82,
-			expectedPowerInWatt:          4.4,
-			expectedTemperatureInCelsius: 30,
-			expectedErrorStatus:          "No Error",
-			expectedPortName:             "a network device",
-		},
-		{
-			model:                        "GS308EPP",
-			fileName:                     "getPoePortStatus.cgi.html",
-			expectedNumberOfStatuses:     8,
-			expectedPoePowerClass:        "4",
-			expectedPoePortStatus:        "Delivering Power",
-			expectedVoltageInVolt:        53,
-			expectedCurrentInMilliAmps:   109,
-			expectedPowerInWatt:          5.8,
-			expectedTemperatureInCelsius: 33,
-			expectedErrorStatus:          "No Error",
-			expectedPortName:             "",
-		},
-		{
-			model:                        "GS316EP",
-			fileName:                     "poePortStatus_GetData_true.html",
-			expectedNumberOfStatuses:     gs316NoPoePorts,
-			expectedPoePowerClass:        "2",
-			expectedPoePortStatus:        "Delivering Power",
-			expectedVoltageInVolt:        54,
-			expectedCurrentInMilliAmps:   22,
-			expectedPowerInWatt:          1.1,
-			expectedTemperatureInCelsius: 23,
-			expectedErrorStatus:          "No Error",
-			expectedPortName:             "AGER 31 SUR Tech",
-		},
-	}
-	for _, test := range tests {
-		t.Run(test.model, func(t *testing.T) {
-			statuses, err := findPortStatusInHtml(NetgearModel(test.model), strings.NewReader(loadTestFile(test.model, test.fileName)))
-
-			then.AssertThat(t, err, is.Nil())
-			then.AssertThat(t, statuses, has.Length[PoePortStatus](test.expectedNumberOfStatuses))
-
-			then.AssertThat(t, statuses[0].PortIndex, is.EqualTo(int8(1)))
-			if len(statuses) > 12 {
-				// only GS316
-				then.AssertThat(t, statuses[12].PortIndex, is.EqualTo(int8(13)))
-			}
-
-			status := statuses[0]
-			then.AssertThat(t, status.PoePowerClass, is.EqualTo(test.expectedPoePowerClass))
-			then.AssertThat(t, status.PoePortStatus, is.EqualTo(test.expectedPoePortStatus))
-			then.AssertThat(t, status.VoltageInVolt, is.EqualTo(int32(test.expectedVoltageInVolt)))
-			then.AssertThat(t, status.CurrentInMilliAmps, is.EqualTo(int32(test.expectedCurrentInMilliAmps)))
-			then.AssertThat(t, status.PowerInWatt, is.EqualTo(test.expectedPowerInWatt))
-			then.AssertThat(t, status.TemperatureInCelsius, is.EqualTo(int32(test.expectedTemperatureInCelsius)))
-			then.AssertThat(t, status.ErrorStatus, is.EqualTo(test.expectedErrorStatus))
-			then.AssertThat(t, status.PortName, is.EqualTo(test.expectedPortName))
-
-		})
-	}
+var testPoePortStatuses = []netgear.PoePortStatus{
+	{
+		PortIndex:            1,
+		PortName:             "Camera",
+		PoePowerClass:        "0",
+		PoePortStatus:        "Delivering Power",
+		ErrorStatus:          "No Error",
+		VoltageInVolt:        53,
+		CurrentInMilliAmps:   82,
+		PowerInWatt:          4.4,
+		TemperatureInCelsius: 30,
+	},
+	{
+		PortIndex:            2,
+		PoePortStatus:        "Searching",
+		ErrorStatus:          "No Error",
+		TemperatureInCelsius: 30,
+	},
 }
 
-func TestPrettyPrintMarkdownStatus(t *testing.T) {
-	tests := []struct {
-		model       string
-		fileName    string
-		expectedVal int
-	}{
-		{
-			model:       "GS305EP",
-			fileName:    "getPoePortStatus.cgi.html",
-			expectedVal: 4,
-		},
-		{
-			model:       "GS308EPP",
-			fileName:    "getPoePortStatus.cgi.html",
-			expectedVal: 8,
-		},
-		{
-			model:       "GS316EP",
-			fileName:    "poePortStatus_GetData_true.html",
-			expectedVal: gs316NoPoePorts,
-		},
-	}
-	for _, test := range tests {
-		t.Run(test.model, func(t *testing.T) {
-			statuses, err := findPortStatusInHtml(NetgearModel(test.model), strings.NewReader(loadTestFile(test.model, test.fileName)))
+func TestPrintMarkdownStatus(t *testing.T) {
+	statuses := poePortStatusTable("", testPoePortStatuses)
 
-			then.AssertThat(t, err, is.Nil())
-			then.AssertThat(t, statuses, has.Length[PoePortStatus](test.expectedVal))
+	markdown := markdownTable(statuses.header(), statuses.content(), nil)
 
-			prettyPrintPoePortStatus(MarkdownFormat, statuses)
-		})
-	}
+	then.AssertThat(t, markdown, is.EqualTo(""+
+		"| Port ID | Port Name | Status           | PortPwr class | Voltage (V) | Current (mA) | PortPwr (W) | Temp. (°C) | Error status |\n"+
+		"|---------|-----------|------------------|---------------|-------------|--------------|-------------|------------|--------------|\n"+
+		"| 1       | Camera    | Delivering Power | 0             | 53          | 82           | 4.40        | 30         | No Error     |\n"+
+		"| 2       |           | Searching        |               | 0           | 0            | 0.00        | 30         | No Error     |\n"))
 }
 
-func TestPrettyPrintJsonStatus(t *testing.T) {
-	tests := []struct {
-		model       string
-		fileName    string
-		expectedVal int
-	}{
-		{
-			model:       "GS305EP",
-			fileName:    "getPoePortStatus.cgi.html",
-			expectedVal: 4,
-		},
-		{
-			model:       "GS308EPP",
-			fileName:    "getPoePortStatus.cgi.html",
-			expectedVal: 8,
-		},
-		{
-			model:       "GS316EP",
-			fileName:    "poePortStatus_GetData_true.html",
-			expectedVal: gs316NoPoePorts,
-		},
-	}
-	for _, test := range tests {
-		t.Run(test.model, func(t *testing.T) {
-			statuses, err := findPortStatusInHtml(NetgearModel(test.model), strings.NewReader(loadTestFile(test.model, test.fileName)))
+func TestPrintJsonStatus(t *testing.T) {
+	sb := strings.Builder{}
 
-			then.AssertThat(t, err, is.Nil())
-			then.AssertThat(t, statuses, has.Length[PoePortStatus](test.expectedVal))
+	err := writeJsonDocument(&sb, jsonDocument{table: poePortStatusTable("", testPoePortStatuses)})
 
-			prettyPrintPoePortStatus(JsonFormat, statuses)
-		})
-	}
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, sb.String(), is.EqualTo(`{"schema_version":1,"poe_status":[`+
+		`{"port_id":1,"port_name":"Camera","status":"Delivering Power","power_class":"0","voltage_v":53,"current_ma":82,"power_w":4.4,"temperature_c":30,"error_status":"No Error"},`+
+		`{"port_id":2,"port_name":"","status":"Searching","power_class":"","voltage_v":0,"current_ma":0,"power_w":0,"temperature_c":30,"error_status":"No Error"}]}`+"\n"))
 }
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"github.com/nitram509/ntgrrc/netgear"
+	"gopkg.in/yaml.v3"
+	"maps"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// portList is the value of --port: port numbers and ranges, e.g. "1-4,7,9-12", "all" ports or the "poe" ports
+type portList []string
+
+// UnmarshalYAML reads the ports of the config file like --port, as a list, e.g. [1-4, 7], or a string, e.g. "1-4,7"
+func (list *portList) UnmarshalYAML(node *yaml.Node) error {
+	var entries []string
+	if node.Kind == yaml.ScalarNode {
+		entries = []string{node.Value}
+	} else if err := node.Decode(&entries); err != nil {
+		return err
+	}
+	*list = nil
+	for _, entry := range entries {
+		*list = append(*list, strings.Split(entry, ",")...)
+	}
+	return nil
+}
+
+// switchPorts are the ports of a switch, which a command selects from; their number and names are requested
+// from the switch only, when needed
+type switchPorts struct {
+	// poe is true for PoE commands, which select from the PoE ports only, so "all" are the PoE ports
+	poe   bool
+	count func() (netgear.PortCount, error)
+	names func() (map[int]string, error)
+}
+
+func newSwitchPorts(args *GlobalOptions, address string, client *netgear.Client, poe bool) switchPorts {
+	ports := switchPorts{poe: poe, count: portCountOf(args, address, client), names: portNames(client)}
+	if poe {
+		ports.names = poePortNames(client)
+	}
+	return ports
+}
+
+// selectPorts are the ports of the port list plus the ports, whose names match the exact names or globs, e.g. 'ap-*'.
+// Ports, which the switch doesn't have, are rejected, before any change is requested.
+// An exact name must be the name of exactly one port, a glob must match at least one port.
+func selectPorts(list portList, patterns []string, ports switchPorts) ([]int, error) {
+	if len(list) == 0 && len(patterns) == 0 {
+		return nil, newCommandError(categoryInvalidArgument, "no ports selected, use --port or --port-name")
+	}
+	var selected []int
+	if len(list) > 0 {
+		count, err := ports.count()
+		if err != nil {
+			return nil, err
+		}
+		selected, err = list.resolve(count, ports.poe)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(patterns) > 0 {
+		portNames, err := ports.names()
+		if err != nil {
+			return nil, err
+		}
+		matches, err := matchPortNames(patterns, portNames)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, matches...)
+	}
+	slices.Sort(selected)
+	return slices.Compact(selected), nil
+}
+
+// resolve expands the port list to port numbers, and checks them against the switch's number of ports,
+// or of PoE ports for PoE commands
+func (list portList) resolve(count netgear.PortCount, poe bool) ([]int, error) {
+	maxPort := count.Ports
+	if poe {
+		maxPort = count.PoePorts
+	}
+	var ports []int
+	for _, entry := range list {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		first, last := 1, maxPort
+		switch entry {
+		case "all":
+		case "poe":
+			last = count.PoePorts
+		default:
+			var ok bool
+			first, last, ok = parsePortRange(entry)
+			if !ok {
+				return nil, newCommandError(categoryInvalidArgument, "invalid port '%s', use port numbers, ranges like '1-4', 'all' or 'poe'", entry)
+			}
+		}
+		for port := first; port <= last; port++ {
+			if port < 1 || port > maxPort {
+				return nil, &netgear.PortOutOfRangeError{Port: port, MaxPort: maxPort}
+			}
+			ports = append(ports, port)
+		}
+	}
+	return ports, nil
+}
+
+// parsePortRange parses a port number or a range of ports, e.g. "1-4", of a port list
+func parsePortRange(entry string) (first int, last int, ok bool) {
+	from, to, isRange := strings.Cut(entry, "-")
+	first, err := strconv.Atoi(from)
+	last = first
+	if err == nil && isRange {
+		last, err = strconv.Atoi(to)
+	}
+	return first, last, err == nil && first <= last
+}
+
+// matchPortNames are the ports, whose names match the exact names or globs
+func matchPortNames(patterns []string, portNames map[int]string) ([]int, error) {
+	var selected []int
+	for _, pattern := range patterns {
+		var matches []int
+		for _, port := range slices.Sorted(maps.Keys(portNames)) {
+			matched, err := path.Match(pattern, portNames[port])
+			if err != nil {
+				return nil, newCommandError(categoryInvalidArgument, "invalid port name glob '%s': %w", pattern, err)
+			}
+			if matched {
+				matches = append(matches, port)
+			}
+		}
+		isGlob := strings.ContainsAny(pattern, `*?[\`)
+		switch {
+		case len(matches) == 0 && isGlob:
+			return nil, newCommandError(categoryInvalidArgument, "no port name matches '%s'", pattern)
+		case len(matches) == 0:
+			return nil, newCommandError(categoryInvalidArgument, "no port is named '%s'", pattern)
+		case len(matches) > 1 && !isGlob:
+			return nil, newCommandError(categoryInvalidArgument, "the port name '%s' is ambiguous, it's the name of ports %s; use --port instead", pattern, joinPorts(matches))
+		}
+		selected = append(selected, matches...)
+	}
+	return selected, nil
+}
+
+// portCountOf is the number of ports of the model from the config file, which is more specific than
+// the detected GS30xEPx family, or else of the switch
+func portCountOf(args *GlobalOptions, address string, client *netgear.Client) func() (netgear.PortCount, error) {
+	return func() (netgear.PortCount, error) {
+		target := args.config.lookupSwitch(address)
+		if target.config != nil {
+			if count, ok := netgear.PortCountOf(target.config.Model); ok {
+				return count, nil
+			}
+		}
+		return client.PortCount()
+	}
+}
+
+// poePortNames are the names of the PoE ports by port number
+func poePortNames(client *netgear.Client) func() (map[int]string, error) {
+	return func() (map[int]string, error) {
+		settings, err := client.PoeSettings()
+		if err != nil {
+			return nil, err
+		}
+		names := map[int]string{}
+		for _, setting := range settings {
+			names[int(setting.PortIndex)] = setting.PortName
+		}
+		return names, nil
+	}
+}
+
+// portNames are the names of all ports by port number
+func portNames(client *netgear.Client) func() (map[int]string, error) {
+	return func() (map[int]string, error) {
+		settings, err := client.PortSettings()
+		if err != nil {
+			return nil, err
+		}
+		names := map[int]string{}
+		for _, setting := range settings {
+			names[int(setting.Index)] = setting.Name
+		}
+		return names, nil
+	}
+}
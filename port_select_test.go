@@ -0,0 +1,90 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/ntgrrc/netgear"
+)
+
+func testSwitchPorts(poe bool) switchPorts {
+	return switchPorts{
+		poe: poe,
+		count: func() (netgear.PortCount, error) {
+			return netgear.PortCount{Ports: 16, PoePorts: 15}, nil
+		},
+		names: func() (map[int]string, error) {
+			return map[int]string{1: "uplink", 2: "ap-hall", 3: "ap-lobby", 4: "cam-lobby", 5: "cam", 6: "cam"}, nil
+		},
+	}
+}
+
+func Test_select_ports_by_ranges(t *testing.T) {
+	var tests = []struct {
+		list     portList
+		poe      bool
+		expected []int
+	}{
+		{portList{"1-4", "7", "9-12"}, true, []int{1, 2, 3, 4, 7, 9, 10, 11, 12}},
+		{portList{"3", "1-3", " 2 "}, true, []int{1, 2, 3}},
+		{portList{"all"}, false, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}},
+		{portList{"ALL"}, true, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}},
+		{portList{"poe"}, false, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}},
+	}
+	for _, test := range tests {
+		t.Run(joinPorts(test.expected), func(t *testing.T) {
+			ports, err := selectPorts(test.list, nil, testSwitchPorts(test.poe))
+
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, ports, is.EqualTo(test.expected))
+		})
+	}
+}
+
+func Test_select_ports_by_exact_names_and_globs(t *testing.T) {
+	ports, err := selectPorts(portList{"1", "3"}, []string{"cam-lobby", "ap-*"}, testSwitchPorts(true))
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, ports, is.EqualTo([]int{1, 2, 3, 4}))
+}
+
+func Test_select_ports_without_names_does_not_request_them(t *testing.T) {
+	ports := testSwitchPorts(true)
+	ports.names = func() (map[int]string, error) {
+		t.Fatal("port names requested")
+		return nil, nil
+	}
+
+	selected, err := selectPorts(portList{"2"}, nil, ports)
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, selected, is.EqualTo([]int{2}))
+}
+
+func Test_select_ports_fails_clearly(t *testing.T) {
+	var tests = []struct {
+		list     portList
+		patterns []string
+		expected string
+	}{
+		{nil, nil, "no ports selected, use --port or --port-name"},
+		{portList{"16"}, nil, "given port id 16, doesn't fit in range 1..15"},
+		{portList{"0-2"}, nil, "given port id 0, doesn't fit in range 1..15"},
+		{portList{"4-1"}, nil, "invalid port '4-1', use port numbers, ranges like '1-4', 'all' or 'poe'"},
+		{portList{"1-"}, nil, "invalid port '1-', use port numbers, ranges like '1-4', 'all' or 'poe'"},
+		{portList{"first"}, nil, "invalid port 'first', use port numbers, ranges like '1-4', 'all' or 'poe'"},
+		{nil, []string{"cam"}, "the port name 'cam' is ambiguous, it's the name of ports 5, 6; use --port instead"},
+		{nil, []string{"cam-hall"}, "no port is named 'cam-hall'"},
+		{nil, []string{"printer-*"}, "no port name matches 'printer-*'"},
+		{nil, []string{"ap-[1"}, "invalid port name glob 'ap-[1': syntax error in pattern"},
+	}
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			_, err := selectPorts(test.list, test.patterns, testSwitchPorts(true))
+
+			then.AssertThat(t, errorCategoryOf(err), is.EqualTo(categoryInvalidArgument))
+			then.AssertThat(t, err.Error(), is.EqualTo(test.expected))
+		})
+	}
+}
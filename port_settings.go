@@ -1,10 +1,8 @@
 package main
 
 import (
-	"errors"
-	"fmt"
-	"github.com/PuerkitoBio/goquery"
-	"io"
+	"github.com/nitram509/ntgrrc/netgear"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -15,170 +13,69 @@ type PortCommand struct {
 }
 
 type PortSettingsCommand struct {
-	Address string `required:"" help:"the Netgear switch's IP address or host name to connect to" short:"a"`
+	SwitchSelector `embed:""`
+	Parallel       int `help:"maximum number of switches to query at the same time" default:"4"`
+	TableOptions   `embed:""`
+	WatchOptions   `embed:""`
 }
 
 func (port *PortSettingsCommand) Run(args *GlobalOptions) error {
-	settings, _, err := requestPortSettings(args, port.Address)
-	if err != nil {
-		return err
-	}
-	prettyPrintPortSettings(args.model, args.OutputFormat, settings)
-	return nil
-}
-
-func requestPortSettings(args *GlobalOptions, host string) (portSettings []PortSetting, hash string, err error) {
-	model, _, err := readTokenAndModel2GlobalOptions(args, host)
-	if err != nil {
-		return portSettings, hash, err
-	}
-
-	var requestUrl string
-	if isModel30x(model) {
-		requestUrl = fmt.Sprintf("http://%s/dashboard.cgi", host)
-	} else if isModel316(model) {
-		requestUrl = fmt.Sprintf("http://%s/iss/specific/dashboard.html", host)
-	} else {
-		panic("model not supported")
-	}
-
-	dashboardData, err := requestPage(args, host, requestUrl)
-	if err != nil {
-		return portSettings, hash, err
-	}
-
-	if checkIsLoginRequired(dashboardData) {
-		return portSettings, hash, errors.New("no content. please, (re-)login first")
-	}
-
-	hash, err = findHashInHtml(model, strings.NewReader(dashboardData))
-	if err != nil {
-		return portSettings, hash, err
-	}
-
-	portSettings, err = findPortSettingsInHtml(model, strings.NewReader(dashboardData))
-
-	if err != nil {
-		return portSettings, hash, err
-	}
-
-	return portSettings, hash, err
-}
-
-func prettyPrintPortSettings(model NetgearModel, format OutputFormat, settings []PortSetting) {
-
-	var header = []string{"Port ID", "Port Name", "Speed", "Ingress Limit", "Egress Limit", "Flow Control", "Port Status", "Link Speed"}
-	var content [][]string
-
-	for _, setting := range settings {
-		var row []string
-		row = append(row, fmt.Sprintf("%d", setting.Index))
-		row = append(row, setting.Name)
-		if isModel30x(model) {
-			setting.Speed = bidiMapLookup(setting.Speed, portSpeedMap)
-		}
-		row = append(row, setting.Speed)
-		if isModel30x(model) {
-			setting.IngressRateLimit = bidiMapLookup(setting.IngressRateLimit, portRateLimitMap)
-		}
-		row = append(row, setting.IngressRateLimit)
-		if isModel30x(model) {
-			setting.EgressRateLimit = bidiMapLookup(setting.EgressRateLimit, portRateLimitMap)
-		}
-		row = append(row, setting.EgressRateLimit)
-		if isModel30x(model) {
-			setting.FlowControl = bidiMapLookup(setting.FlowControl, portFlowControlMap)
+	query := func(client *netgear.Client, switchName string) (table, error) {
+		settings, err := client.PortSettings()
+		if err != nil {
+			return table{}, err
 		}
-		row = append(row, setting.FlowControl)
-		row = append(row, setting.PortStatus)
-		row = append(row, setting.LinkSpeed)
-		content = append(content, row)
+		return portSettingsTable(switchName, settings), nil
 	}
-	switch format {
-	case MarkdownFormat:
-		printMarkdownTable(header, content)
-	case JsonFormat:
-		printJsonDataTable("port_settings", header, content)
-	default:
-		panic("not implemented format: " + format)
+	if port.Watch > 0 {
+		return port.watchAll(args, port.Parallel, &port.TableOptions, port.Watch, "port_settings", query)
 	}
-
+	return port.queryAll(args, port.Parallel, &port.TableOptions, "port_settings", query)
 }
 
-func findPortSettingsInHtml(model NetgearModel, reader io.Reader) ([]PortSetting, error) {
-	if isModel30x(model) {
-		return findPortSettingsInGs30xEPxHtml(reader)
-	}
-	if isModel316(model) {
-		return findPortSettingsInGs316EPxHtml(reader)
-	}
-	panic("model not supported")
+// portSettingValue is a row for templates, e.g. {{.Switch}}, with the fields of netgear.PortSetting
+type portSettingValue struct {
+	Switch string
+	netgear.PortSetting
 }
 
-func findPortSettingsInGs30xEPxHtml(reader io.Reader) (ports []PortSetting, err error) {
-
-	doc, err := goquery.NewDocumentFromReader(reader)
-	if err != nil {
-		return ports, err
-	}
-
-	doc.Find("li.list_item").Each(func(i int, s *goquery.Selection) {
-		portCfg := PortSetting{}
+var portSettingsColumns = []column[netgear.PortSetting]{
+	{name: "port_id", influx: influxTag, alias: "port", header: "Port ID", value: func(s netgear.PortSetting) any { return s.Index }},
+	{name: "port_name", influx: influxTag, alias: "name", header: "Port Name", value: func(s netgear.PortSetting) any { return s.Name }},
+	{name: "speed", header: "Speed", value: func(s netgear.PortSetting) any { return s.Speed }},
+	{name: "ingress_limit", header: "Ingress Limit", value: func(s netgear.PortSetting) any { return s.IngressRateLimit }},
+	{name: "egress_limit", header: "Egress Limit", value: func(s netgear.PortSetting) any { return s.EgressRateLimit }},
+	{name: "flow_control", header: "Flow Control", value: func(s netgear.PortSetting) any { return strings.EqualFold(s.FlowControl, "on") },
+		text: func(s netgear.PortSetting) string { return s.FlowControl }},
+	{name: "port_status", alias: "status", header: "Port Status", value: func(s netgear.PortSetting) any { return s.PortStatus }},
+	{name: "link_up", influx: influxField, header: "Link Up", hidden: true, value: func(s netgear.PortSetting) any { return isPortUp(s.PortStatus) }},
+	{name: "link_speed", header: "Link Speed", value: func(s netgear.PortSetting) any { return s.LinkSpeed }},
+	{name: "link_speed_mbps", influx: influxField, header: "Link Speed (Mbit/s)", hidden: true, value: func(s netgear.PortSetting) any { return int64(linkSpeedInBitsPerSecond(s.LinkSpeed) / 1e6) }},
+}
 
-		id, _ := s.Find("input[type=hidden].port").Attr("value")
-		var id64, _ = strconv.ParseInt(id, 10, 8)
-		portCfg.Index = int8(id64)
-		portCfg.Name, _ = s.Find("input[type=hidden].portName").Attr("value")
-		portCfg.Speed, _ = s.Find("input[type=hidden].Speed").Attr("value")
-		portCfg.IngressRateLimit, _ = s.Find("input[type=hidden].ingressRate").Attr("value")
-		portCfg.EgressRateLimit, _ = s.Find("input[type=hidden].egressRate").Attr("value")
-		portCfg.FlowControl, _ = s.Find("input[type=hidden].flowCtr").Attr("value")
-		portCfg.LinkSpeed, _ = s.Find("input[type=hidden].LinkedSpeed").Attr("value")
-		portCfg.PortStatus = strings.TrimSpace(s.Find("span.pull-right").Text())
-		ports = append(ports, portCfg)
+func portSettingsTable(switchName string, settings []netgear.PortSetting) table {
+	return newTable("port_settings", switchName, portSettingsColumns, settings, func(setting netgear.PortSetting) any {
+		return portSettingValue{Switch: switchName, PortSetting: setting}
 	})
+}
 
-	return ports, nil
+// isPortUp knows the GS30x's 'UP' and the GS316's 'CONNECTED' port status
+func isPortUp(portStatus string) bool {
+	status := strings.ToUpper(portStatus)
+	return status == "UP" || status == "CONNECTED"
 }
 
-func findPortSettingsInGs316EPxHtml(reader io.Reader) (ports []PortSetting, err error) {
+var linkSpeedRegex = regexp.MustCompile(`^(\d+)\s*([MG])`)
 
-	doc, err := goquery.NewDocumentFromReader(reader)
-	if err != nil {
-		return ports, err
+// linkSpeedInBitsPerSecond converts the link speed, e.g. '1000M full' or '100M Half', and 0 for 'No Speed'
+func linkSpeedInBitsPerSecond(linkSpeed string) float64 {
+	match := linkSpeedRegex.FindStringSubmatch(strings.ToUpper(linkSpeed))
+	if match == nil {
+		return 0
 	}
-
-	doc.Find("div.dashboard-port-status").Each(func(i int, s *goquery.Selection) {
-		s.Find("span.port-number").Each(func(i int, selection *goquery.Selection) {
-			ports = append(ports, PortSetting{})
-		})
-
-		s.Find("span.port-number").Each(func(i int, selection *goquery.Selection) {
-			var id64, _ = strconv.ParseInt(strings.TrimSpace(selection.Text()), 10, 8)
-			ports[i].Index = int8(id64)
-		})
-		s.Find("span.port-name span.name").Each(func(i int, selection *goquery.Selection) {
-			ports[i].Name = strings.TrimSpace(selection.Text())
-		})
-		s.Find("p.speed-text").Each(func(i int, selection *goquery.Selection) {
-			ports[i].Speed = strings.TrimSpace(selection.Text())
-		})
-		s.Find("p.ingress-text").Each(func(i int, selection *goquery.Selection) {
-			ports[i].IngressRateLimit = strings.TrimSpace(selection.Text())
-		})
-		s.Find("p.egress-text").Each(func(i int, selection *goquery.Selection) {
-			ports[i].EgressRateLimit = strings.TrimSpace(selection.Text())
-		})
-		s.Find("p.flow-text").Each(func(i int, selection *goquery.Selection) {
-			ports[i].FlowControl = strings.TrimSpace(selection.Text())
-		})
-		s.Find("span.status-on-port").Each(func(i int, selection *goquery.Selection) {
-			ports[i].PortStatus = strings.TrimSpace(selection.Text())
-		})
-		s.Find("p.link-speed-text").Each(func(i int, selection *goquery.Selection) {
-			ports[i].LinkSpeed = strings.TrimSpace(selection.Text())
-		})
-	})
-
-	return ports, nil
+	speed, _ := strconv.ParseFloat(match[1], 64)
+	if match[2] == "G" {
+		return speed * 1e9
+	}
+	return speed * 1e6
 }
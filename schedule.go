@@ -0,0 +1,332 @@
+package main
+
+import (
+	"fmt"
+	"github.com/nitram509/ntgrrc/netgear"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PoeSchedule switches the PoE power of ports during a time window, e.g. off on weekdays from 20:00 to 07:00;
+// outside the window, the ports' PoE power is switched the other way
+type PoeSchedule struct {
+	Name string `yaml:"name"`
+	// Switches are names from the config file or addresses; alternatively, Group selects the switches of a group
+	Switches []string `yaml:"switches"`
+	Group    string   `yaml:"group"`
+	// Ports are port numbers and ranges like for --port, e.g. [1-4, 7], or "all" PoE ports
+	Ports portList `yaml:"ports"`
+	// Power is the PoE power state of the ports during the window [enable, disable]
+	Power string `yaml:"power"`
+	// Days, the window starts on: mon, tue, wed, thu, fri, sat, sun, weekdays or weekends; default is every day
+	Days []string `yaml:"days"`
+	// From and To are the local times of the window, e.g. "20:00"; a window ending before its start ends the next day
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+var scheduleDays = map[string][]time.Weekday{
+	"mon":      {time.Monday},
+	"tue":      {time.Tuesday},
+	"wed":      {time.Wednesday},
+	"thu":      {time.Thursday},
+	"fri":      {time.Friday},
+	"sat":      {time.Saturday},
+	"sun":      {time.Sunday},
+	"weekdays": {time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	"weekends": {time.Saturday, time.Sunday},
+}
+
+func (schedule *PoeSchedule) validate() error {
+	switch {
+	case len(schedule.Switches) == 0 && schedule.Group == "":
+		return fmt.Errorf("has neither switches nor a group")
+	case len(schedule.Ports) == 0:
+		return fmt.Errorf("has no ports")
+	case schedule.Power != "enable" && schedule.Power != "disable":
+		return fmt.Errorf("has an unknown power state '%s', use 'enable' or 'disable'", schedule.Power)
+	}
+	for _, entry := range schedule.Ports {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		first, _, ok := parsePortRange(entry)
+		if entry != "all" && entry != "poe" && (!ok || first < 1) {
+			return fmt.Errorf("has an invalid port '%s', use port numbers, ranges like '1-4', 'all' or 'poe'", entry)
+		}
+	}
+	for _, day := range schedule.Days {
+		if _, ok := scheduleDays[strings.ToLower(day)]; !ok {
+			return fmt.Errorf("has an unknown day '%s', use mon, tue, wed, thu, fri, sat, sun, weekdays or weekends", day)
+		}
+	}
+	for _, clock := range []string{schedule.From, schedule.To} {
+		if _, err := parseClock(clock); err != nil {
+			return err
+		}
+	}
+	if schedule.From == schedule.To {
+		return fmt.Errorf("has a window from %s to %s, which is empty, use different times", schedule.From, schedule.To)
+	}
+	return nil
+}
+
+// parseClock parses the time of the day, e.g. "07:30", into the minutes since midnight
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("has an invalid time '%s', use HH:MM, e.g. '20:00'", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// startsOn is true, when the window starts on the day of the week
+func (schedule *PoeSchedule) startsOn(day time.Weekday) bool {
+	if len(schedule.Days) == 0 {
+		return true
+	}
+	for _, name := range schedule.Days {
+		if slices.Contains(scheduleDays[strings.ToLower(name)], day) {
+			return true
+		}
+	}
+	return false
+}
+
+// isActive is true, when the time is in a window, which started on this or, overnight, on the previous day
+func (schedule *PoeSchedule) isActive(now time.Time) bool {
+	from, _ := parseClock(schedule.From)
+	to, _ := parseClock(schedule.To)
+	minute := now.Hour()*60 + now.Minute()
+	if from < to {
+		return schedule.startsOn(now.Weekday()) && minute >= from && minute < to
+	}
+	yesterday := now.AddDate(0, 0, -1).Weekday()
+	return (schedule.startsOn(now.Weekday()) && minute >= from) || (schedule.startsOn(yesterday) && minute < to)
+}
+
+// expectedPower is the PoE power state of the ports at the time
+func (schedule *PoeSchedule) expectedPower(now time.Time) string {
+	if schedule.isActive(now) {
+		return schedule.Power
+	}
+	if schedule.Power == "enable" {
+		return "disable"
+	}
+	return "enable"
+}
+
+func (schedule *PoeSchedule) targets(args *GlobalOptions) ([]switchTarget, error) {
+	selector := SwitchSelector{Address: schedule.Switches, Group: schedule.Group}
+	return selector.targets(args)
+}
+
+type ScheduleCommand struct {
+	ScheduleListCommand ScheduleListCommand `cmd:"" name:"list" help:"show the PoE schedules from the config file and the power state, they expect now" default:"1"`
+	ScheduleRunCommand  ScheduleRunCommand  `cmd:"" name:"run" help:"switch the PoE power of ports according to the schedules from the config file, until interrupted"`
+}
+
+type ScheduleListCommand struct{}
+
+type ScheduleRunCommand struct {
+	Interval time.Duration `help:"how often to check the switches, and to switch the PoE power, if needed" default:"1m"`
+	Once     bool          `help:"check and switch the PoE power only once, e.g. to be run by cron"`
+}
+
+// scheduleValue is a row for templates
+type scheduleValue struct {
+	Name          string
+	Switches      []string
+	Ports         portList
+	Days          []string
+	From          string
+	To            string
+	Power         string
+	Active        bool
+	ExpectedPower string
+}
+
+var scheduleColumns = []column[scheduleValue]{
+	{name: "name", header: "Name", value: func(s scheduleValue) any { return s.Name }},
+	{name: "switches", header: "Switches", value: func(s scheduleValue) any { return s.Switches },
+		text: func(s scheduleValue) string { return strings.Join(s.Switches, ", ") }},
+	{name: "ports", header: "Ports", value: func(s scheduleValue) any { return s.Ports },
+		text: func(s scheduleValue) string { return strings.Join(s.Ports, ",") }},
+	{name: "days", header: "Days", value: func(s scheduleValue) any { return s.Days },
+		text: func(s scheduleValue) string { return strings.Join(s.Days, ", ") }},
+	{name: "from", header: "From", value: func(s scheduleValue) any { return s.From }},
+	{name: "to", header: "To", value: func(s scheduleValue) any { return s.To }},
+	{name: "power", header: "Power", value: func(s scheduleValue) any { return s.Power }},
+	{name: "active", header: "Active", value: func(s scheduleValue) any { return s.Active }},
+	{name: "expected_power", header: "Expected Power", value: func(s scheduleValue) any { return s.ExpectedPower }},
+}
+
+func (list *ScheduleListCommand) Run(args *GlobalOptions) error {
+	now := time.Now()
+	var values []scheduleValue
+	for _, schedule := range args.configFile().Schedules {
+		targets, err := schedule.targets(args)
+		if err != nil {
+			return err
+		}
+		var switches []string
+		for _, target := range targets {
+			switches = append(switches, target.Name)
+		}
+		days := schedule.Days
+		if len(days) == 0 {
+			days = []string{"daily"}
+		}
+		values = append(values, scheduleValue{
+			Name:          schedule.Name,
+			Switches:      switches,
+			Ports:         schedule.Ports,
+			Days:          days,
+			From:          schedule.From,
+			To:            schedule.To,
+			Power:         schedule.Power,
+			Active:        schedule.isActive(now),
+			ExpectedPower: schedule.expectedPower(now),
+		})
+	}
+	return printTable(tableOutput(args, ""), newTable("schedules", "", scheduleColumns, values, func(value scheduleValue) any { return value }))
+}
+
+// Run checks the switches on the interval and switches the PoE power of the ports, which differ from the schedules.
+// Thus, the ports get the expected state after a restart or a missed window, too.
+// Failures are logged, and retried on the next check.
+func (run *ScheduleRunCommand) Run(args *GlobalOptions) error {
+	err := checkInterval(run.Interval)
+	if err != nil {
+		return err
+	}
+	daemonOptions(args)
+	schedules := args.configFile().Schedules
+	if len(schedules) == 0 {
+		return newCommandError(categoryInvalidArgument, "there are no schedules in the config file")
+	}
+	reconciler := scheduleReconciler{args: args, schedules: schedules, clients: newClientCache()}
+	if run.Once {
+		return reconciler.reconcile(time.Now())
+	}
+	if !args.Quiet {
+		logEvent("running %d schedule(s), checking every %s", len(schedules), run.Interval)
+	}
+	ticker := time.NewTicker(run.Interval)
+	defer ticker.Stop()
+	for {
+		_ = reconciler.reconcile(time.Now())
+		<-ticker.C
+	}
+}
+
+type scheduleReconciler struct {
+	args      *GlobalOptions
+	schedules []PoeSchedule
+	clients   *clientCache
+}
+
+// portPower is the expected PoE power state of a port, and the schedule, which expects it
+type portPower struct {
+	power    string
+	schedule string
+}
+
+// reconcile switches the PoE power of all ports, which differ from the schedules; when schedules overlap,
+// the last active one in the config file wins, see reconcileSwitch. It returns an error, when a switch failed.
+func (reconciler *scheduleReconciler) reconcile(now time.Time) error {
+	var targets []switchTarget
+	schedules := map[string][]PoeSchedule{}
+	for _, schedule := range reconciler.schedules {
+		scheduleTargets, err := schedule.targets(reconciler.args)
+		if err != nil {
+			logEventError(schedule.Name, err)
+			return err
+		}
+		for _, target := range scheduleTargets {
+			if _, ok := schedules[target.Address]; !ok {
+				targets = append(targets, target)
+			}
+			schedules[target.Address] = append(schedules[target.Address], schedule)
+		}
+	}
+	failures := 0
+	for _, target := range targets {
+		err := reconciler.reconcileSwitch(target, schedules[target.Address], now)
+		if err != nil {
+			failures++
+			logEventError(target.Name, err)
+		}
+	}
+	if failures > 0 {
+		return newCommandError(categorySwitchesFailed, "%d of %d switches failed", failures, len(targets))
+	}
+	return nil
+}
+
+// reconcileSwitch checks the schedules' ports against the switch's PoE ports, before any change is requested.
+// A port gets the power state of the last active schedule, which covers it; only when none is active,
+// the port gets the opposite power state of the last schedule.
+func (reconciler *scheduleReconciler) reconcileSwitch(target switchTarget, schedules []PoeSchedule, now time.Time) error {
+	client, switchArgs, created, err := reconciler.clients.client(reconciler.args, target)
+	if err != nil {
+		return err
+	}
+	count, err := portCountOf(switchArgs, target.Address, client)()
+	if err != nil {
+		return err
+	}
+	expected := map[int]portPower{}
+	activePorts := map[int]bool{}
+	for _, schedule := range schedules {
+		ports, err := schedule.Ports.resolve(count, true)
+		if err != nil {
+			return fmt.Errorf("schedule '%s': %w", schedule.Name, err)
+		}
+		active := schedule.isActive(now)
+		for _, port := range ports {
+			if activePorts[port] && !active {
+				continue
+			}
+			expected[port] = portPower{power: schedule.expectedPower(now), schedule: schedule.Name}
+			activePorts[port] = active
+		}
+	}
+	settings, err := client.PoeSettings()
+	if err != nil {
+		return err
+	}
+	changes := map[portPower][]int{}
+	for _, setting := range settings {
+		power, ok := expected[int(setting.PortIndex)]
+		if ok && setting.PortPwr != (power.power == "enable") {
+			changes[power] = append(changes[power], int(setting.PortIndex))
+		}
+	}
+	for _, power := range slices.SortedFunc(maps.Keys(changes), comparePortPower) {
+		ports := changes[power]
+		slices.Sort(ports)
+		_, err = client.SetPoe(ports, netgear.PoePortSettingsUpdate{PortPwr: power.power})
+		if err != nil {
+			return err
+		}
+		logEvent("%s: %sd PoE of port(s) %s, schedule '%s'", target.Name, power.power, joinPorts(ports), power.schedule)
+	}
+	if created {
+		_ = touchToken(switchArgs, target.Address)
+	}
+	return nil
+}
+
+func comparePortPower(a, b portPower) int {
+	return strings.Compare(a.schedule+a.power, b.schedule+b.power)
+}
+
+func joinPorts(ports []int) string {
+	var texts []string
+	for _, port := range ports {
+		texts = append(texts, strconv.Itoa(port))
+	}
+	return strings.Join(texts, ", ")
+}
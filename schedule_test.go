@@ -0,0 +1,175 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/ntgrrc/netgear"
+)
+
+func Test_overnight_schedule_is_active_until_the_next_morning(t *testing.T) {
+	schedule := PoeSchedule{Power: "disable", Days: []string{"weekdays"}, From: "20:00", To: "07:00"}
+	friday := time.Date(2026, 10, 16, 0, 0, 0, 0, time.Local)
+
+	then.AssertThat(t, schedule.isActive(friday.Add(19*time.Hour+59*time.Minute)), is.False())
+	then.AssertThat(t, schedule.isActive(friday.Add(20*time.Hour)), is.True())
+	then.AssertThat(t, schedule.isActive(friday.AddDate(0, 0, 1).Add(6*time.Hour+59*time.Minute)), is.True())
+	then.AssertThat(t, schedule.isActive(friday.AddDate(0, 0, 1).Add(7*time.Hour)), is.False())
+	then.AssertThat(t, schedule.isActive(friday.AddDate(0, 0, 1).Add(20*time.Hour)), is.False())
+	then.AssertThat(t, schedule.isActive(friday.AddDate(0, 0, 3).Add(3*time.Hour)), is.False())
+	then.AssertThat(t, schedule.expectedPower(friday.Add(21*time.Hour)), is.EqualTo("disable"))
+	then.AssertThat(t, schedule.expectedPower(friday.Add(12*time.Hour)), is.EqualTo("enable"))
+}
+
+func Test_config_file_with_invalid_schedule_is_rejected(t *testing.T) {
+	_, err := loadTestConfig(t, testConfig+`
+schedules:
+  - name: office-night
+    group: office
+    ports: [3, 4, 5]
+    power: disable
+    days: [weekdays]
+    from: "20:00"
+    to: "7 am"
+`)
+
+	then.AssertThat(t, errorCategoryOf(err), is.EqualTo(categoryInvalidArgument))
+	then.AssertThat(t, err.Error(), is.EqualTo("config file: schedule 'office-night' has an invalid time '7 am', use HH:MM, e.g. '20:00'"))
+}
+
+func Test_schedule_switches_the_ports_which_differ(t *testing.T) {
+	// setup
+	args, host := loggedInEmulator(t, netgear.GS316EP)
+	reconciler := scheduleReconciler{args: args, clients: newClientCache(), schedules: []PoeSchedule{
+		{Name: "night", Switches: []string{host}, Ports: portList{"2-3"}, Power: "disable", From: "20:00", To: "07:00"},
+		{Name: "camera", Switches: []string{host}, Ports: portList{"3"}, Power: "enable", From: "00:00", To: "23:59"},
+	}}
+	night := time.Date(2026, 10, 16, 22, 0, 0, 0, time.Local)
+
+	// when
+	err := reconciler.reconcile(night)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	client, err := newClient(args, host)
+	then.AssertThat(t, err, is.Nil())
+	settings, err := client.PoeSettings()
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, settings, has.Length[netgear.PoePortSetting](15))
+	then.AssertThat(t, settings[0].PortPwr, is.True())
+	then.AssertThat(t, settings[1].PortPwr, is.False())
+	then.AssertThat(t, settings[2].PortPwr, is.True())
+
+	// when
+	err = reconciler.reconcile(night.Add(10 * time.Hour))
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	settings, err = client.PoeSettings()
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, settings[1].PortPwr, is.True())
+}
+
+func Test_active_schedule_wins_over_a_later_inactive_one(t *testing.T) {
+	// setup
+	args, host := loggedInEmulator(t, netgear.GS316EP)
+	reconciler := scheduleReconciler{args: args, clients: newClientCache(), schedules: []PoeSchedule{
+		{Name: "night", Switches: []string{host}, Ports: portList{"2"}, Power: "disable", Days: []string{"weekdays"}, From: "20:00", To: "07:00"},
+		{Name: "weekend", Switches: []string{host}, Ports: portList{"2"}, Power: "disable", Days: []string{"weekends"}, From: "08:00", To: "18:00"},
+	}}
+	client, err := newClient(args, host)
+	then.AssertThat(t, err, is.Nil())
+	portPwrAt := func(now time.Time) bool {
+		err := reconciler.reconcile(now)
+		then.AssertThat(t, err, is.Nil())
+		settings, err := client.PoeSettings()
+		then.AssertThat(t, err, is.Nil())
+		return settings[1].PortPwr
+	}
+	friday := time.Date(2026, 10, 16, 0, 0, 0, 0, time.Local)
+
+	then.AssertThat(t, portPwrAt(friday.Add(22*time.Hour)), is.False())
+	then.AssertThat(t, portPwrAt(friday.AddDate(0, 0, 1).Add(7*time.Hour+30*time.Minute)), is.True())
+	then.AssertThat(t, portPwrAt(friday.AddDate(0, 0, 1).Add(12*time.Hour)), is.False())
+	then.AssertThat(t, portPwrAt(friday.AddDate(0, 0, 3).Add(12*time.Hour)), is.True())
+}
+
+func Test_config_file_with_empty_schedule_window_is_rejected(t *testing.T) {
+	_, err := loadTestConfig(t, testConfig+`
+schedules:
+  - name: office-night
+    group: office
+    ports: [3]
+    power: disable
+    from: "20:00"
+    to: "20:00"
+`)
+
+	then.AssertThat(t, errorCategoryOf(err), is.EqualTo(categoryInvalidArgument))
+	then.AssertThat(t, err.Error(), is.EqualTo("config file: schedule 'office-night' has a window from 20:00 to 20:00, which is empty, use different times"))
+}
+
+func Test_schedule_ports_are_checked_against_the_switch(t *testing.T) {
+	args, host := loggedInEmulator(t, netgear.GS316EP)
+	reconciler := scheduleReconciler{args: args, clients: newClientCache(), schedules: []PoeSchedule{
+		{Name: "night", Switches: []string{host}, Ports: portList{"14-16"}, Power: "disable", From: "20:00", To: "07:00"},
+	}}
+
+	err := reconciler.reconcileSwitch(switchTarget{Name: host, Address: host}, reconciler.schedules, time.Now())
+
+	then.AssertThat(t, err.Error(), is.EqualTo("schedule 'night': given port id 16, doesn't fit in range 1..15"))
+	client, err := newClient(args, host)
+	then.AssertThat(t, err, is.Nil())
+	settings, err := client.PoeSettings()
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, settings[13].PortPwr, is.True())
+}
+
+func Test_schedule_ports_accept_ranges(t *testing.T) {
+	config, err := loadTestConfig(t, testConfig+`
+schedules:
+  - name: office-night
+    group: office
+    ports: [1-3, 5]
+    power: disable
+    from: "20:00"
+    to: "07:00"
+  - name: lobby
+    group: office
+    ports: 4,7-8
+    power: disable
+    from: "20:00"
+    to: "07:00"
+`)
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, config.Schedules[0].Ports, is.EqualTo(portList{"1-3", "5"}))
+	then.AssertThat(t, config.Schedules[1].Ports, is.EqualTo(portList{"4", "7-8"}))
+}
+
+func Test_config_file_with_invalid_schedule_port_is_rejected(t *testing.T) {
+	_, err := loadTestConfig(t, testConfig+`
+schedules:
+  - name: office-night
+    group: office
+    ports: [0-2]
+    power: disable
+    from: "20:00"
+    to: "07:00"
+`)
+
+	then.AssertThat(t, errorCategoryOf(err), is.EqualTo(categoryInvalidArgument))
+	then.AssertThat(t, err.Error(), is.EqualTo("config file: schedule 'office-night' has an invalid port '0-2', use port numbers, ranges like '1-4', 'all' or 'poe'"))
+}
+
+func Test_schedule_run_rejects_a_non_positive_interval(t *testing.T) {
+	run := ScheduleRunCommand{Interval: -time.Minute}
+
+	err := run.Run(&GlobalOptions{})
+
+	then.AssertThat(t, errorCategoryOf(err), is.EqualTo(categoryInvalidArgument))
+	then.AssertThat(t, err.Error(), is.EqualTo("invalid --interval -1m0s, it must be positive"))
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/nitram509/ntgrrc/netgear"
+	"net/http"
+	"os"
+	"time"
+)
+
+type SessionCommand struct {
+	SessionListCommand  SessionListCommand  `cmd:"" name:"list" help:"list all stored sessions and check if they still work" default:"1"`
+	SessionShowCommand  SessionShowCommand  `cmd:"" name:"show" help:"show the stored session of a single switch and check if it still works"`
+	SessionPruneCommand SessionPruneCommand `cmd:"" name:"prune" help:"delete stored sessions, which expired or were not used for some time"`
+}
+
+const (
+	sessionValid       = "valid"
+	sessionExpired     = "expired"
+	sessionUnchecked   = "unchecked"
+	sessionHostUnknown = "unknown host; please login again"
+)
+
+type SessionListCommand struct {
+	Offline bool          `help:"don't check the sessions, only show what's stored"`
+	Timeout time.Duration `help:"timeout for checking a session" default:"5s"`
+}
+
+type SessionShowCommand struct {
+	Address string        `required:"" help:"the Netgear switch's IP address or host name to connect to, or its name from the config file" short:"a"`
+	Offline bool          `help:"don't check the session, only show what's stored"`
+	Timeout time.Duration `help:"timeout for checking the session" default:"5s"`
+}
+
+type SessionPruneCommand struct {
+	OlderThan time.Duration `help:"also delete sessions, which were not used for this duration, e.g. 24h" default:"0s"`
+	DryRun    bool          `help:"only show which sessions would be deleted"`
+	Timeout   time.Duration `help:"timeout for checking a session" default:"5s"`
+}
+
+// storedSession is a token file together with its content and the result of checking the session
+type storedSession struct {
+	fileName string
+	session  *session
+	status   string
+}
+
+func (list *SessionListCommand) Run(args *GlobalOptions) error {
+	sessions, err := readStoredSessions(args)
+	if err != nil {
+		return err
+	}
+	if !list.Offline {
+		for i := range sessions {
+			checkStoredSession(args, &sessions[i], list.Timeout)
+		}
+	}
+	return prettyPrintSessions(tableOutput(args, ""), sessions)
+}
+
+func (show *SessionShowCommand) Run(args *GlobalOptions) error {
+	host := resolveAddress(args, show.Address)
+	fileName := tokenFilename(args.TokenDir, host)
+	s, err := readSession(args, fileName)
+	if err != nil {
+		return err
+	}
+	if s.Host == "" {
+		s.Host = host
+	}
+	stored := storedSession{fileName: fileName, session: s, status: sessionUnchecked}
+	if !show.Offline {
+		checkStoredSession(args, &stored, show.Timeout)
+	}
+	return prettyPrintSessions(tableOutput(args, host), []storedSession{stored})
+}
+
+// Run deletes expired sessions and, with --older-than, sessions not used for a while.
+// Sessions, which can't be checked, e.g. because the switch is not reachable, are kept.
+func (prune *SessionPruneCommand) Run(args *GlobalOptions) error {
+	sessions, err := readStoredSessions(args)
+	if err != nil {
+		return err
+	}
+	var pruned []storedSession
+	for _, stored := range sessions {
+		if stored.session == nil {
+			continue
+		}
+		if prune.OlderThan > 0 && time.Since(stored.session.LastUsed) > prune.OlderThan {
+			stored.status = fmt.Sprintf("not used for more than %s", prune.OlderThan)
+		} else {
+			checkStoredSession(args, &stored, prune.Timeout)
+			if stored.status != sessionExpired {
+				continue
+			}
+		}
+		if !prune.DryRun {
+			err = os.Remove(stored.fileName)
+			if err != nil {
+				return err
+			}
+		}
+		pruned = append(pruned, stored)
+	}
+	return prettyPrintSessions(tableOutput(args, ""), pruned)
+}
+
+// readStoredSessions reads all token files; a file, which can't be read, is listed with the error as status
+func readStoredSessions(args *GlobalOptions) ([]storedSession, error) {
+	fileNames, err := tokenFilenames(args.TokenDir)
+	if err != nil {
+		return nil, err
+	}
+	var sessions []storedSession
+	for _, fileName := range fileNames {
+		s, err := readSession(args, fileName)
+		if err != nil {
+			sessions = append(sessions, storedSession{fileName: fileName, status: err.Error()})
+			continue
+		}
+		sessions = append(sessions, storedSession{fileName: fileName, session: s, status: sessionUnchecked})
+	}
+	return sessions, nil
+}
+
+// checkStoredSession requests a small page from the switch, to find out if the session still works
+func checkStoredSession(args *GlobalOptions, stored *storedSession, timeout time.Duration) {
+	if stored.session == nil {
+		return
+	}
+	if stored.session.Host == "" {
+		stored.status = sessionHostUnknown
+		return
+	}
+	client := netgear.NewClient(stored.session.Host,
+		netgear.WithVerboseOutput(verboseOutput(args)),
+		netgear.WithHttpClient(&http.Client{Timeout: timeout}),
+		netgear.WithSession(stored.session.Model, stored.session.Token))
+	err := client.CheckSession()
+	switch {
+	case err == nil:
+		stored.status = sessionValid
+	case errors.Is(err, netgear.ErrLoginRequired):
+		stored.status = sessionExpired
+	default:
+		stored.status = "unknown: " + err.Error()
+	}
+}
+
+// sessionValue is a row for templates; unknown times, e.g. from former versions, are zero
+type sessionValue struct {
+	Host      string
+	Model     netgear.NetgearModel
+	LoginTime time.Time
+	LastUsed  time.Time
+	Status    string
+	TokenFile string
+}
+
+// sessionColumns have null as unknown times in JSON
+var sessionColumns = []column[sessionValue]{
+	{name: "host", header: "Host", value: func(s sessionValue) any { return s.Host }},
+	{name: "model", header: "Model", value: func(s sessionValue) any { return s.Model }},
+	{name: "login_time", header: "Login Time", value: func(s sessionValue) any { return optionalTime(s.LoginTime) },
+		text: func(s sessionValue) string { return formatSessionTime(s.LoginTime) }},
+	{name: "last_used", header: "Last Used", value: func(s sessionValue) any { return optionalTime(s.LastUsed) },
+		text: func(s sessionValue) string { return formatSessionTime(s.LastUsed) }},
+	{name: "status", header: "Status", value: func(s sessionValue) any { return s.Status }},
+	{name: "token_file", header: "Token File", value: func(s sessionValue) any { return s.TokenFile }},
+}
+
+func prettyPrintSessions(output outputOptions, sessions []storedSession) error {
+	var values []sessionValue
+	for _, stored := range sessions {
+		value := sessionValue{Status: stored.status, TokenFile: stored.fileName}
+		if stored.session != nil {
+			value.Host = stored.session.Host
+			value.Model = stored.session.Model
+			value.LoginTime = stored.session.LoginTime
+			value.LastUsed = stored.session.LastUsed
+		}
+		values = append(values, value)
+	}
+	return printTable(output, newTable("sessions", "", sessionColumns, values, func(value sessionValue) any { return value }))
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// formatSessionTime formats the time in the local time zone; an unknown time, e.g. from former versions, is empty
+func formatSessionTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format(time.DateTime)
+}
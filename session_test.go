@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/ntgrrc/netgear"
+)
+
+func Test_stored_session_contains_host_and_times(t *testing.T) {
+	// setup
+	args := GlobalOptions{
+		TokenDir: t.TempDir(),
+		model:    netgear.GS30xEPx,
+	}
+	const host = "ntgrrc-test-case-host"
+	before := time.Now().Add(-time.Second)
+	// given
+	err := storeToken(&args, host, "1234567890")
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	s, err := readSession(&args, tokenFilename(args.TokenDir, host))
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, s.Host, is.EqualTo(host))
+	then.AssertThat(t, s.Model, is.EqualTo(netgear.GS30xEPx))
+	then.AssertThat(t, s.Token, is.EqualTo("1234567890"))
+	then.AssertThat(t, s.LoginTime.After(before), is.True())
+	then.AssertThat(t, s.LastUsed, is.EqualTo(s.LoginTime))
+}
+
+func Test_token_file_of_former_versions_can_still_be_read(t *testing.T) {
+	// setup
+	args := GlobalOptions{TokenDir: t.TempDir()}
+	const host = "ntgrrc-test-case-host"
+	err := ensureConfigPathExists(args.TokenDir)
+	then.AssertThat(t, err, is.Nil())
+	// given
+	err = os.WriteFile(tokenFilename(args.TokenDir, host), []byte("GS316EP:abcdef"), 0600)
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	model, token, err := readTokenAndModel2GlobalOptions(&args, host)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, model, is.EqualTo(netgear.GS316EP))
+	then.AssertThat(t, token, is.EqualTo("abcdef"))
+}
+
+func Test_touching_a_token_updates_the_last_use(t *testing.T) {
+	// setup
+	args := GlobalOptions{
+		TokenDir: t.TempDir(),
+		model:    netgear.GS30xEPx,
+	}
+	const host = "ntgrrc-test-case-host"
+	// given
+	err := storeSession(&args, session{Host: host, Model: netgear.GS30xEPx, Token: "1234567890"})
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	err = touchToken(&args, host)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	s, err := readSession(&args, tokenFilename(args.TokenDir, host))
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, s.LoginTime.IsZero(), is.True())
+	then.AssertThat(t, s.LastUsed.IsZero(), is.False())
+}
+
+func Test_stored_sessions_are_checked_against_the_switch(t *testing.T) {
+	// given
+	args, host := loggedInEmulator(t, netgear.GS308EPP)
+	args.model = netgear.GS308EPP
+	err := storeToken(args, "localhost:1", "expired")
+	then.AssertThat(t, err, is.Nil())
+	expiredHost := strings.Replace(host, "127.0.0.1", "localhost", 1)
+	err = storeToken(args, expiredHost, "expired")
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	sessions, err := readStoredSessions(args)
+	then.AssertThat(t, err, is.Nil())
+	statuses := map[string]string{}
+	for i := range sessions {
+		checkStoredSession(args, &sessions[i], time.Second)
+		statuses[sessions[i].session.Host] = sessions[i].status
+	}
+
+	// then
+	then.AssertThat(t, sessions, has.Length[storedSession](3))
+	then.AssertThat(t, statuses[host], is.EqualTo(sessionValid))
+	then.AssertThat(t, statuses[expiredHost], is.EqualTo(sessionExpired))
+	then.AssertThat(t, strings.HasPrefix(statuses["localhost:1"], "unknown: "), is.True())
+}
+
+func Test_prune_deletes_expired_sessions_only(t *testing.T) {
+	// given
+	args, host := loggedInEmulator(t, netgear.GS316EP)
+	args.OutputFormat = MarkdownFormat
+	args.model = netgear.GS316EP
+	err := storeToken(args, strings.Replace(host, "127.0.0.1", "localhost", 1), "expired")
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	prune := SessionPruneCommand{Timeout: time.Second}
+	err = prune.Run(args)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	fileNames, err := tokenFilenames(args.TokenDir)
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, fileNames, has.Length[string](1))
+	then.AssertThat(t, fileNames[0], is.EqualTo(tokenFilename(args.TokenDir, host)))
+}
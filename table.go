@@ -0,0 +1,338 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// column describes a column of a table, which all output formats use. The name, e.g. "power_w",
+// is the key in JSON; --columns, --where and --sort accept the name, the alias, e.g. "power", or the header.
+type column[T any] struct {
+	name   string
+	alias  string
+	header string
+	// hidden columns are part of JSON, but shown in the other formats only, when selected with --columns
+	hidden bool
+	value  func(result T) any
+	// text is shown in the text formats; without it, the value is formatted with fmt.Sprint
+	text func(result T) string
+	// influx is the column's role in the InfluxDB line protocol; other columns are left out
+	influx influxRole
+}
+
+// tableColumn is a column of a table, without the type of the results
+type tableColumn struct {
+	name   string
+	alias  string
+	header string
+	hidden bool
+	influx influxRole
+}
+
+// record is a row of a table: the texts and the typed values of the columns, the result for templates,
+// and the time of the query
+type record struct {
+	texts  []string
+	values []any
+	result any
+	time   time.Time
+}
+
+// table is the output of a command: the columns' texts are shown in markdown, CSV and TSV,
+// the typed values in JSON, and the results are the data of templates
+type table struct {
+	// item is the name of the rows in JSON, e.g. "poe_status"
+	item    string
+	columns []tableColumn
+	records []record
+}
+
+// newTable builds a table from the results of a command; with a switch's name, the table starts with a hidden
+// 'switch' column, which is shown when several switches are queried. The template result wraps each result,
+// e.g. to add the switch's name.
+func newTable[T any](item string, switchName string, columns []column[T], results []T, templateResult func(result T) any) table {
+	if switchName != "" {
+		switchColumn := column[T]{name: "switch", header: "Switch", hidden: true, influx: influxTag, value: func(T) any { return switchName }}
+		columns = append([]column[T]{switchColumn}, columns...)
+	}
+	t := table{item: item}
+	for _, c := range columns {
+		t.columns = append(t.columns, tableColumn{name: c.name, alias: c.alias, header: c.header, hidden: c.hidden, influx: c.influx})
+	}
+	now := time.Now()
+	for _, result := range results {
+		r := record{result: templateResult(result), time: now}
+		for _, c := range columns {
+			value := c.value(result)
+			text := fmt.Sprint(value)
+			if c.text != nil {
+				text = c.text(result)
+			}
+			r.values = append(r.values, value)
+			r.texts = append(r.texts, text)
+		}
+		t.records = append(t.records, r)
+	}
+	return t
+}
+
+// header are the headers of the visible columns
+func (t table) header() []string {
+	var header []string
+	for _, c := range t.columns {
+		if !c.hidden {
+			header = append(header, c.header)
+		}
+	}
+	return header
+}
+
+// content are the texts of the visible columns
+func (t table) content() [][]string {
+	var content [][]string
+	for _, r := range t.records {
+		var row []string
+		for i, c := range t.columns {
+			if !c.hidden {
+				row = append(row, r.texts[i])
+			}
+		}
+		content = append(content, row)
+	}
+	return content
+}
+
+// rows are the typed values of all columns, for JSON
+func (t table) rows() []jsonObject {
+	var names []string
+	for _, c := range t.columns {
+		names = append(names, c.name)
+	}
+	var rows []jsonObject
+	for _, r := range t.records {
+		rows = append(rows, jsonObject{names: names, values: r.values})
+	}
+	return rows
+}
+
+// results are the data of templates
+func (t table) results() []any {
+	var results []any
+	for _, r := range t.records {
+		results = append(results, r.result)
+	}
+	return results
+}
+
+// showColumn makes a hidden column, e.g. the switch's name, visible
+func (t table) showColumn(name string) table {
+	t.columns = slices.Clone(t.columns)
+	for i := range t.columns {
+		if t.columns[i].name == name {
+			t.columns[i].hidden = false
+		}
+	}
+	return t
+}
+
+func (t table) columnIndex(name string) (int, error) {
+	for i, c := range t.columns {
+		if strings.EqualFold(c.name, name) || strings.EqualFold(c.alias, name) || strings.EqualFold(c.header, name) {
+			return i, nil
+		}
+	}
+	var names []string
+	for _, c := range t.columns {
+		names = append(names, c.name)
+	}
+	return 0, newCommandError(categoryInvalidArgument, "unknown column '%s', the columns are: %s", name, strings.Join(names, ", "))
+}
+
+// TableOptions select, filter and sort the rows and columns of a table, in all output formats
+type TableOptions struct {
+	Columns []string `help:"show only these columns, by name or header, e.g. 'port_id,power_w'" placeholder:"COLUMN"`
+	Where   []string `help:"show only the rows matching the condition COLUMN(=,!=,<,<=,>,>=)VALUE, e.g. 'status=Delivering Power' or 'power>5'; use multiple times for rows matching all conditions" placeholder:"CONDITION" sep:"none"`
+	Sort    []string `help:"sort the rows by these columns, ascending or with ':desc' descending, e.g. 'power:desc'" placeholder:"COLUMN[:desc]"`
+}
+
+// apply filters, sorts and then selects the columns, thus the conditions and the order may use other columns
+func (options *TableOptions) apply(t table) (table, error) {
+	for _, where := range options.Where {
+		c, err := parseCondition(t, where)
+		if err != nil {
+			return t, err
+		}
+		t.records = slices.DeleteFunc(slices.Clone(t.records), func(r record) bool { return !c.matches(r) })
+	}
+	if len(options.Sort) > 0 {
+		t.records = slices.Clone(t.records)
+		err := options.sort(t)
+		if err != nil {
+			return t, err
+		}
+	}
+	if len(options.Columns) > 0 {
+		return selectColumns(t, options.Columns)
+	}
+	return t, nil
+}
+
+func (options *TableOptions) sort(t table) error {
+	type sortKey struct {
+		index      int
+		descending bool
+	}
+	var keys []sortKey
+	for _, s := range options.Sort {
+		name, direction, _ := strings.Cut(s, ":")
+		index, err := t.columnIndex(strings.TrimSpace(name))
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(direction) {
+		case "", "asc":
+			keys = append(keys, sortKey{index: index})
+		case "desc":
+			keys = append(keys, sortKey{index: index, descending: true})
+		default:
+			return newCommandError(categoryInvalidArgument, "unknown sort order '%s', use 'asc' or 'desc'", direction)
+		}
+	}
+	slices.SortStableFunc(t.records, func(a, b record) int {
+		for _, key := range keys {
+			result := compareValues(a.values[key.index], a.texts[key.index], b.values[key.index], b.texts[key.index])
+			if key.descending {
+				result = -result
+			}
+			if result != 0 {
+				return result
+			}
+		}
+		return 0
+	})
+	return nil
+}
+
+// selectColumns keeps the given columns, in the given order, and shows them all
+func selectColumns(t table, names []string) (table, error) {
+	var indexes []int
+	for _, name := range names {
+		index, err := t.columnIndex(strings.TrimSpace(name))
+		if err != nil {
+			return t, err
+		}
+		indexes = append(indexes, index)
+	}
+	selected := table{item: t.item}
+	for _, index := range indexes {
+		c := t.columns[index]
+		c.hidden = false
+		selected.columns = append(selected.columns, c)
+	}
+	for _, r := range t.records {
+		s := record{result: r.result, time: r.time}
+		for _, index := range indexes {
+			s.texts = append(s.texts, r.texts[index])
+			s.values = append(s.values, r.values[index])
+		}
+		selected.records = append(selected.records, s)
+	}
+	return selected, nil
+}
+
+type condition struct {
+	index    int
+	operator string
+	operand  string
+}
+
+var conditionOperators = []string{"!=", "<=", ">=", "=", "<", ">"}
+
+func parseCondition(t table, where string) (condition, error) {
+	position := strings.IndexAny(where, "!<>=")
+	if position < 1 {
+		return condition{}, newCommandError(categoryInvalidArgument, "invalid condition '%s', expected COLUMN(=,!=,<,<=,>,>=)VALUE", where)
+	}
+	for _, operator := range conditionOperators {
+		if strings.HasPrefix(where[position:], operator) {
+			index, err := t.columnIndex(strings.TrimSpace(where[:position]))
+			if err != nil {
+				return condition{}, err
+			}
+			return condition{index: index, operator: operator, operand: strings.TrimSpace(where[position+len(operator):])}, nil
+		}
+	}
+	return condition{}, newCommandError(categoryInvalidArgument, "invalid condition '%s', expected COLUMN(=,!=,<,<=,>,>=)VALUE", where)
+}
+
+func (c condition) matches(r record) bool {
+	result := compareValues(r.values[c.index], r.texts[c.index], c.operand, c.operand)
+	switch c.operator {
+	case "=":
+		return result == 0
+	case "!=":
+		return result != 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	default:
+		return result >= 0
+	}
+}
+
+// compareValues compares numbers and booleans by value, and otherwise the texts, ignoring the case.
+// A value also equals the text of the other, e.g. the port power's 'true' equals 'enabled'.
+func compareValues(a any, aText string, b any, bText string) int {
+	aNumber, aIsNumber := asNumber(a)
+	bNumber, bIsNumber := asNumber(b)
+	if aIsNumber && bIsNumber {
+		return cmp.Compare(aNumber, bNumber)
+	}
+	if strings.EqualFold(fmt.Sprint(a), fmt.Sprint(b)) || strings.EqualFold(aText, bText) {
+		return 0
+	}
+	return strings.Compare(strings.ToLower(aText), strings.ToLower(bText))
+}
+
+// asNumber converts the typed values, and texts of numbers or booleans, e.g. the operand of a condition
+func asNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return exactFloat64(v), true
+	case float64:
+		return v, true
+	case *float64:
+		if v == nil {
+			return 0, false
+		}
+		return *v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		if number, err := strconv.ParseFloat(v, 64); err == nil {
+			return number, true
+		}
+		if b, err := strconv.ParseBool(v); err == nil {
+			return asNumber(b)
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/ntgrrc/netgear"
+)
+
+var testTablePoePortStatuses = []netgear.PoePortStatus{
+	{PortIndex: 1, PoePortStatus: "Delivering Power", PowerInWatt: 4.4},
+	{PortIndex: 2, PoePortStatus: "Searching"},
+	{PortIndex: 3, PoePortStatus: "Delivering Power", PowerInWatt: 12.5},
+	{PortIndex: 4, PoePortStatus: "delivering power", PowerInWatt: 5.8},
+}
+
+func Test_table_where_filters_rows_by_text_and_number(t *testing.T) {
+	options := TableOptions{Where: []string{"status=Delivering Power", "power>5"}}
+
+	result, err := options.apply(poePortStatusTable("office-1", testTablePoePortStatuses))
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, result.content(), is.EqualTo([][]string{
+		{"3", "", "Delivering Power", "", "0", "0", "12.50", "0", ""},
+		{"4", "", "delivering power", "", "0", "0", "5.80", "0", ""},
+	}))
+}
+
+func Test_table_where_compares_booleans_with_value_and_text(t *testing.T) {
+	settings := []netgear.PoePortSetting{{PortIndex: 1, PortPwr: true}, {PortIndex: 2}}
+
+	enabled, err := (&TableOptions{Where: []string{"port_power=enabled"}}).apply(poePortSettingsTable("", settings))
+	then.AssertThat(t, err, is.Nil())
+	disabled, err := (&TableOptions{Where: []string{"port_power != true"}}).apply(poePortSettingsTable("", settings))
+	then.AssertThat(t, err, is.Nil())
+
+	then.AssertThat(t, enabled.records, has.Length[record](1))
+	then.AssertThat(t, enabled.content()[0][0], is.EqualTo("1"))
+	then.AssertThat(t, disabled.records, has.Length[record](1))
+	then.AssertThat(t, disabled.content()[0][0], is.EqualTo("2"))
+}
+
+func Test_table_sort_and_columns(t *testing.T) {
+	options := TableOptions{Sort: []string{"power:desc", "port_id"}, Columns: []string{"switch", "Port ID", "power"}}
+
+	result, err := options.apply(poePortStatusTable("office-1", testTablePoePortStatuses))
+
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, result.header(), is.EqualTo([]string{"Switch", "Port ID", "PortPwr (W)"}))
+	then.AssertThat(t, result.content(), is.EqualTo([][]string{
+		{"office-1", "3", "12.50"},
+		{"office-1", "4", "5.80"},
+		{"office-1", "1", "4.40"},
+		{"office-1", "2", "0.00"},
+	}))
+	then.AssertThat(t, result.results()[0].(poePortStatusValue).PortIndex, is.EqualTo(int8(3)))
+}
+
+func Test_table_hides_the_switch_for_a_single_switch(t *testing.T) {
+	single := poePortStatusTable("office-1", testTablePoePortStatuses)
+	multiple := single.showColumn("switch")
+
+	then.AssertThat(t, single.header()[0], is.EqualTo("Port ID"))
+	then.AssertThat(t, multiple.header()[0], is.EqualTo("Switch"))
+	then.AssertThat(t, single.rows()[0].names[0], is.EqualTo("switch"))
+}
+
+func Test_table_options_report_unknown_columns_and_invalid_conditions(t *testing.T) {
+	statuses := poePortStatusTable("", testTablePoePortStatuses)
+
+	_, unknownColumn := (&TableOptions{Columns: []string{"watts"}}).apply(statuses)
+	_, invalidCondition := (&TableOptions{Where: []string{"power"}}).apply(statuses)
+	_, invalidOrder := (&TableOptions{Sort: []string{"power:up"}}).apply(statuses)
+
+	then.AssertThat(t, unknownColumn.Error(), has.Prefix("unknown column 'watts', the columns are: port_id, port_name"))
+	then.AssertThat(t, invalidCondition.Error(), has.Prefix("invalid condition 'power'"))
+	then.AssertThat(t, invalidOrder.Error(), has.Prefix("unknown sort order 'up'"))
+}
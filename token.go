@@ -1,37 +1,141 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/nitram509/ntgrrc/netgear"
 	"hash/adler32"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"time"
 )
 
+// separator is used by the token files of former versions, which contain 'model:token' only
 const separator = ":"
 
+const tokenFilePrefix = "token-"
+
+// session is stored as JSON in the token file, see tokenFilename
+type session struct {
+	Host      string               `json:"host"`
+	Model     netgear.NetgearModel `json:"model"`
+	Token     string               `json:"token"`
+	LoginTime time.Time            `json:"login_time"`
+	LastUsed  time.Time            `json:"last_used"`
+}
+
 func storeToken(args *GlobalOptions, host string, token string) error {
+	now := time.Now()
+	return storeSession(args, session{
+		Host:      host,
+		Model:     args.model,
+		Token:     token,
+		LoginTime: now,
+		LastUsed:  now,
+	})
+}
+
+func storeSession(args *GlobalOptions, s session) error {
 	err := ensureConfigPathExists(args.TokenDir)
 	if err != nil {
 		return err
 	}
+	fileName := tokenFilename(args.TokenDir, s.Host)
+	if args.Verbose {
+		fmt.Println("Storing login token " + fileName)
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	secret, err := tokenSecret(args)
+	if err != nil {
+		return err
+	}
+	if secret != nil {
+		data, err = encryptToken(secret, data)
+		if err != nil {
+			return err
+		}
+	}
+	return writePrivateFile(fileName, data)
+}
+
+// writePrivateFile replaces the file with a new one, which only the user can read. The data is written to a temporary file,
+// which is created with mode 0600, and renamed then, because the file might exist from former versions, which used
+// more permissive file modes, and os.WriteFile keeps the mode of an existing file.
+func writePrivateFile(fileName string, data []byte) error {
+	// the name doesn't start with tokenFilePrefix, thus a left over file isn't listed as session
+	file, err := os.CreateTemp(filepath.Dir(fileName), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), fileName)
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+	}
+	return err
+}
+
+// deleteToken removes the stored token for the host, if any
+func deleteToken(args *GlobalOptions, host string) error {
+	fileName := tokenFilename(args.TokenDir, host)
 	if args.Verbose {
-		fmt.Println("Storing login token " + tokenFilename(args.TokenDir, host))
+		fmt.Println("Deleting login token " + fileName)
 	}
-	data := fmt.Sprintf("%s%s%s", args.model, separator, token)
-	return os.WriteFile(tokenFilename(args.TokenDir, host), []byte(data), 0644)
+	err := os.Remove(fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// touchToken records the successful use of the stored session for the host, if any
+func touchToken(args *GlobalOptions, host string) error {
+	s, err := readSession(args, tokenFilename(args.TokenDir, host))
+	if errors.Is(err, netgear.ErrNoSession) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if s.Host == "" {
+		s.Host = host
+	}
+	s.LastUsed = time.Now()
+	return storeSession(args, *s)
 }
 
 func tokenFilename(configDir string, host string) string {
 	hash32 := adler32.New()
 	io.WriteString(hash32, host)
-	return filepath.Join(dotConfigDirName(configDir), "token-"+fmt.Sprintf("%x", hash32.Sum(nil)))
+	return filepath.Join(dotConfigDirName(configDir), tokenFilePrefix+fmt.Sprintf("%x", hash32.Sum(nil)))
+}
+
+// tokenFilenames lists all stored token files, alphabetically sorted
+func tokenFilenames(configDir string) ([]string, error) {
+	fileNames, err := filepath.Glob(filepath.Join(dotConfigDirName(configDir), tokenFilePrefix+"*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(fileNames)
+	return fileNames, nil
 }
 
-func readTokenAndModel2GlobalOptions(args *GlobalOptions, host string) (NetgearModel, string, error) {
+func readTokenAndModel2GlobalOptions(args *GlobalOptions, host string) (netgear.NetgearModel, string, error) {
 
 	if len(args.model) > 0 && len(args.token) > 0 {
 		return args.model, args.token, nil
@@ -40,20 +144,49 @@ func readTokenAndModel2GlobalOptions(args *GlobalOptions, host string) (NetgearM
 	if args.Verbose {
 		fmt.Println("reading token from: " + tokenFilename(args.TokenDir, host))
 	}
-	bytes, err := os.ReadFile(tokenFilename(args.TokenDir, host))
+	s, err := readSession(args, tokenFilename(args.TokenDir, host))
+	if err != nil {
+		return "", "", err
+	}
+	args.model = s.Model
+	args.token = s.Token
+	return args.model, args.token, nil
+}
+
+// readSession reads and decrypts a token file. Token files of former versions only contain 'model:token',
+// thus host, login time and last use are unknown.
+func readSession(args *GlobalOptions, fileName string) (*session, error) {
+	bytes, err := os.ReadFile(fileName)
 	if errors.Is(err, fs.ErrNotExist) {
-		return "", "", errors.New("no session (token) exists. please login first")
+		return nil, netgear.ErrNoSession
+	}
+	if err != nil {
+		return nil, err
 	}
-	data := strings.SplitN(string(bytes), separator, 2)
-	if len(data) != 2 {
-		return "", "", errors.New("you did an upgrade from a former ntgrcc version. please login again")
+	if isEncryptedToken(bytes) {
+		bytes, err = decryptTokenWithSecretFromArgs(args, bytes)
+		if err != nil {
+			return nil, err
+		}
 	}
-	if !isSupportedModel(data[0]) {
-		return "", "", errors.New("unknown model stored in token. please login again")
+	s := &session{}
+	if strings.HasPrefix(string(bytes), "{") {
+		err = json.Unmarshal(bytes, s)
+		if err != nil {
+			return nil, newCommandError(categoryNotLoggedIn, "the stored token is damaged. please login again")
+		}
+	} else {
+		data := strings.SplitN(string(bytes), separator, 2)
+		if len(data) != 2 {
+			return nil, newCommandError(categoryNotLoggedIn, "you did an upgrade from a former ntgrcc version. please login again")
+		}
+		s.Model = netgear.NetgearModel(data[0])
+		s.Token = data[1]
 	}
-	args.model = NetgearModel(data[0])
-	args.token = data[1]
-	return args.model, args.token, err
+	if !netgear.IsSupportedModel(string(s.Model)) {
+		return nil, newCommandError(categoryNotLoggedIn, "unknown model stored in token. please login again")
+	}
+	return s, nil
 }
 
 func ensureConfigPathExists(configDir string) error {
@@ -62,9 +195,29 @@ func ensureConfigPathExists(configDir string) error {
 	return err
 }
 
+// dotConfigDirName is the directory to store the tokens in.
+// When --token-dir is given, it's the '.config/ntgrrc' subdirectory, like in former versions,
+// otherwise the user's state directory is used, see defaultTokenDirName.
 func dotConfigDirName(configDir string) string {
 	if configDir == "" {
-		configDir = os.TempDir()
+		return defaultTokenDirName()
 	}
 	return filepath.Join(configDir, ".config", "ntgrrc")
 }
+
+// defaultTokenDirName follows the XDG base directory specification: $XDG_STATE_HOME/ntgrrc,
+// which defaults to ~/.local/state/ntgrrc. On Windows and MacOS, the user's config directory is used.
+func defaultTokenDirName() string {
+	if stateHome := os.Getenv("XDG_STATE_HOME"); stateHome != "" {
+		return filepath.Join(stateHome, "ntgrrc")
+	}
+	if runtime.GOOS != "windows" && runtime.GOOS != "darwin" {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, ".local", "state", "ntgrrc")
+		}
+	}
+	if configDir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(configDir, "ntgrrc")
+	}
+	return filepath.Join(os.TempDir(), ".config", "ntgrrc")
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"os"
+)
+
+const tokenPassphraseEnvVar = "NTGRRC_TOKEN_PASSPHRASE"
+
+// encrypted token files start with this prefix, followed by base64(salt | nonce | AES-GCM sealed data)
+const encryptedTokenPrefix = "ntgrrc-encrypted-v1:"
+
+const (
+	tokenSaltLength    = 16
+	tokenKeyIterations = 100_000
+)
+
+// tokenSecret returns the content of the --token-key-file or the passphrase from the NTGRRC_TOKEN_PASSPHRASE
+// environment variable. Without both, nil is returned, which means tokens are stored unencrypted.
+func tokenSecret(args *GlobalOptions) ([]byte, error) {
+	if args.TokenKeyFile != "" {
+		secret, err := os.ReadFile(args.TokenKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		secret = bytes.TrimRight(secret, "\r\n")
+		if len(secret) == 0 {
+			return nil, newCommandError(categoryInvalidArgument, "the token key file is empty")
+		}
+		return secret, nil
+	}
+	if passphrase := os.Getenv(tokenPassphraseEnvVar); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+	return nil, nil
+}
+
+func isEncryptedToken(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encryptedTokenPrefix))
+}
+
+func encryptToken(secret []byte, plaintext []byte) ([]byte, error) {
+	salt := make([]byte, tokenSaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newTokenCipher(secret, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+	payload := append(salt, nonce...)
+	payload = gcm.Seal(payload, nonce, plaintext, nil)
+	return []byte(encryptedTokenPrefix + base64.StdEncoding.EncodeToString(payload)), nil
+}
+
+func decryptToken(secret []byte, data []byte) ([]byte, error) {
+	payload, err := base64.StdEncoding.DecodeString(string(bytes.TrimPrefix(data, []byte(encryptedTokenPrefix))))
+	if err != nil {
+		return nil, newCommandError(categoryNotLoggedIn, "the stored token is damaged. please login again")
+	}
+	if len(payload) < tokenSaltLength {
+		return nil, newCommandError(categoryNotLoggedIn, "the stored token is damaged. please login again")
+	}
+	gcm, err := newTokenCipher(secret, payload[:tokenSaltLength])
+	if err != nil {
+		return nil, err
+	}
+	payload = payload[tokenSaltLength:]
+	if len(payload) < gcm.NonceSize() {
+		return nil, newCommandError(categoryNotLoggedIn, "the stored token is damaged. please login again")
+	}
+	plaintext, err := gcm.Open(nil, payload[:gcm.NonceSize()], payload[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, newCommandError(categoryNotLoggedIn, "unable to decrypt the stored token; wrong key or passphrase? otherwise, please login again")
+	}
+	return plaintext, nil
+}
+
+func decryptTokenWithSecretFromArgs(args *GlobalOptions, data []byte) ([]byte, error) {
+	secret, err := tokenSecret(args)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, newCommandError(categoryInvalidArgument, "the stored token is encrypted. please use --token-key-file or the %s environment variable", tokenPassphraseEnvVar)
+	}
+	return decryptToken(secret, data)
+}
+
+func newTokenCipher(secret []byte, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, string(secret), salt, tokenKeyIterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -1,8 +1,14 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
+	"github.com/nitram509/ntgrrc/netgear"
+
 	"github.com/corbym/gocrest/is"
 	"github.com/corbym/gocrest/then"
 )
@@ -10,8 +16,9 @@ import (
 func Test_storing_and_loading_a_token_also_preserves_the_model(t *testing.T) {
 	// setup
 	args := GlobalOptions{
-		Verbose: false,
-		model:   GS30xEPx,
+		Verbose:  false,
+		TokenDir: t.TempDir(),
+		model:    netgear.GS30xEPx,
 	}
 	const host = "ntgrrc-test-case-host"
 	// given
@@ -25,16 +32,17 @@ func Test_storing_and_loading_a_token_also_preserves_the_model(t *testing.T) {
 	// then
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, token, is.EqualTo("1234567890"))
-	then.AssertThat(t, model, is.EqualTo(GS30xEPx))
+	then.AssertThat(t, model, is.EqualTo(netgear.GS30xEPx))
 	then.AssertThat(t, args.token, is.EqualTo("1234567890"))
-	then.AssertThat(t, args.model, is.EqualTo(GS30xEPx))
+	then.AssertThat(t, args.model, is.EqualTo(netgear.GS30xEPx))
 }
 
 func Test_loading_a_token_with_model(t *testing.T) {
 	// setup
 	args := GlobalOptions{
-		Verbose: false,
-		model:   GS30xEPx,
+		Verbose:  false,
+		TokenDir: t.TempDir(),
+		model:    netgear.GS30xEPx,
 	}
 	const host = "ntgrrc-test-case-host"
 	// given
@@ -47,7 +55,97 @@ func Test_loading_a_token_with_model(t *testing.T) {
 	// then
 	then.AssertThat(t, err, is.Nil())
 	then.AssertThat(t, token, is.EqualTo("1234567890"))
-	then.AssertThat(t, model, is.EqualTo(GS30xEPx))
+	then.AssertThat(t, model, is.EqualTo(netgear.GS30xEPx))
 	then.AssertThat(t, args.token, is.EqualTo("1234567890"))
-	then.AssertThat(t, args.model, is.EqualTo(GS30xEPx))
+	then.AssertThat(t, args.model, is.EqualTo(netgear.GS30xEPx))
+}
+
+func Test_token_file_is_only_readable_by_the_user(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not supported on Windows")
+	}
+	// setup
+	args := GlobalOptions{
+		TokenDir: t.TempDir(),
+		model:    netgear.GS30xEPx,
+	}
+	const host = "ntgrrc-test-case-host"
+	fileName := tokenFilename(args.TokenDir, host)
+	err := ensureConfigPathExists(args.TokenDir)
+	then.AssertThat(t, err, is.Nil())
+	// given
+	err = os.WriteFile(fileName, []byte("GS30xEPx:old"), 0644)
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	err = storeToken(&args, host, "1234567890")
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	info, err := os.Stat(fileName)
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, info.Mode().Perm(), is.EqualTo(os.FileMode(0600)))
+	files, err := os.ReadDir(dotConfigDirName(args.TokenDir))
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, len(files), is.EqualTo(1).Reason("no temporary file is left"))
+}
+
+func Test_default_token_dir_follows_xdg_state_home(t *testing.T) {
+	stateHome := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", stateHome)
+
+	then.AssertThat(t, dotConfigDirName(""), is.EqualTo(filepath.Join(stateHome, "ntgrrc")))
+}
+
+func Test_storing_and_loading_an_encrypted_token(t *testing.T) {
+	// setup
+	keyFile := filepath.Join(t.TempDir(), "key")
+	err := os.WriteFile(keyFile, []byte("my secret key\n"), 0600)
+	then.AssertThat(t, err, is.Nil())
+	args := GlobalOptions{
+		TokenDir:     t.TempDir(),
+		TokenKeyFile: keyFile,
+		model:        netgear.GS316EP,
+	}
+	const host = "ntgrrc-test-case-host"
+	// given
+	err = storeToken(&args, host, "1234567890")
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	args.model = ""
+	model, token, err := readTokenAndModel2GlobalOptions(&args, host)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, token, is.EqualTo("1234567890"))
+	then.AssertThat(t, model, is.EqualTo(netgear.GS316EP))
+	data, err := os.ReadFile(tokenFilename(args.TokenDir, host))
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, strings.HasPrefix(string(data), encryptedTokenPrefix), is.True())
+	then.AssertThat(t, strings.Contains(string(data), "1234567890"), is.False())
+}
+
+func Test_loading_an_encrypted_token_requires_the_right_passphrase(t *testing.T) {
+	// setup
+	t.Setenv(tokenPassphraseEnvVar, "correct passphrase")
+	args := GlobalOptions{
+		TokenDir: t.TempDir(),
+		model:    netgear.GS30xEPx,
+	}
+	const host = "ntgrrc-test-case-host"
+	// given
+	err := storeToken(&args, host, "1234567890")
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	args.model = ""
+	t.Setenv(tokenPassphraseEnvVar, "wrong passphrase")
+	_, _, errWrongPassphrase := readTokenAndModel2GlobalOptions(&args, host)
+	t.Setenv(tokenPassphraseEnvVar, "")
+	_, _, errNoPassphrase := readTokenAndModel2GlobalOptions(&args, host)
+
+	// then
+	then.AssertThat(t, errWrongPassphrase, is.Not(is.Nil()))
+	then.AssertThat(t, errNoPassphrase, is.Not(is.Nil()))
 }
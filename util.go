@@ -1,9 +1,11 @@
 package main
 
 import (
-	"errors"
+	"fmt"
+	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func max(a int, b int) int {
@@ -13,6 +15,12 @@ func max(a int, b int) int {
 	return a
 }
 
+// exactFloat64 converts without the float32's binary representation noise, e.g. 5.8 instead of 5.800000190734863
+func exactFloat64(value float32) float64 {
+	exact, _ := strconv.ParseFloat(strconv.FormatFloat(float64(value), 'f', -1, 32), 64)
+	return exact
+}
+
 func suffixToLength(s string, length int) string {
 	if len(s) < length {
 		diff := length - len(s)
@@ -21,33 +29,26 @@ func suffixToLength(s string, length int) string {
 	return s
 }
 
-func parseFloat32(text string) float32 {
-	i64, _ := strconv.ParseFloat(text, 32)
-	return float32(i64)
+// logEvent prints a line with the local time, like a log file, for long-running commands
+func logEvent(format string, a ...any) {
+	fmt.Printf("%s %s\n", time.Now().Format(time.DateTime), fmt.Sprintf(format, a...))
 }
 
-func parseInt32(text string) int32 {
-	i64, _ := strconv.ParseInt(text, 10, 32)
-	return int32(i64)
+// logEventError prints the error with the local time on stderr, for long-running commands
+func logEventError(name string, err error) {
+	fmt.Fprintf(os.Stderr, "%s Error: %s: %s\n", time.Now().Format(time.DateTime), name, err.Error())
 }
 
-func ensureModelIs30x(args *GlobalOptions, host string) error {
-	model, _, err := readTokenAndModel2GlobalOptions(args, host)
-	if err != nil {
-		return err
-	}
-	if !isModel30x(model) {
-		return errors.New("This command is not yet supported for your Netgear model. " +
-			"You might want to support the project by creating an issue on Github")
-	}
-	return nil
+// daemonOptions sets the options of long-running commands, which outlive the sessions on the switches:
+// expired sessions are renewed by logging in again, see --re-login
+func daemonOptions(args *GlobalOptions) {
+	args.ReLogin = true
 }
 
-func filter[T any](ss []T, test func(T) bool) (ret []T) {
-	for _, s := range ss {
-		if test(s) {
-			ret = append(ret, s)
-		}
+// checkInterval rejects the --interval of long-running commands, which a ticker can't run on
+func checkInterval(interval time.Duration) error {
+	if interval <= 0 {
+		return newCommandError(categoryInvalidArgument, "invalid --interval %s, it must be positive", interval)
 	}
-	return
+	return nil
 }
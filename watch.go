@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"golang.org/x/term"
+	"os"
+	"strings"
+	"time"
+)
+
+// WatchOptions poll the switches repeatedly
+type WatchOptions struct {
+	Watch time.Duration `help:"poll the switches on this interval, e.g. '5s', and redraw the table, highlighting the changes; with JSON output, print a JSON document per line" placeholder:"INTERVAL"`
+}
+
+const (
+	ansiClearScreen = "\033[H\033[2J"
+	ansiHighlight   = "\033[1;7m"
+	ansiReset       = "\033[0m"
+)
+
+// watchAll polls the switches on the interval, until interrupted. On a terminal, markdown tables are redrawn in place,
+// highlighting the cells, which changed since the previous poll; other formats are printed for each poll.
+// The sessions are re-used for all polls. Failed polls are reported, but don't stop watching.
+func (selector *SwitchSelector) watchAll(args *GlobalOptions, parallel int, options *TableOptions, interval time.Duration, item string, query switchQuery) error {
+	targets, err := selector.targets(args)
+	if err != nil {
+		return err
+	}
+	multiple := selector.isMultiple()
+	output := tableOutput(args, tableAddress(targets, multiple))
+	redraw := output.format == MarkdownFormat && term.IsTerminal(int(os.Stdout.Fd()))
+	clients := newClientCache()
+	changes := tableChanges{}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		polled := time.Now()
+		results, t, err := queryTable(args, targets, multiple, parallel, options, item, clients, query)
+		if errorCategoryOf(err) == categoryInvalidArgument {
+			return err
+		}
+		title := fmt.Sprintf("Every %s: %s, %s\n\n", interval, strings.ReplaceAll(item, "_", " "), polled.Format(time.DateTime))
+		switch {
+		case redraw:
+			screen := ansiClearScreen + title
+			if err == nil && len(t.columns) > 0 {
+				screen += markdownTable(t.header(), t.content(), changes.highlight(t))
+			}
+			fmt.Print(screen)
+			printFailures(results)
+		case output.format == MarkdownFormat:
+			fmt.Print(title)
+			fallthrough
+		default:
+			if err == nil {
+				err = printSwitchTable(output, results, t)
+				if errorCategoryOf(err) == categoryInvalidArgument {
+					return err
+				}
+			}
+		}
+		if err != nil {
+			reportError(output.format, err)
+		}
+		<-ticker.C
+	}
+}
+
+// tableChanges finds the cells, which changed since the previous poll; the rows are identified by the switch and port
+type tableChanges struct {
+	previous map[string][]string
+}
+
+// highlight remembers the table's content and returns the decoration of the markdown table's changed cells
+func (changes *tableChanges) highlight(t table) func(row int, column int, cell string) string {
+	content := t.content()
+	current := map[string][]string{}
+	changed := map[int]map[int]bool{}
+	for i, r := range t.records {
+		key := rowKey(t, r, i)
+		current[key] = content[i]
+		previous, ok := changes.previous[key]
+		if !ok {
+			continue
+		}
+		for c := range content[i] {
+			if c < len(previous) && previous[c] != content[i][c] {
+				if changed[i] == nil {
+					changed[i] = map[int]bool{}
+				}
+				changed[i][c] = true
+			}
+		}
+	}
+	changes.previous = current
+	return func(row int, column int, cell string) string {
+		if changed[row][column] {
+			return ansiHighlight + cell + ansiReset
+		}
+		return cell
+	}
+}
+
+// rowKey identifies a row by the switch and port; without these columns, by its position
+func rowKey(t table, r record, position int) string {
+	var key []string
+	for i, c := range t.columns {
+		if c.name == "switch" || c.name == "port_id" {
+			key = append(key, r.texts[i])
+		}
+	}
+	if len(key) == 0 {
+		return fmt.Sprint(position)
+	}
+	return strings.Join(key, "/")
+}
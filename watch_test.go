@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/ntgrrc/netgear"
+)
+
+func Test_watch_highlights_the_cells_changed_since_the_previous_poll(t *testing.T) {
+	changes := tableChanges{}
+	first := poePortStatusTable("office-1", []netgear.PoePortStatus{
+		{PortIndex: 1, PoePortStatus: "Delivering Power", PowerInWatt: 5.8},
+		{PortIndex: 2, PoePortStatus: "Searching"},
+	})
+	second := poePortStatusTable("office-1", []netgear.PoePortStatus{
+		{PortIndex: 2, PoePortStatus: "Delivering Power", PowerInWatt: 3.1},
+		{PortIndex: 1, PoePortStatus: "Delivering Power", PowerInWatt: 5.8},
+	})
+
+	firstPoll := markdownTable(first.header(), first.content(), changes.highlight(first))
+	secondPoll := markdownTable(second.header(), second.content(), changes.highlight(second))
+
+	then.AssertThat(t, strings.Contains(firstPoll, ansiHighlight), is.False())
+	lines := strings.Split(secondPoll, "\n")
+	then.AssertThat(t, lines[2], is.StringContaining("| "+ansiHighlight+"Delivering Power"+ansiReset+" |"))
+	then.AssertThat(t, lines[2], is.StringContaining("| "+ansiHighlight+"3.10"+ansiReset+"        |"))
+	then.AssertThat(t, strings.Contains(lines[3], ansiHighlight), is.False())
+}
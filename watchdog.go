@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PoeWatchdog checks the health of the device behind a port, e.g. an IP camera, and power cycles the port,
+// when the device failed several checks in a row
+type PoeWatchdog struct {
+	Name string `yaml:"name"`
+	// Switch is a name from the config file or an address
+	Switch string `yaml:"switch"`
+	Port   int    `yaml:"port"`
+	// the health check is one of: TCP connects to host:port, HTTP gets the URL, Exec runs the command with the shell
+	TCP  string `yaml:"tcp"`
+	HTTP string `yaml:"http"`
+	Exec string `yaml:"exec"`
+	// Interval between the checks, default 30s; Timeout of a single check, default 5s
+	Interval time.Duration `yaml:"interval"`
+	Timeout  time.Duration `yaml:"timeout"`
+	// Failures is the number of failed checks in a row, before the port is power cycled, default 3
+	Failures int `yaml:"failures"`
+	// OffTime keeps the power off for this duration, see "poe cycle --off-time"
+	OffTime time.Duration `yaml:"off-time"`
+	// Backoff is the time for the device to start, before it's checked again after a power cycle, default 2m.
+	// It doubles with every power cycle, which didn't help, up to an hour.
+	Backoff time.Duration `yaml:"backoff"`
+	// MaxCyclesPerHour limits the power cycles of the port, default 3
+	MaxCyclesPerHour int `yaml:"max-cycles-per-hour"`
+}
+
+const maxWatchdogBackoff = time.Hour
+
+func (watchdog *PoeWatchdog) validate() error {
+	checks := 0
+	for _, check := range []string{watchdog.TCP, watchdog.HTTP, watchdog.Exec} {
+		if check != "" {
+			checks++
+		}
+	}
+	switch {
+	case watchdog.Switch == "":
+		return fmt.Errorf("has no switch")
+	case watchdog.Port < 1:
+		return fmt.Errorf("has an invalid port %d", watchdog.Port)
+	case checks != 1:
+		return fmt.Errorf("needs exactly one health check of 'tcp', 'http' or 'exec'")
+	case watchdog.Interval < 0 || watchdog.Timeout < 0 || watchdog.OffTime < 0 || watchdog.Backoff < 0:
+		return fmt.Errorf("has a negative duration")
+	case watchdog.Failures < 0 || watchdog.MaxCyclesPerHour < 0:
+		return fmt.Errorf("has a negative number of failures or cycles")
+	}
+	return nil
+}
+
+// withDefaults sets the defaults of all settings, which are not given
+func (watchdog PoeWatchdog) withDefaults() PoeWatchdog {
+	if watchdog.Interval == 0 {
+		watchdog.Interval = 30 * time.Second
+	}
+	if watchdog.Timeout == 0 {
+		watchdog.Timeout = 5 * time.Second
+	}
+	if watchdog.Failures == 0 {
+		watchdog.Failures = 3
+	}
+	if watchdog.Backoff == 0 {
+		watchdog.Backoff = 2 * time.Minute
+	}
+	if watchdog.MaxCyclesPerHour == 0 {
+		watchdog.MaxCyclesPerHour = 3
+	}
+	return watchdog
+}
+
+// check runs the health check; an error means, the device is not healthy
+func (watchdog *PoeWatchdog) check() error {
+	ctx, cancel := context.WithTimeout(context.Background(), watchdog.Timeout)
+	defer cancel()
+	switch {
+	case watchdog.TCP != "":
+		conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", watchdog.TCP)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	case watchdog.HTTP != "":
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, watchdog.HTTP, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("HTTP status %s", resp.Status)
+		}
+		return nil
+	default:
+		output, err := shellCommand(ctx, watchdog.Exec).CombinedOutput()
+		if err != nil && len(output) > 0 {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+		}
+		return err
+	}
+}
+
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// watchdogAction is the outcome of a health check
+type watchdogAction int
+
+const (
+	watchdogHealthy watchdogAction = iota
+	watchdogRecovered
+	watchdogFailed
+	watchdogCycle
+	watchdogLimitReached
+	// watchdogStillLimited is a failed check, after the limit of power cycles was reported
+	watchdogStillLimited
+)
+
+// watchdogState counts the failed checks in a row and the power cycles of the last hour
+type watchdogState struct {
+	watchdog  PoeWatchdog
+	failures  int
+	unhealthy bool
+	limited   bool
+	backoff   time.Duration
+	// resumeAt is the time, the device is checked again after a power cycle
+	resumeAt time.Time
+	cycles   []time.Time
+}
+
+func newWatchdogState(watchdog PoeWatchdog) *watchdogState {
+	return &watchdogState{watchdog: watchdog, backoff: watchdog.Backoff}
+}
+
+// checked records the result of a health check and decides, whether to power cycle the port
+func (state *watchdogState) checked(now time.Time, err error) watchdogAction {
+	if err == nil {
+		recovered := state.unhealthy
+		state.failures = 0
+		state.unhealthy = false
+		state.limited = false
+		state.backoff = state.watchdog.Backoff
+		if recovered {
+			return watchdogRecovered
+		}
+		return watchdogHealthy
+	}
+	state.failures++
+	state.unhealthy = true
+	if state.failures < state.watchdog.Failures {
+		return watchdogFailed
+	}
+	var lastHour []time.Time
+	for _, cycle := range state.cycles {
+		if now.Sub(cycle) < time.Hour {
+			lastHour = append(lastHour, cycle)
+		}
+	}
+	state.cycles = lastHour
+	if len(state.cycles) >= state.watchdog.MaxCyclesPerHour {
+		if state.limited {
+			return watchdogStillLimited
+		}
+		state.limited = true
+		return watchdogLimitReached
+	}
+	state.limited = false
+	state.cycles = append(state.cycles, now)
+	state.failures = 0
+	state.resumeAt = now.Add(state.backoff)
+	state.backoff = min(state.backoff*2, maxWatchdogBackoff)
+	return watchdogCycle
+}
+
+type PoeWatchdogCommand struct {
+	DryRun bool `help:"only log, which ports would be power cycled"`
+}
+
+// Run checks the devices of all watchdogs concurrently, until interrupted, and power cycles their ports, when needed.
+func (poe *PoeWatchdogCommand) Run(args *GlobalOptions) error {
+	daemonOptions(args)
+	watchdogs := args.configFile().Watchdogs
+	if len(watchdogs) == 0 {
+		return newCommandError(categoryInvalidArgument, "there are no watchdogs in the config file")
+	}
+	runner := watchdogRunner{args: args, clients: newClientCache(), dryRun: poe.DryRun}
+	if !args.Quiet {
+		logEvent("running %d watchdog(s)", len(watchdogs))
+	}
+	wg := sync.WaitGroup{}
+	for _, watchdog := range watchdogs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runner.run(watchdog.withDefaults())
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+type watchdogRunner struct {
+	args      *GlobalOptions
+	clients   *clientCache
+	dryRun    bool
+	hostLocks hostLocks
+}
+
+func (runner *watchdogRunner) run(watchdog PoeWatchdog) {
+	state := newWatchdogState(watchdog)
+	ticker := time.NewTicker(watchdog.Interval)
+	defer ticker.Stop()
+	for {
+		now := time.Now()
+		if !now.Before(state.resumeAt) {
+			err := watchdog.check()
+			switch state.checked(now, err) {
+			case watchdogRecovered:
+				logEvent("%s: healthy again", watchdog.Name)
+			case watchdogFailed:
+				logEvent("%s: check failed (%d of %d): %s", watchdog.Name, state.failures, watchdog.Failures, err.Error())
+			case watchdogLimitReached:
+				logEvent("%s: check failed: %s; not power cycling, the port was power cycled %d time(s) in the last hour already",
+					watchdog.Name, err.Error(), len(state.cycles))
+			case watchdogCycle:
+				logEvent("%s: check failed %d time(s) in a row: %s", watchdog.Name, watchdog.Failures, err.Error())
+				runner.powerCycle(watchdog, state.resumeAt)
+			}
+		}
+		<-ticker.C
+	}
+}
+
+func (runner *watchdogRunner) powerCycle(watchdog PoeWatchdog, resumeAt time.Time) {
+	target := runner.args.config.lookupSwitch(watchdog.Switch)
+	if runner.dryRun {
+		logEvent("%s: would power cycle port %d of %s (dry run)", watchdog.Name, watchdog.Port, target.Name)
+		return
+	}
+	defer runner.hostLocks.lock(target.Address)()
+
+	client, switchArgs, created, err := runner.clients.client(runner.args, target)
+	if err == nil {
+		command := PoeCyclePowerCommand{OffTime: watchdog.OffTime}
+		_, err = command.cycle(client, []int{watchdog.Port})
+	}
+	if err != nil {
+		logEventError(watchdog.Name, fmt.Errorf("power cycling port %d of %s failed: %w", watchdog.Port, target.Name, err))
+		return
+	}
+	if created {
+		_ = touchToken(switchArgs, target.Address)
+	}
+	logEvent("%s: power cycled port %d of %s, checking again at %s", watchdog.Name, watchdog.Port, target.Name, resumeAt.Format(time.TimeOnly))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/ntgrrc/netgear"
+)
+
+func Test_watchdog_power_cycles_after_failures_in_a_row_with_backoff_and_limit(t *testing.T) {
+	state := newWatchdogState(PoeWatchdog{Failures: 2, Backoff: time.Minute, MaxCyclesPerHour: 2})
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+	failed := http.ErrHandlerTimeout
+
+	then.AssertThat(t, state.checked(now, nil), is.EqualTo(watchdogHealthy))
+	then.AssertThat(t, state.checked(now, failed), is.EqualTo(watchdogFailed))
+	then.AssertThat(t, state.checked(now, failed), is.EqualTo(watchdogCycle))
+	then.AssertThat(t, state.resumeAt, is.EqualTo(now.Add(time.Minute)))
+
+	now = now.Add(time.Minute)
+	then.AssertThat(t, state.checked(now, failed), is.EqualTo(watchdogFailed))
+	then.AssertThat(t, state.checked(now, failed), is.EqualTo(watchdogCycle))
+	then.AssertThat(t, state.resumeAt, is.EqualTo(now.Add(2*time.Minute)))
+
+	now = now.Add(2 * time.Minute)
+	then.AssertThat(t, state.checked(now, failed), is.EqualTo(watchdogFailed))
+	then.AssertThat(t, state.checked(now, failed), is.EqualTo(watchdogLimitReached))
+	then.AssertThat(t, state.checked(now, failed), is.EqualTo(watchdogStillLimited))
+	then.AssertThat(t, state.checked(now.Add(time.Hour), failed), is.EqualTo(watchdogCycle))
+	then.AssertThat(t, state.checked(now.Add(time.Hour), nil), is.EqualTo(watchdogRecovered))
+	then.AssertThat(t, state.backoff, is.EqualTo(time.Minute))
+}
+
+func Test_watchdog_health_checks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	check := func(watchdog PoeWatchdog) error {
+		watchdog = watchdog.withDefaults()
+		return watchdog.check()
+	}
+
+	then.AssertThat(t, check(PoeWatchdog{TCP: strings.TrimPrefix(server.URL, "http://")}), is.Nil())
+	then.AssertThat(t, check(PoeWatchdog{TCP: "localhost:1"}), is.Not(is.Nil()))
+	then.AssertThat(t, check(PoeWatchdog{HTTP: server.URL}), is.Nil())
+	then.AssertThat(t, check(PoeWatchdog{HTTP: server.URL + "/hung"}).Error(), is.EqualTo("HTTP status 500 Internal Server Error"))
+	if runtime.GOOS != "windows" {
+		then.AssertThat(t, check(PoeWatchdog{Exec: "exit 0"}), is.Nil())
+		then.AssertThat(t, check(PoeWatchdog{Exec: "echo no answer; exit 1"}).Error(), is.EqualTo("exit status 1: no answer"))
+	}
+}
+
+func Test_config_file_with_watchdog_without_health_check_is_rejected(t *testing.T) {
+	_, err := loadTestConfig(t, testConfig+`
+watchdogs:
+  - name: camera
+    switch: office-1
+    port: 3
+`)
+
+	then.AssertThat(t, err.Error(), is.EqualTo("config file: watchdog 'camera' needs exactly one health check of 'tcp', 'http' or 'exec'"))
+}
+
+func Test_watchdog_power_cycles_the_port(t *testing.T) {
+	// setup
+	sw, args, host := loggedInEmulatorSwitch(t, netgear.GS308EPP)
+	sw.SetPowerUpDelay(time.Hour)
+	runner := watchdogRunner{args: args, clients: newClientCache()}
+
+	// when
+	runner.powerCycle(PoeWatchdog{Name: "camera", Switch: host, Port: 1}, time.Now())
+
+	// then
+	client, err := newClient(args, host)
+	then.AssertThat(t, err, is.Nil())
+	statuses, err := client.PoeStatus()
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, statuses[0].PoePortStatus, is.EqualTo("Searching"))
+}